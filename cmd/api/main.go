@@ -1,29 +1,41 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
-	"os"
 
+	"github.com/agugliotta/dog-app-bff/internal/config"
+	"github.com/agugliotta/dog-app-bff/internal/events"
 	"github.com/agugliotta/dog-app-bff/internal/handlers"
+	"github.com/agugliotta/dog-app-bff/internal/middleware"
+	"github.com/agugliotta/dog-app-bff/internal/notify"
+	"github.com/agugliotta/dog-app-bff/internal/scheduler"
 	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/webhooks"
 )
 
 // APIServer representa nuestra aplicación de servidor HTTP.
 // Contiene la dirección de escucha y una referencia a nuestro store de datos.
 type APIServer struct {
-	addr       string
-	breedStore store.BreedStore // Nuestra interfaz de store, que será una instancia de PostgresStore
-	petStore   store.PetStore
+	addr  string
+	store store.AppStore
+	// events es el bus donde los handlers publican los cambios confirmados.
+	events *events.Bus
+	// eventLog alimenta el stream de eventos.
+	eventLog *events.Log
+	cfg      *config.Config
 }
 
 // NewAPIServer crea una nueva instancia de APIServer.
-// Recibe la dirección en la que escuchará y la implementación del store a usar.
-func NewAPIServer(addr string, bs store.BreedStore, ps store.PetStore) *APIServer {
+// Recibe la configuración cargada y la implementación del store a usar.
+func NewAPIServer(cfg *config.Config, s store.AppStore, bus *events.Bus, eventLog *events.Log) *APIServer {
 	return &APIServer{
-		addr:       addr,
-		breedStore: bs,
-		petStore:   ps,
+		addr:     cfg.Addr,
+		store:    s,
+		events:   bus,
+		eventLog: eventLog,
+		cfg:      cfg,
 	}
 }
 
@@ -32,36 +44,107 @@ func (s *APIServer) Run() {
 	// Inicializa el router estándar de Go.
 	router := http.NewServeMux()
 
-	// Registra todas nuestras rutas, pasando el router y el store.
-	handlers.RegisterRoutes(router, s.breedStore, s.petStore)
+	// Registra todas nuestras rutas, pasando el router y el store. Cada grupo de rutas
+	// recibe solo las interfaces del store que usa.
+	handlers.RegisterRoutes(router, s.store, s.store, s.events)
+	handlers.RegisterMedicalRoutes(router, s.store)
+	handlers.RegisterMedicationRoutes(router, s.store)
+	handlers.RegisterBulkRoutes(router, s.store, s.store, s.store, s.events)
+	handlers.RegisterTransferRoutes(router, s.store, s.store, s.store, s.events)
+	handlers.RegisterSyncRoutes(router, s.store, s.store, s.store, s.events)
+
+	// Las rutas de administración van en su propio router, protegido por sujeto.
+	adminRouter := http.NewServeMux()
+	handlers.RegisterAdminRoutes(adminRouter, s.store)
+	handlers.RegisterWebhookRoutes(adminRouter, s.store, s.cfg.Scheduler.Enabled)
+	router.Handle("/api/v1/admin/", middleware.RequireSubject(s.cfg.AdminSubjects)(adminRouter))
+
+	// El stream lleva los cambios de las mascotas del sujeto, así que solo lo abren los
+	// sujetos autenticados.
+	streamRouter := http.NewServeMux()
+	handlers.RegisterStreamRoutes(streamRouter, s.eventLog, s.cfg.StreamHeartbeat)
+	router.Handle("GET /api/v1/pets/events", middleware.RequireAuthenticated()(streamRouter))
+
+	// Envuelve el router con los middlewares globales. CORS va primero para que las
+	// solicitudes preflight no consuman cuota del rate limiter.
+	handler := middleware.Chain(router,
+		middleware.CORS(s.cfg.CORS),
+		middleware.RequestID(),
+		middleware.Identity(s.cfg.Identity),
+		middleware.AuditContext(),
+		middleware.ReadYourWrites(),
+		middleware.RateLimit(s.cfg.RateLimit),
+		middleware.Idempotency(s.cfg.Idempotency),
+	)
 
 	log.Printf("Servidor iniciando en %s...", s.addr)
-	// Inicia el servidor HTTP, usando nuestro router para manejar las solicitudes.
-	err := http.ListenAndServe(s.addr, router)
+	// Inicia el servidor HTTP, usando nuestro router (ya envuelto) para manejar las solicitudes.
+	err := http.ListenAndServe(s.addr, handler)
 	if err != nil {
 		log.Fatalf("El servidor falló al iniciar: %v", err)
 	}
 }
 
 func main() {
-	// 1. Obtener la cadena de conexión de PostgreSQL de una variable de entorno.
-	connStr := os.Getenv("DB_CONN_STRING")
-	if connStr == "" {
+	// 1. Cargar la configuración desde las variables de entorno.
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error al cargar la configuración: %v", err)
+	}
+	if cfg.StoreDriver != "sqlite" && cfg.Postgres.ConnString == "" {
 		log.Fatal("La variable de entorno DB_CONN_STRING no está configurada. Por favor, configúrala.")
 	}
 
-	// 2. Inicializar el store de PostgreSQL.
-	// Esto establece la conexión a la base de datos.
-	pgStore, err := store.NewPostgresStore(connStr)
+	// 2. Inicializar el store (PostgreSQL por defecto).
+	// Esto establece la conexión a la base de datos, reintentando mientras arranca.
+	appStore, err := store.Open(context.Background(), cfg.StoreDriver, cfg.Postgres, cfg.SQLitePath)
 	if err != nil {
-		log.Fatalf("Error al inicializar el store de PostgreSQL: %v", err)
+		log.Fatalf("Error al inicializar el store: %v", err)
 	}
 	// Asegúrate de cerrar la conexión a la base de datos cuando la aplicación se detenga.
-	defer pgStore.Close() // Esto se ejecutará cuando main() termine.
+	defer appStore.Close() // Esto se ejecutará cuando main() termine.
+
+	// Aplica las migraciones pendientes antes de aceptar solicitudes.
+	if err := appStore.Migrate(); err != nil {
+		log.Fatalf("Error al aplicar las migraciones: %v", err)
+	}
+
+	// 3. Arrancar en segundo plano el planificador de recordatorios y de entregas de
+	// webhooks, que guarda sus trabajos en el mismo store. Los eventos para los webhooks
+	// los guarda el store junto con cada cambio; sin planificador esperan en la cola.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := events.NewBus()
+	if cfg.Scheduler.Enabled {
+		notifier, err := notify.New(cfg.Notifier)
+		if err != nil {
+			log.Fatalf("Error al configurar los avisos: %v", err)
+		}
+		sched := scheduler.New(appStore, cfg.Scheduler)
+		scheduler.NewReminders(appStore, appStore, appStore, notifier, cfg.Reminders).Register(sched)
+		dispatcher := webhooks.NewDispatcher(appStore, cfg.Webhooks)
+		dispatcher.Register(sched)
+		go sched.Run(ctx)
+	}
+
+	// El stream de eventos lee de un registro de los últimos eventos. Con Postgres, los
+	// eventos de todas las instancias llegan a ese registro por LISTEN/NOTIFY; con los demás
+	// stores, solo los de esta instancia.
+	eventLog := events.NewLog(cfg.EventLogSize)
+	if ps, ok := appStore.(store.PubSub); ok {
+		bus.Subscribe(events.Forward(ps, events.Channel))
+		go func() {
+			if err := events.Receive(ctx, ps, events.Channel, eventLog.Publish); err != nil {
+				log.Printf("Error al escuchar los eventos de las demás instancias: %v", err)
+			}
+		}()
+	} else {
+		bus.Subscribe(eventLog.Publish)
+	}
 
-	// 3. Crear una nueva instancia de APIServer, inyectando el store de PostgreSQL.
-	server := NewAPIServer(":8080", pgStore, pgStore)
+	// 4. Crear una nueva instancia de APIServer, inyectando el store.
+	server := NewAPIServer(cfg, appStore, bus, eventLog)
 
-	// 4. Iniciar el servidor.
+	// 5. Iniciar el servidor.
 	server.Run()
 }
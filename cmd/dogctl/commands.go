@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/config"
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/fixtures"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/transfer"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// seed carga los conjuntos de fixtures indicados y, con -file, un archivo de fixtures
+// propio, en una transacción (ver store.Seed).
+func seed(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+	fs := newFlags("seed", errOut)
+	sets := fs.String("set", fixtures.Base, "comma-separated embedded fixture sets to load, in order: "+strings.Join(fixtures.Names(), ", "))
+	file := fs.String("file", "", "fixture file to load after the sets")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	var parts []*fixtures.Set
+	if *sets != "" {
+		set, err := fixtures.Load(strings.Split(*sets, ",")...)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, set)
+	}
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		set, err := fixtures.Read(f)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, set)
+	}
+	set, err := fixtures.Merge(parts...)
+	if err != nil {
+		return err
+	}
+
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	report, err := store.Seed(ctx, s, set)
+	if err != nil {
+		return err
+	}
+	for _, c := range []struct {
+		name   string
+		counts store.SeedCounts
+	}{{"breeds", report.Breeds}, {"pets", report.Pets}} {
+		fmt.Fprintf(out, "%s: %d inserted, %d updated, %d unchanged\n", c.name, c.counts.Inserted, c.counts.Updated, c.counts.Unchanged)
+	}
+	return nil
+}
+
+// seedBreeds inserta o actualiza las razas de un archivo, todas en una transacción. Se
+// puede repetir: las razas que no cambiaron no se tocan ni se auditan.
+func seedBreeds(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+	fs := newFlags("breeds seed", errOut)
+	file := fs.String("file", "", `CSV, JSON or NDJSON file with the breeds, or "-" for stdin`)
+	format := fs.String("format", "", `"csv" or "ndjson" (also for a JSON array); by default, from the file extension`)
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		fmt.Fprintln(fs.Output(), "-file is required")
+		fs.Usage()
+		return errUsage
+	}
+	if *format == "" {
+		*format = formatFromExt(*file)
+	}
+
+	r := io.Reader(os.Stdin)
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	breeds, err := transfer.ReadBreeds(r, *format)
+	if err != nil {
+		return err
+	}
+
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	counts := map[store.UpsertResult]int{}
+	err = s.WithinTx(ctx, func(tx store.Tx) error {
+		for _, b := range breeds {
+			res, err := tx.UpsertBreed(ctx, b)
+			if err != nil {
+				return fmt.Errorf("breed %q: %w", b.ID, err)
+			}
+			counts[res]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d breeds: %d inserted, %d updated, %d unchanged\n",
+		len(breeds), counts[store.Inserted], counts[store.Updated], counts[store.Unchanged])
+	return nil
+}
+
+// formatFromExt deduce el formato de un archivo de razas por su extensión.
+func formatFromExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return transfer.FormatCSV
+	default:
+		return transfer.FormatNDJSON
+	}
+}
+
+func listPets(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+	fs := newFlags("pets list", errOut)
+	asJSON := fs.Bool("json", false, "print the pets as a JSON array")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	pets, err := s.GetPets(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(pets)
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tBIRTH\tBREED\tVERSION")
+	for _, p := range pets {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", p.ID, p.Name, p.Birth.Format(time.DateOnly), p.Breed.ID, p.Version)
+	}
+	return tw.Flush()
+}
+
+func createPet(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+	fs := newFlags("pets create", errOut)
+	name := fs.String("name", "", "name of the pet")
+	birth := fs.String("birth", "", "date of birth, as YYYY-MM-DD")
+	breed := fs.String("breed", "", "breed ID")
+	owner := fs.String("owner", "", "authenticated subject that owns the pet; by default, none")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" || *birth == "" || *breed == "" {
+		fmt.Fprintln(fs.Output(), "-name, -birth and -breed are required")
+		fs.Usage()
+		return errUsage
+	}
+	birthDate, err := time.Parse(time.DateOnly, *birth)
+	if err != nil {
+		return errors.New("bad date of birth format, use YYYY-MM-DD")
+	}
+	breedID, err := types.ParseBreedID(*breed)
+	if err != nil {
+		return err
+	}
+
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	// El dueño se guarda como el sujeto del cambio, igual que en la API.
+	meta := audit.FromContext(ctx)
+	meta.Subject = strings.TrimSpace(*owner)
+	pet, err := s.CreatePet(audit.WithMeta(ctx, meta), strings.TrimSpace(*name), birthDate, breedID)
+	if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrForeignKeyViolation) {
+		return fmt.Errorf("breed %q not found", breedID)
+	}
+	if err != nil {
+		return err
+	}
+	publishPetEvent(ctx, s, errOut, events.PetCreated, pet)
+	fmt.Fprintln(out, pet.ID)
+	return nil
+}
+
+// deletePets borra las mascotas indicadas en una transacción: si alguna no existe, no se
+// borra ninguna.
+func deletePets(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+	fs := newFlags("pets delete", errOut)
+	version := fs.Int64("version", store.AnyVersion, "delete the pet only if it has this version (only with a single ID)")
+	if err := parseFlags(fs, args, 1, -1); err != nil {
+		return err
+	}
+	if *version != store.AnyVersion && fs.NArg() > 1 {
+		fmt.Fprintln(fs.Output(), "-version can only be used with a single ID")
+		return errUsage
+	}
+	ids := make([]types.PetID, fs.NArg())
+	for i, arg := range fs.Args() {
+		id, err := types.ParsePetID(arg)
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+	}
+
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	deleted := make([]*types.Pet, len(ids))
+	err = s.WithinTx(ctx, func(tx store.Tx) error {
+		for i, id := range ids {
+			var err error
+			deleted[i], err = tx.DeletePet(ctx, id, *version)
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				return fmt.Errorf("pet %s not found", id)
+			case errors.Is(err, store.ErrVersionConflict):
+				return fmt.Errorf("pet %s is not at version %d", id, *version)
+			case err != nil:
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, pet := range deleted {
+		publishPetEvent(ctx, s, errOut, events.PetDeleted, pet)
+	}
+	fmt.Fprintf(out, "%d pets deleted\n", len(ids))
+	return nil
+}
+
+// export escribe las mascotas o las razas en el formato de la API (ver transfer.WritePets).
+func export(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+	fs := newFlags("export", errOut)
+	format := fs.String("format", transfer.FormatCSV, `"csv" or "ndjson"`)
+	output := fs.String("o", "", "output file; by default, stdout")
+	if err := parseFlags(fs, args, 1, 1); err != nil {
+		return err
+	}
+	what := fs.Arg(0)
+	if what != "pets" && what != "breeds" {
+		fmt.Fprintf(fs.Output(), "unknown data %q, use pets or breeds\n", what)
+		return errUsage
+	}
+	if _, err := transfer.ContentType(*format); err != nil {
+		return err
+	}
+
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	var write func(w io.Writer) error
+	if what == "pets" {
+		pets, err := s.GetPets(ctx)
+		if err != nil {
+			return err
+		}
+		write = func(w io.Writer) error { return transfer.WritePets(w, *format, pets) }
+	} else {
+		breeds, err := s.GetBreeds(ctx)
+		if err != nil {
+			return err
+		}
+		write = func(w io.Writer) error { return transfer.WriteBreeds(w, *format, breeds) }
+	}
+
+	if *output == "" {
+		return write(out)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(*output)
+		return err
+	}
+	return f.Close()
+}
+
+// health comprueba que la base de datos responda y, con -url, que la API también. No
+// reintenta más allá de -timeout, para que sirva en scripts y sondas.
+func health(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+	fs := newFlags("health", errOut)
+	baseURL := fs.String("url", "", "base URL of the API to check, e.g. http://localhost:8080")
+	timeout := fs.Duration("timeout", 5*time.Second, "maximum time for each check")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	healthy := true
+	check := func(name string, fn func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			healthy = false
+			fmt.Fprintf(out, "%s: FAIL (%v)\n", name, err)
+			return
+		}
+		fmt.Fprintf(out, "%s: ok\n", name)
+	}
+
+	check("database", func(ctx context.Context) error {
+		cfg.Postgres.Connect.Timeout = *timeout
+		s, err := openStore(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		_, err = s.GetBreeds(ctx)
+		return err
+	})
+	if *baseURL != "" {
+		check("api", func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(*baseURL, "/")+"/api/v1/breeds", nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("GET /api/v1/breeds returned %s", resp.Status)
+			}
+			return nil
+		})
+	}
+	if !healthy {
+		return errors.New("unhealthy")
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Command dogctl administra la base de datos de la aplicación sin pasar por la API:
+// migraciones, carga de razas, mascotas, exportaciones y comprobación de salud. Lee la
+// misma configuración que cmd/api (STORE_DRIVER, DB_CONN_STRING, SQLITE_PATH, ...).
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/config"
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// actor es el actor con el que se auditan los cambios hechos con dogctl.
+const actor = "dogctl"
+
+const usage = `Usage: dogctl <command> [flags] [args]
+
+Commands:
+  migrate                                  apply pending migrations
+  seed [-set base,demo] [-file path]       load the embedded fixtures and/or a fixture file
+  breeds seed -file <path> [-format f]     insert or update breeds from a CSV, JSON or NDJSON file
+  pets list [-json]                        list pets
+  pets create -name <n> -birth <date> -breed <id> [-owner <subject>]
+                                           create a pet
+  pets delete [-version v] <id>...         delete pets
+  export [-format csv|ndjson] [-o path] pets|breeds
+                                           export pets or breeds
+  health [-url <base url>] [-timeout d]    check the database and, optionally, the API
+
+The store is configured with the same environment variables as the API.
+Run "dogctl <command> -h" for the flags of a command.
+`
+
+// errUsage indica que los argumentos no son válidos; el mensaje ya se mostró.
+var errUsage = errors.New("invalid usage")
+
+// command es un subcomando: recibe sus argumentos, escribe el resultado en out y los
+// mensajes de uso en errOut. Abre el store con openStore después de validar los argumentos.
+type command func(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error
+
+var commands = map[string]command{
+	"migrate": migrate,
+	"seed":    seed,
+	"breeds":  subcommands("breeds", map[string]command{"seed": seedBreeds}),
+	"pets": subcommands("pets", map[string]command{
+		"list":   listPets,
+		"create": createPet,
+		"delete": deletePets,
+	}),
+	"export": export,
+	"health": health,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run ejecuta dogctl y devuelve el código de salida: 0 si todo fue bien, 1 si falló y 2
+// si los argumentos no son válidos. El resultado va a stdout y los errores a stderr.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if args[0] == "-h" || args[0] == "help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "dogctl: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "dogctl: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = audit.WithMeta(ctx, audit.Meta{Actor: actor})
+	switch err := cmd(ctx, cfg, args[1:], stdout, stderr); {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "dogctl %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// subcommands despacha al subcomando de name indicado en el primer argumento.
+func subcommands(name string, cmds map[string]command) command {
+	return func(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+		if len(args) == 0 {
+			fmt.Fprintf(errOut, "dogctl %s: missing subcommand\n\n%s", name, usage)
+			return errUsage
+		}
+		cmd, ok := cmds[args[0]]
+		if !ok {
+			fmt.Fprintf(errOut, "dogctl %s: unknown subcommand %q\n\n%s", name, args[0], usage)
+			return errUsage
+		}
+		return cmd(ctx, cfg, args[1:], out, errOut)
+	}
+}
+
+// openStore abre el store configurado; quien lo abre debe cerrarlo.
+func openStore(ctx context.Context, cfg *config.Config) (store.AppStore, error) {
+	return store.Open(ctx, cfg.StoreDriver, cfg.Postgres, cfg.SQLitePath)
+}
+
+// publishPetEvent envía a las instancias de la API un cambio ya confirmado de pet (después
+// del cambio o, en los borrados, antes de borrarla), para que llegue a su stream de eventos
+// como los cambios hechos por la API. Solo es posible si el store comparte eventos entre
+// procesos (ver store.PubSub); si no, no hace nada. Si falla, el cambio ya está hecho: se
+// avisa en errOut y no se devuelve error.
+func publishPetEvent(ctx context.Context, s store.AppStore, errOut io.Writer, typ string, pet *types.Pet) {
+	ps, ok := s.(store.PubSub)
+	if !ok {
+		return
+	}
+	var payload any
+	if typ != events.PetDeleted {
+		payload = pet
+	}
+	err := func() error {
+		e, err := events.New(ctx, typ, pet.ID.String(), payload)
+		if err != nil {
+			return err
+		}
+		e.Owner = pet.Owner
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		return ps.Notify(context.WithoutCancel(ctx), events.Channel, string(b))
+	}()
+	if err != nil {
+		fmt.Fprintf(errOut, "dogctl: warning: %s event of pet %s not published: %v\n", typ, pet.ID, err)
+	}
+}
+
+// newFlags crea el FlagSet de un subcomando, que escribe su ayuda y sus errores en errOut.
+// Los errores de parseo se devuelven en lugar de terminar el proceso.
+func newFlags(name string, errOut io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("dogctl "+name, flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	return fs
+}
+
+// parseFlags parsea args y comprueba que queden entre minArgs y maxArgs argumentos
+// posicionales (maxArgs < 0 es sin límite).
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if n := fs.NArg(); n < minArgs || maxArgs >= 0 && n > maxArgs {
+		fmt.Fprintf(fs.Output(), "%s: wrong number of arguments\n", fs.Name())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func migrate(ctx context.Context, cfg *config.Config, args []string, out, errOut io.Writer) error {
+	if err := parseFlags(newFlags("migrate", errOut), args, 0, 0); err != nil {
+		return err
+	}
+	s, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if err := s.Migrate(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Migrations applied")
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func TestRun(t *testing.T) {
+	t.Setenv("STORE_DRIVER", "sqlite")
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "dogctl.db"))
+
+	exec := func(args ...string) (code int, stdout, stderr string) {
+		var out, errOut bytes.Buffer
+		code = run(args, &out, &errOut)
+		return code, out.String(), errOut.String()
+	}
+
+	// Los casos comparten la base de datos y se ejecutan en orden.
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{"no arguments", nil, 2, "", "Usage: dogctl"},
+		{"help", []string{"help"}, 0, "Usage: dogctl", ""},
+		{"unknown command", []string{"adopt"}, 2, "", `unknown command "adopt"`},
+		{"missing subcommand", []string{"pets"}, 2, "", "missing subcommand"},
+		{"unknown subcommand", []string{"pets", "rename"}, 2, "", `unknown subcommand "rename"`},
+		{"unknown flag", []string{"pets", "list", "-yaml"}, 2, "", "flag provided but not defined: -yaml"},
+		{"extra argument", []string{"migrate", "now"}, 2, "", "wrong number of arguments"},
+		{"missing required flags", []string{"pets", "create", "-name", "Rex"}, 2, "", "-name, -birth and -breed are required"},
+		{"delete without IDs", []string{"pets", "delete"}, 2, "", "wrong number of arguments"},
+		{"version with several IDs", []string{"pets", "delete", "-version", "1", "a", "b"}, 2, "", "-version can only be used with a single ID"},
+		{"command help", []string{"migrate", "-h"}, 0, "", "Usage of dogctl migrate"},
+		{"migrate", []string{"migrate"}, 0, "Migrations applied", ""},
+		{"seed", []string{"seed", "-set", "base"}, 0, "breeds:", ""},
+		{"create", []string{"pets", "create", "-name", "Rex", "-birth", "2020-01-02", "-breed", "golden-retriever", "-owner", "alice"}, 0, "-", ""},
+		{"create with an unknown breed", []string{"pets", "create", "-name", "Rex", "-birth", "2020-01-02", "-breed", "wolf"}, 1, "", `breed "wolf" not found`},
+		{"create with a bad date", []string{"pets", "create", "-name", "Rex", "-birth", "02/01/2020", "-breed", "golden-retriever"}, 1, "", "bad date of birth format"},
+		{"delete a missing pet", []string{"pets", "delete", "0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41"}, 1, "", "not found"},
+		{"delete a malformed ID", []string{"pets", "delete", "p1"}, 1, "", "dogctl pets:"},
+		{"list", []string{"pets", "list"}, 0, "Rex", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := exec(tt.args...)
+			if code != tt.wantCode {
+				t.Fatalf("expected exit code %d, got %d (stdout %q, stderr %q)", tt.wantCode, code, stdout, stderr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("expected stdout to contain %q, got %q", tt.wantStdout, stdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("expected stderr to contain %q, got %q", tt.wantStderr, stderr)
+			}
+			if tt.wantStderr == "" && stderr != "" {
+				t.Errorf("expected nothing on stderr, got %q", stderr)
+			}
+		})
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		code, stdout, _ := exec("pets", "list", "-json")
+		var pets []types.Pet
+		if code != 0 || json.Unmarshal([]byte(stdout), &pets) != nil {
+			t.Fatalf("expected the pets as JSON, got %d %q", code, stdout)
+		}
+		i := slices.IndexFunc(pets, func(p types.Pet) bool { return p.Name == "Rex" })
+		if i < 0 || pets[i].Owner != "alice" {
+			t.Fatalf("expected Rex owned by alice, got %+v", pets)
+		}
+		if code, stdout, stderr := exec("pets", "delete", pets[i].ID.String()); code != 0 || stdout != "1 pets deleted\n" {
+			t.Errorf("expected the pet deleted, got %d %q %q", code, stdout, stderr)
+		}
+	})
+}
+
+// pubSubStore es un store que comparte eventos entre procesos, como el de Postgres.
+type pubSubStore struct {
+	store.AppStore
+	payloads []string
+}
+
+func (s *pubSubStore) Notify(ctx context.Context, channel, payload string) error {
+	if channel != events.Channel {
+		return nil
+	}
+	s.payloads = append(s.payloads, payload)
+	return nil
+}
+
+func (s *pubSubStore) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	return nil
+}
+
+func TestPublishPetEvent(t *testing.T) {
+	ctx := audit.WithMeta(context.Background(), audit.Meta{Actor: actor})
+	pet := &types.Pet{ID: "0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41", Name: "Rex", Owner: "alice", Version: 1}
+	var errOut bytes.Buffer
+
+	// Sin PubSub no se publica nada.
+	publishPetEvent(ctx, struct{ store.AppStore }{}, &errOut, events.PetCreated, pet)
+
+	s := &pubSubStore{}
+	publishPetEvent(ctx, s, &errOut, events.PetCreated, pet)
+	publishPetEvent(ctx, s, &errOut, events.PetDeleted, pet)
+	if errOut.Len() != 0 || len(s.payloads) != 2 {
+		t.Fatalf("expected 2 events and no warnings, got %q %q", s.payloads, errOut.String())
+	}
+	var created, deleted events.Event
+	if err := json.Unmarshal([]byte(s.payloads[0]), &created); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	if err := json.Unmarshal([]byte(s.payloads[1]), &deleted); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	if created.Type != events.PetCreated || created.EntityID != pet.ID.String() || created.Actor != actor || created.Data == nil {
+		t.Errorf("unexpected created event: %+v", created)
+	}
+	if created.Owner != "alice" || deleted.Type != events.PetDeleted || deleted.Owner != "alice" || deleted.Data != nil {
+		t.Errorf("unexpected deleted event: %+v", deleted)
+	}
+}
@@ -0,0 +1,44 @@
+package audit
+
+import "context"
+
+// Tipos de entidad auditados.
+const (
+	EntityPet   = "pet"
+	EntityBreed = "breed"
+)
+
+// Acciones auditadas.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Meta identifica quién y desde qué solicitud se realizó un cambio.
+type Meta struct {
+	Actor     string
+	RequestID string
+	// Subject es el sujeto autenticado de la solicitud, o "" si es anónima. Las mascotas
+	// que se crean lo guardan como dueño (types.Pet.Owner).
+	Subject string
+}
+
+// SystemActor se usa cuando el cambio no viene de una solicitud HTTP (tests, tareas internas).
+const SystemActor = "system"
+
+type metaKey struct{}
+
+// WithMeta devuelve una copia del contexto con los datos de auditoría de la solicitud.
+func WithMeta(ctx context.Context, m Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, m)
+}
+
+// FromContext devuelve los datos de auditoría del contexto. Si no hay actor, usa SystemActor.
+func FromContext(ctx context.Context) Meta {
+	m, _ := ctx.Value(metaKey{}).(Meta)
+	if m.Actor == "" {
+		m.Actor = SystemActor
+	}
+	return m
+}
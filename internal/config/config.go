@@ -0,0 +1,344 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/middleware"
+	"github.com/agugliotta/dog-app-bff/internal/notify"
+	"github.com/agugliotta/dog-app-bff/internal/scheduler"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/webhooks"
+)
+
+// Config agrupa toda la configuración de la aplicación, leída de variables de entorno
+// para que cada entorno (local, CI, demo, producción) pueda ajustarla sin recompilar.
+type Config struct {
+	Addr string
+	// StoreDriver elige la implementación del store: "postgres" (lib/pq), "pgx" o "sqlite".
+	StoreDriver string
+	Postgres    store.PostgresConfig
+	// SQLitePath es el archivo de la base de datos cuando StoreDriver es "sqlite".
+	SQLitePath  string
+	CORS        middleware.CORSConfig
+	Identity    middleware.IdentityConfig
+	RateLimit   middleware.RateLimitConfig
+	Idempotency middleware.IdempotencyConfig
+	// AdminSubjects son los sujetos autenticados con acceso a /api/v1/admin.
+	AdminSubjects []string
+	// Scheduler y Reminders ajustan el planificador de recordatorios; Notifier elige el
+	// canal por el que se envían.
+	Scheduler scheduler.Config
+	Reminders scheduler.ReminderConfig
+	Notifier  notify.Config
+	// Webhooks ajusta las entregas de los webhooks, que también ejecuta el planificador.
+	Webhooks webhooks.Config
+	// EventLogSize es cuántos eventos recientes se guardan para que los clientes del stream
+	// puedan reanudarlo; StreamHeartbeat, cada cuánto se envía un latido por el stream.
+	EventLogSize    int
+	StreamHeartbeat time.Duration
+}
+
+// Load lee la configuración desde el entorno, aplicando valores por defecto razonables.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Addr:          getEnv("HTTP_ADDR", ":8080"),
+		StoreDriver:   getEnv("STORE_DRIVER", "postgres"),
+		SQLitePath:    getEnv("SQLITE_PATH", "dog-app.db"),
+		AdminSubjects: getEnvList("ADMIN_SUBJECTS", nil),
+	}
+
+	switch cfg.StoreDriver {
+	case "postgres", "pgx", "sqlite":
+	default:
+		return nil, fmt.Errorf("invalid value for STORE_DRIVER: %q", cfg.StoreDriver)
+	}
+
+	var err error
+	cfg.Postgres, err = loadPostgres()
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Postgres.Replicas) > 0 && cfg.StoreDriver != "postgres" {
+		return nil, fmt.Errorf("DB_REPLICA_CONN_STRINGS is only supported with STORE_DRIVER=postgres")
+	}
+	cfg.CORS, err = loadCORS()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Identity, err = loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimit, err = loadRateLimit()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Idempotency.TTL, err = getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	maxBody, err := getEnvInt("IDEMPOTENCY_MAX_BODY_BYTES", 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Idempotency.MaxBodyBytes = int64(maxBody)
+	cfg.Scheduler, cfg.Reminders, err = loadScheduler()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Notifier, err = loadNotifier()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Webhooks = webhooks.DefaultConfig()
+	if cfg.Webhooks.Timeout, err = getEnvDuration("WEBHOOK_TIMEOUT", cfg.Webhooks.Timeout); err != nil {
+		return nil, err
+	}
+	if cfg.EventLogSize, err = getEnvInt("EVENT_LOG_SIZE", 1000); err != nil {
+		return nil, err
+	}
+	if cfg.StreamHeartbeat, err = getEnvDuration("STREAM_HEARTBEAT", 15*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.EventLogSize <= 0 || cfg.StreamHeartbeat <= 0 {
+		return nil, fmt.Errorf("EVENT_LOG_SIZE and STREAM_HEARTBEAT must be positive")
+	}
+
+	return cfg, nil
+}
+
+// loadPostgres parte de store.DefaultPostgresConfig y permite ajustar cada valor.
+func loadPostgres() (store.PostgresConfig, error) {
+	pg := store.DefaultPostgresConfig(os.Getenv("DB_CONN_STRING"))
+
+	var err error
+	if pg.Pool.MaxOpenConns, err = getEnvInt("DB_MAX_OPEN_CONNS", pg.Pool.MaxOpenConns); err != nil {
+		return pg, err
+	}
+	if pg.Pool.MaxIdleConns, err = getEnvInt("DB_MAX_IDLE_CONNS", pg.Pool.MaxIdleConns); err != nil {
+		return pg, err
+	}
+	if pg.Pool.ConnMaxLifetime, err = getEnvDuration("DB_CONN_MAX_LIFETIME", pg.Pool.ConnMaxLifetime); err != nil {
+		return pg, err
+	}
+	if pg.Pool.ConnMaxIdleTime, err = getEnvDuration("DB_CONN_MAX_IDLE_TIME", pg.Pool.ConnMaxIdleTime); err != nil {
+		return pg, err
+	}
+	if pg.Connect.Timeout, err = getEnvDuration("DB_CONNECT_TIMEOUT", pg.Connect.Timeout); err != nil {
+		return pg, err
+	}
+	if pg.Breaker.FailureThreshold, err = getEnvInt("DB_BREAKER_THRESHOLD", pg.Breaker.FailureThreshold); err != nil {
+		return pg, err
+	}
+	if pg.Breaker.OpenTimeout, err = getEnvDuration("DB_BREAKER_OPEN_TIMEOUT", pg.Breaker.OpenTimeout); err != nil {
+		return pg, err
+	}
+	pg.Replicas = getEnvList("DB_REPLICA_CONN_STRINGS", nil)
+	if pg.ReplicaCooldown, err = getEnvDuration("DB_REPLICA_COOLDOWN", pg.ReplicaCooldown); err != nil {
+		return pg, err
+	}
+	return pg, nil
+}
+
+func loadCORS() (middleware.CORSConfig, error) {
+	cors := middleware.CORSConfig{
+		AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", nil),
+		AllowedMethods: getEnvList("CORS_ALLOWED_METHODS", middleware.DefaultCORSMethods),
+		AllowedHeaders: getEnvList("CORS_ALLOWED_HEADERS", middleware.DefaultCORSHeaders),
+		ExposedHeaders: getEnvList("CORS_EXPOSED_HEADERS", []string{"ETag"}),
+	}
+
+	var err error
+	if cors.AllowCredentials, err = getEnvBool("CORS_ALLOW_CREDENTIALS", false); err != nil {
+		return cors, err
+	}
+	if cors.MaxAge, err = getEnvDuration("CORS_MAX_AGE", 10*time.Minute); err != nil {
+		return cors, err
+	}
+	// Con credenciales, "*" permitiría a cualquier sitio leer las respuestas del usuario.
+	if cors.AllowCredentials && slices.Contains(cors.AllowedOrigins, "*") {
+		return cors, errors.New(`CORS_ALLOW_CREDENTIALS can't be used with CORS_ALLOWED_ORIGINS="*"`)
+	}
+	return cors, nil
+}
+
+func loadIdentity() (middleware.IdentityConfig, error) {
+	proxies, err := middleware.ParsePrefixes(getEnvList("TRUSTED_PROXIES", nil))
+	if err != nil {
+		return middleware.IdentityConfig{}, fmt.Errorf("invalid value for TRUSTED_PROXIES: %w", err)
+	}
+	return middleware.IdentityConfig{
+		TrustedProxies: proxies,
+		SubjectHeader:  os.Getenv("AUTH_SUBJECT_HEADER"),
+	}, nil
+}
+
+// defaultRouteLimits son los límites por ruta si no se indica RATE_LIMIT_ROUTES. Los lotes
+// y la importación crean hasta 100 y 10000 mascotas por solicitud, así que se limitan aparte
+// para que no sirvan para saltarse el límite de altas.
+var defaultRouteLimits = []string{
+	"POST /api/v1/pets=10/1m",
+	"POST /api/v1/pets/bulk=1/10m",
+	"POST /api/v1/import/pets=1/1h",
+}
+
+// loadRateLimit lee RATE_LIMIT_DEFAULT ("N/duración", vacío u "off" para desactivar) y
+// RATE_LIMIT_ROUTES, una lista de "MÉTODO /ruta=N/duración" separada por comas.
+func loadRateLimit() (middleware.RateLimitConfig, error) {
+	var rl middleware.RateLimitConfig
+
+	if def := getEnv("RATE_LIMIT_DEFAULT", "120/1m"); def != "off" {
+		limit, err := middleware.ParseLimit(def)
+		if err != nil {
+			return rl, fmt.Errorf("invalid value for RATE_LIMIT_DEFAULT: %w", err)
+		}
+		rl.Default = limit
+	}
+
+	for _, spec := range getEnvList("RATE_LIMIT_ROUTES", defaultRouteLimits) {
+		route, limitSpec, ok := strings.Cut(spec, "=")
+		if !ok {
+			return rl, fmt.Errorf("invalid value for RATE_LIMIT_ROUTES: %q", spec)
+		}
+		limit, err := middleware.ParseLimit(limitSpec)
+		if err != nil {
+			return rl, fmt.Errorf("invalid value for RATE_LIMIT_ROUTES: %w", err)
+		}
+		var method, path string
+		if fields := strings.Fields(route); len(fields) == 2 {
+			method, path = strings.ToUpper(fields[0]), fields[1]
+		} else if len(fields) == 1 {
+			path = fields[0]
+		} else {
+			return rl, fmt.Errorf("invalid value for RATE_LIMIT_ROUTES: %q", spec)
+		}
+		rl.Routes = append(rl.Routes, middleware.RouteLimit{Method: method, Path: path, Limit: limit})
+	}
+
+	return rl, nil
+}
+
+// loadScheduler parte de los valores por defecto del planificador y de los recordatorios.
+func loadScheduler() (scheduler.Config, scheduler.ReminderConfig, error) {
+	sc, rc := scheduler.DefaultConfig(), scheduler.DefaultReminderConfig()
+
+	var err error
+	if sc.Enabled, err = getEnvBool("SCHEDULER_ENABLED", sc.Enabled); err != nil {
+		return sc, rc, err
+	}
+	if sc.PollInterval, err = getEnvDuration("SCHEDULER_POLL_INTERVAL", sc.PollInterval); err != nil {
+		return sc, rc, err
+	}
+	if sc.PlanInterval, err = getEnvDuration("SCHEDULER_PLAN_INTERVAL", sc.PlanInterval); err != nil {
+		return sc, rc, err
+	}
+	if sc.Lease, err = getEnvDuration("SCHEDULER_LEASE", sc.Lease); err != nil {
+		return sc, rc, err
+	}
+	if sc.MaxAttempts, err = getEnvInt("SCHEDULER_MAX_ATTEMPTS", sc.MaxAttempts); err != nil {
+		return sc, rc, err
+	}
+	if sc.MinBackoff, err = getEnvDuration("SCHEDULER_MIN_BACKOFF", sc.MinBackoff); err != nil {
+		return sc, rc, err
+	}
+	if sc.MaxBackoff, err = getEnvDuration("SCHEDULER_MAX_BACKOFF", sc.MaxBackoff); err != nil {
+		return sc, rc, err
+	}
+	if rc.Lookahead, err = getEnvDuration("REMINDER_LOOKAHEAD", rc.Lookahead); err != nil {
+		return sc, rc, err
+	}
+	if rc.DoseLead, err = getEnvDuration("DOSE_REMINDER_LEAD", rc.DoseLead); err != nil {
+		return sc, rc, err
+	}
+	if sc.PollInterval <= 0 || sc.PlanInterval <= 0 || sc.Lease <= 0 {
+		return sc, rc, fmt.Errorf("SCHEDULER_POLL_INTERVAL, SCHEDULER_PLAN_INTERVAL and SCHEDULER_LEASE must be positive")
+	}
+	if rc.Lookahead <= sc.PlanInterval {
+		return sc, rc, fmt.Errorf("REMINDER_LOOKAHEAD must be longer than SCHEDULER_PLAN_INTERVAL")
+	}
+	return sc, rc, nil
+}
+
+// loadNotifier lee el canal de los recordatorios. NOTIFIER es "log", "webhook" o "email".
+func loadNotifier() (notify.Config, error) {
+	nc := notify.Config{
+		Kind:       getEnv("NOTIFIER", "log"),
+		WebhookURL: os.Getenv("NOTIFY_WEBHOOK_URL"),
+		SMTPAddr:   getEnv("SMTP_ADDR", "localhost:1025"),
+		From:       getEnv("SMTP_FROM", "reminders@dog-app.local"),
+		To:         getEnvList("NOTIFY_EMAIL_TO", nil),
+	}
+
+	var err error
+	if nc.WebhookTimeout, err = getEnvDuration("NOTIFY_WEBHOOK_TIMEOUT", 10*time.Second); err != nil {
+		return nc, err
+	}
+	if _, err := notify.New(nc); err != nil {
+		return nc, fmt.Errorf("invalid value for NOTIFIER: %w", err)
+	}
+	return nc, nil
+}
+
+func getEnv(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// getEnvList interpreta la variable como una lista separada por comas.
+func getEnvList(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func getEnvBool(key string, def bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getEnvInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvDuration(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return d, nil
+}
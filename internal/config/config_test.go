@@ -0,0 +1,53 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/middleware"
+)
+
+func TestDefaultRateLimitCoversBulkCreates(t *testing.T) {
+	t.Setenv("RATE_LIMIT_DEFAULT", "")
+	t.Setenv("RATE_LIMIT_ROUTES", "")
+	rl, err := loadRateLimit()
+	if err != nil {
+		t.Fatalf("loadRateLimit failed: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	rl.Now = func() time.Time { return now }
+	handler := middleware.RateLimit(rl)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		method, path string
+		// allowed es cuántas solicitudes seguidas se aceptan antes del 429.
+		allowed int
+	}{
+		{"POST", "/api/v1/pets", 10},
+		{"POST", "/api/v1/pets/bulk", 1},
+		{"POST", "/api/v1/import/pets", 1},
+		{"GET", "/api/v1/pets", 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			for i := range tt.allowed {
+				if code := send(tt.method, tt.path); code != http.StatusOK {
+					t.Fatalf("request %d: expected 200, got %d", i+1, code)
+				}
+			}
+			if code := send(tt.method, tt.path); code != http.StatusTooManyRequests {
+				t.Errorf("expected 429 after %d requests, got %d", tt.allowed, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package events es el bus de eventos de dominio del BFF: los handlers publican lo que
+// cambió (una mascota creada, actualizada o borrada) y los suscriptores (el stream de
+// eventos, ...) reaccionan sin que los handlers los conozcan. Los webhooks no dependen del
+// bus: el store guarda cada evento en la misma transacción que el cambio (ver
+// store.KindEvent).
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+)
+
+// Tipos de evento. El prefijo antes del punto es el tipo de entidad, como en audit.
+const (
+	PetCreated   = audit.EntityPet + ".created"
+	PetUpdated   = audit.EntityPet + ".updated"
+	PetDeleted   = audit.EntityPet + ".deleted"
+	BreedCreated = audit.EntityBreed + ".created"
+	BreedUpdated = audit.EntityBreed + ".updated"
+)
+
+// Types son todos los tipos de evento que se publican. Las razas no se borran, así que no
+// hay "breed.deleted"; sus eventos solo llegan a los webhooks (ver store.KindEvent).
+var Types = []string{PetCreated, PetUpdated, PetDeleted, BreedCreated, BreedUpdated}
+
+// ChangeType devuelve el tipo de evento de un cambio auditado: PetCreated para
+// audit.ActionCreate sobre audit.EntityPet, y así con el resto.
+func ChangeType(entityType, action string) string {
+	return entityType + "." + action + "d"
+}
+
+// Event es un cambio ya confirmado en el store. Data es la entidad después del cambio
+// (vacío en los borrados).
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	EntityID   string          `json:"entityId"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"requestId,omitempty"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	// Owner es el dueño de la mascota del evento (types.Pet.Owner), también en los borrados.
+	// Lo rellena quien publica en el Bus para que el stream envíe a cada sujeto solo los
+	// eventos de sus mascotas; los eventos de los webhooks no lo llevan.
+	Owner string `json:"owner,omitempty"`
+}
+
+// EntityType devuelve el tipo de entidad del evento, p. ej. "pet".
+func (e Event) EntityType() string {
+	entity, _, _ := strings.Cut(e.Type, ".")
+	return entity
+}
+
+// New crea un evento con un ID nuevo, el actor y la solicitud de ctx (ver audit.FromContext)
+// y data codificada en JSON.
+func New(ctx context.Context, typ, entityID string, data any) (Event, error) {
+	meta := audit.FromContext(ctx)
+	e := Event{
+		ID:         newID(),
+		Type:       typ,
+		EntityID:   entityID,
+		Actor:      meta.Actor,
+		RequestID:  meta.RequestID,
+		OccurredAt: time.Now().UTC(),
+	}
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return e, fmt.Errorf("failed to marshal %s event: %w", typ, err)
+		}
+		e.Data = b
+	}
+	return e, nil
+}
+
+// Publisher publica eventos. Publish no devuelve error: el cambio ya está confirmado, así
+// que un suscriptor que falla no puede deshacerlo.
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// Handler recibe los eventos de un suscriptor.
+type Handler func(ctx context.Context, e Event)
+
+// Bus reparte cada evento a todos sus suscriptores, en el orden en que se suscribieron y
+// de forma síncrona: los suscriptores lentos deben delegar el trabajo (p. ej. en una cola).
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []subscription
+	nextID int
+}
+
+type subscription struct {
+	id int
+	h  Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registra h y devuelve la función que lo da de baja.
+func (b *Bus) Subscribe(h Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, subscription{id: id, h: h})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subs = slices.DeleteFunc(slices.Clone(b.subs), func(s subscription) bool { return s.id == id })
+	}
+}
+
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	// subs nunca se modifica en sitio, así que basta con leer el slice actual.
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		func() {
+			// Un suscriptor que entra en pánico no debe tumbar la solicitud que publicó.
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("Pánico en un suscriptor del evento %s (%s): %v", e.ID, e.Type, p)
+				}
+			}()
+			s.h(ctx, e)
+		}()
+	}
+}
+
+// newID genera un UUID v4 aleatorio.
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
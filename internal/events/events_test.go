@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e Event) { got = append(got, "first "+e.Type) })
+	bus.Subscribe(func(ctx context.Context, e Event) { panic("boom") })
+	unsubscribe := bus.Subscribe(func(ctx context.Context, e Event) { got = append(got, "third "+e.Type) })
+
+	bus.Publish(context.Background(), Event{Type: PetCreated})
+	unsubscribe()
+	bus.Publish(context.Background(), Event{Type: PetDeleted})
+
+	want := "first pet.created, third pet.created, first pet.deleted"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("expected %q, got %q", want, strings.Join(got, ", "))
+	}
+}
+
+func TestNew(t *testing.T) {
+	ctx := audit.WithMeta(context.Background(), audit.Meta{Actor: "alice", RequestID: "req-1"})
+	e, err := New(ctx, PetUpdated, "p1", map[string]string{"name": "Fido"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if len(e.ID) != 36 || e.Actor != "alice" || e.RequestID != "req-1" || e.EntityType() != "pet" ||
+		e.OccurredAt.IsZero() || string(e.Data) != `{"name":"Fido"}` {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	e, _ = New(context.Background(), PetDeleted, "p1", nil)
+	if e.Actor != audit.SystemActor || e.Data != nil {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
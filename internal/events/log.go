@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Log guarda los últimos eventos publicados y los reparte en vivo a sus suscripciones,
+// para que un cliente que se reconecta pueda recibir lo que se perdió (ver Subscribe).
+// Su método Publish es un Handler, así que puede suscribirse a un Bus o a Receive.
+type Log struct {
+	mu     sync.Mutex
+	size   int
+	events []Event
+	subs   map[*Subscription]struct{}
+}
+
+// NewLog crea un registro que conserva los últimos size eventos.
+func NewLog(size int) *Log {
+	return &Log{size: max(size, 1), subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription recibe los eventos publicados en el Log desde que se creó. Si el suscriptor
+// no lee al ritmo de los eventos y se llena su búfer, C se cierra: el suscriptor debe
+// volver a suscribirse desde el último evento que procesó.
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	log    *Log
+	closed bool
+}
+
+// Close da de baja la suscripción. Se puede llamar más de una vez.
+func (s *Subscription) Close() {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	s.log.drop(s)
+}
+
+// Publish guarda el evento y lo entrega a las suscripciones. Los eventos repetidos (mismo
+// ID que uno que aún está en el registro) se ignoran.
+func (l *Log) Publish(ctx context.Context, e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if slices.ContainsFunc(l.events, func(old Event) bool { return old.ID == e.ID }) {
+		return
+	}
+	l.events = append(l.events, e)
+	if over := len(l.events) - l.size; over > 0 {
+		l.events = slices.Delete(l.events, 0, over)
+	}
+	for s := range l.subs {
+		select {
+		case s.c <- e:
+		default:
+			l.drop(s)
+		}
+	}
+}
+
+// Subscribe crea una suscripción con un búfer de buffer eventos. Si lastID no está vacío,
+// devuelve además los eventos posteriores a lastID que siguen en el registro, sin huecos
+// entre ellos y los que llegarán por la suscripción. ok es false si lastID ya no está en el
+// registro (o nunca estuvo): el suscriptor se ha perdido eventos y debe releer el estado
+// completo.
+func (l *Log) Subscribe(lastID string, buffer int) (backlog []Event, s *Subscription, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ok = true
+	if lastID != "" {
+		i := slices.IndexFunc(l.events, func(e Event) bool { return e.ID == lastID })
+		if i < 0 {
+			ok = false
+		} else {
+			backlog = slices.Clone(l.events[i+1:])
+		}
+	}
+	c := make(chan Event, max(buffer, 1))
+	s = &Subscription{C: c, c: c, log: l}
+	l.subs[s] = struct{}{}
+	return backlog, s, ok
+}
+
+// drop cierra la suscripción. Debe llamarse con l.mu bloqueado.
+func (l *Log) drop(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(l.subs, s)
+	close(s.c)
+}
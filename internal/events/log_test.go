@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func ids(events []Event) string {
+	var out []string
+	for _, e := range events {
+		out = append(out, e.ID)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestLog(t *testing.T) {
+	ctx := context.Background()
+	l := NewLog(3)
+	for _, id := range []string{"e1", "e2", "e3", "e4"} {
+		l.Publish(ctx, Event{ID: id, Type: PetCreated})
+	}
+
+	backlog, sub, ok := l.Subscribe("e2", 2)
+	defer sub.Close()
+	if !ok || ids(backlog) != "e3,e4" {
+		t.Errorf("expected to resume after e2 with e3,e4, got %q (%v)", ids(backlog), ok)
+	}
+	if backlog, s, ok := l.Subscribe("e1", 1); ok || len(backlog) != 0 {
+		t.Errorf("expected e1 to have left the log, got %q (%v)", ids(backlog), ok)
+	} else {
+		s.Close()
+	}
+	if backlog, s, ok := l.Subscribe("", 1); !ok || len(backlog) != 0 {
+		t.Errorf("expected no backlog without a last ID, got %q (%v)", ids(backlog), ok)
+	} else {
+		s.Close()
+	}
+
+	l.Publish(ctx, Event{ID: "e5"})
+	l.Publish(ctx, Event{ID: "e5"})
+	if e := <-sub.C; e.ID != "e5" {
+		t.Errorf("expected e5, got %s", e.ID)
+	}
+
+	// Un suscriptor que no lee se queda sin suscripción al llenarse su búfer.
+	for _, id := range []string{"e6", "e7", "e8"} {
+		l.Publish(ctx, Event{ID: id})
+	}
+	var got []Event
+	for e := range sub.C {
+		got = append(got, e)
+	}
+	if ids(got) != "e6,e7" {
+		t.Errorf("expected the buffered e6,e7 before the subscription closed, got %q", ids(got))
+	}
+	sub.Close()
+}
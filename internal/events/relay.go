@@ -0,0 +1,48 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+)
+
+// Channel es el canal del Broker por el que las instancias de la API, y dogctl, comparten
+// los eventos.
+const Channel = "dog_app_events"
+
+// Broker transporta mensajes entre todas las instancias del BFF, p. ej. con LISTEN/NOTIFY
+// de Postgres (ver store.PubSub).
+type Broker interface {
+	// Notify envía payload a quienes escuchan channel, incluida la propia instancia.
+	Notify(ctx context.Context, channel, payload string) error
+	// Listen llama a fn con cada mensaje de channel hasta que se cancela ctx.
+	Listen(ctx context.Context, channel string, fn func(payload string)) error
+}
+
+// Forward devuelve el Handler que reenvía cada evento local a channel. Suscrito a un Bus,
+// hace que Receive entregue los eventos de todas las instancias.
+func Forward(b Broker, channel string) Handler {
+	return func(ctx context.Context, e Event) {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			log.Printf("Error al codificar el evento %s: %v", e.ID, err)
+			return
+		}
+		// El cambio ya está confirmado: se reenvía aunque el cliente corte la solicitud.
+		if err := b.Notify(context.WithoutCancel(ctx), channel, string(payload)); err != nil {
+			log.Printf("Error al reenviar el evento %s (%s): %v", e.ID, e.Type, err)
+		}
+	}
+}
+
+// Receive entrega a h los eventos que llegan por channel hasta que se cancela ctx.
+func Receive(ctx context.Context, b Broker, channel string, h Handler) error {
+	return b.Listen(ctx, channel, func(payload string) {
+		var e Event
+		if err := json.Unmarshal([]byte(payload), &e); err != nil {
+			log.Printf("Evento con formato inválido en el canal %s: %v", channel, err)
+			return
+		}
+		h(ctx, e)
+	})
+}
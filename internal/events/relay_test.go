@@ -0,0 +1,36 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+// loopback es un Broker en memoria que entrega cada mensaje a quien escucha.
+type loopback struct {
+	fn func(payload string)
+}
+
+func (b *loopback) Notify(ctx context.Context, channel, payload string) error {
+	b.fn(payload)
+	return nil
+}
+
+func (b *loopback) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	b.fn = fn
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	b := &loopback{}
+	var got []Event
+	Receive(context.Background(), b, "events", func(ctx context.Context, e Event) { got = append(got, e) })
+
+	bus := NewBus()
+	bus.Subscribe(Forward(b, "events"))
+	e, _ := New(context.Background(), PetUpdated, "p1", map[string]string{"name": "Fido"})
+	bus.Publish(context.Background(), e)
+
+	if len(got) != 1 || got[0].ID != e.ID || got[0].Type != PetUpdated || string(got[0].Data) != `{"name":"Fido"}` {
+		t.Errorf("expected %+v to be relayed, got %+v", e, got)
+	}
+}
@@ -0,0 +1,167 @@
+// Package fixtures contiene los datos de ejemplo de la aplicación, versionados junto al
+// código como archivos JSON embebidos en el binario. Son los mismos para los tests, el
+// desarrollo local y la demo; store.Seed los carga en cualquier store.
+package fixtures
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Version es la versión del formato de los archivos que entiende este paquete. Un archivo
+// con otra versión se rechaza en lugar de cargarse a medias.
+const Version = 1
+
+// Conjuntos embebidos. Base son las razas y mascotas con las que cuentan los tests y el
+// entorno de desarrollo; Demo añade razas y mascotas para la demo, y se carga sobre Base.
+const (
+	Base = "base"
+	Demo = "demo"
+)
+
+// ErrInvalid indica que un archivo de fixtures no es válido.
+var ErrInvalid = errors.New("invalid fixtures")
+
+//go:embed data/*.json
+var data embed.FS
+
+// Set son las razas y mascotas de uno o más archivos.
+type Set struct {
+	Breeds []types.Breed
+	Pets   []Pet
+}
+
+// Pet es una mascota de ejemplo. No tiene ID: se identifica por su nombre y su raza, para
+// que cargarla otra vez actualice la misma mascota en lugar de crear otra.
+type Pet struct {
+	Name  string
+	Birth time.Time
+	Breed types.BreedID
+}
+
+// file es el formato de los archivos.
+type file struct {
+	Version int           `json:"version"`
+	Breeds  []types.Breed `json:"breeds"`
+	Pets    []struct {
+		Name  string `json:"name"`
+		Birth string `json:"birth"`
+		Breed string `json:"breed"`
+	} `json:"pets"`
+}
+
+// Names devuelve los conjuntos embebidos.
+func Names() []string {
+	entries, _ := fs.ReadDir(data, "data")
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = strings.TrimSuffix(e.Name(), ".json")
+	}
+	return names
+}
+
+// Load lee los conjuntos embebidos indicados y los une en orden (ver Merge).
+func Load(names ...string) (*Set, error) {
+	sets := make([]*Set, len(names))
+	for i, name := range names {
+		f, err := data.Open("data/" + name + ".json")
+		if err != nil {
+			return nil, fmt.Errorf("unknown fixture set %q", name)
+		}
+		sets[i], err = Read(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("fixture set %q: %w", name, err)
+		}
+	}
+	return Merge(sets...)
+}
+
+// MustLoad es como Load pero entra en pánico si falla. Los conjuntos embebidos se
+// comprueban en los tests, así que solo falla con un nombre desconocido.
+func MustLoad(names ...string) *Set {
+	s, err := Load(names...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Read lee un archivo de fixtures con el formato de los embebidos, p. ej. uno propio de un
+// entorno. Devuelve un error envuelto en ErrInvalid si el archivo no es válido. Sus mascotas
+// pueden ser de razas de otro archivo: eso se comprueba al unirlos con Merge.
+func Read(r io.Reader) (*Set, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var f file
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("%w: version %d is not supported, expected %d", ErrInvalid, f.Version, Version)
+	}
+
+	s := &Set{Breeds: f.Breeds, Pets: make([]Pet, len(f.Pets))}
+	for i, p := range f.Pets {
+		birth, err := time.Parse(time.DateOnly, p.Birth)
+		if err != nil {
+			return nil, fmt.Errorf("%w: pet %q: bad date of birth format, use YYYY-MM-DD", ErrInvalid, p.Name)
+		}
+		breed, err := types.ParseBreedID(p.Breed)
+		if err != nil {
+			return nil, fmt.Errorf("%w: pet %q: %v", ErrInvalid, p.Name, err)
+		}
+		s.Pets[i] = Pet{Name: strings.TrimSpace(p.Name), Birth: birth, Breed: breed}
+		if s.Pets[i].Name == "" {
+			return nil, fmt.Errorf("%w: pet %d has no name", ErrInvalid, i+1)
+		}
+	}
+	for _, b := range s.Breeds {
+		if err := b.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: breed %q: %v", ErrInvalid, b.ID, err)
+		}
+	}
+	return s, nil
+}
+
+// Merge une los conjuntos en orden. Una raza repetida reemplaza a la anterior, así que un
+// conjunto puede corregir las razas de otro; una mascota repetida (mismo nombre y raza) es
+// un error, igual que una mascota de una raza que no está en ningún conjunto.
+func Merge(sets ...*Set) (*Set, error) {
+	merged := &Set{Breeds: []types.Breed{}, Pets: []Pet{}}
+	breeds := map[types.BreedID]int{}
+	for _, s := range sets {
+		for _, b := range s.Breeds {
+			if i, ok := breeds[b.ID]; ok {
+				merged.Breeds[i] = b
+				continue
+			}
+			breeds[b.ID] = len(merged.Breeds)
+			merged.Breeds = append(merged.Breeds, b)
+		}
+	}
+
+	pets := map[Pet]bool{}
+	for _, s := range sets {
+		for _, p := range s.Pets {
+			if _, ok := breeds[p.Breed]; !ok {
+				return nil, fmt.Errorf("%w: pet %q: breed %q not found", ErrInvalid, p.Name, p.Breed)
+			}
+			key := Pet{Name: p.Name, Breed: p.Breed}
+			if pets[key] {
+				return nil, fmt.Errorf("%w: pet %q of breed %q is repeated", ErrInvalid, p.Name, p.Breed)
+			}
+			pets[key] = true
+			merged.Pets = append(merged.Pets, p)
+		}
+	}
+	return merged, nil
+}
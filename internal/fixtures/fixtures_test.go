@@ -0,0 +1,78 @@
+package fixtures
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedSets(t *testing.T) {
+	if names := Names(); !slices.Equal(names, []string{Base, Demo}) {
+		t.Errorf("unexpected sets %v", names)
+	}
+	base, err := Load(Base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(base.Breeds) != 5 || len(base.Pets) != 2 || base.Pets[0].Name != "Buddy" || base.Pets[0].Birth.Format("2006-01-02") != "2022-05-10" {
+		t.Errorf("unexpected base set %+v", base)
+	}
+	// Demo usa razas de Base: solo se puede cargar sobre ella.
+	if _, err := Load(Demo); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected demo alone to be invalid, got %v", err)
+	}
+	demo, err := Load(Base, Demo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(demo.Breeds) <= len(base.Breeds) || len(demo.Pets) <= len(base.Pets) {
+		t.Errorf("expected demo to add breeds and pets, got %+v", demo)
+	}
+	if _, err := Load("missing"); err == nil {
+		t.Error("expected an error for an unknown set")
+	}
+}
+
+func TestRead(t *testing.T) {
+	const valid = `{"version": 1,
+		"breeds": [{"id": "poodle", "name": "Poodle", "size": "medium"}],
+		"pets": [{"name": "Luna", "birth": "2021-03-04", "breed": "poodle"}]}`
+	s, err := Read(strings.NewReader(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Breeds) != 1 || len(s.Pets) != 1 || s.Pets[0].Breed != "poodle" {
+		t.Errorf("unexpected set %+v", s)
+	}
+
+	for name, data := range map[string]string{
+		"other version": strings.Replace(valid, `"version": 1`, `"version": 2`, 1),
+		"unknown field": strings.Replace(valid, `"version": 1`, `"version": 1, "owners": []`, 1),
+		"bad birth":     strings.Replace(valid, "2021-03-04", "04/03/2021", 1),
+		"bad breed id":  strings.Replace(valid, `"breed": "poodle"`, `"breed": "Poodle"`, 1),
+		"no pet name":   strings.Replace(valid, `"Luna"`, `" "`, 1),
+		"unknown size":  strings.Replace(valid, `"medium"`, `"huge"`, 1),
+	} {
+		if _, err := Read(strings.NewReader(data)); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := MustLoad(Base)
+	override := &Set{Breeds: slices.Clone(base.Breeds[:1])}
+	override.Breeds[0].Origin = "Somewhere else"
+	merged, err := Merge(base, override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Breeds) != len(base.Breeds) || merged.Breeds[0].Origin != "Somewhere else" {
+		t.Errorf("expected the later breed to replace the earlier one, got %+v", merged.Breeds)
+	}
+
+	if _, err := Merge(base, &Set{Pets: base.Pets[:1]}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected a repeated pet to be invalid, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// AuditHandler expone el historial de cambios a los administradores.
+type AuditHandler struct {
+	auditStore store.AuditStore
+}
+
+func NewAuditHandler(as store.AuditStore) *AuditHandler {
+	return &AuditHandler{
+		auditStore: as,
+	}
+}
+
+// GetHistoryHandler devuelve todos los cambios de una entidad en orden cronológico.
+// Ruta: GET /api/v1/admin/audit/{entityType}/{entityID}
+func (ah *AuditHandler) GetHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	entityType := r.PathValue("entityType")
+	entityID := r.PathValue("entityID")
+
+	switch entityType {
+	case audit.EntityPet:
+		id, err := types.ParsePetID(entityID)
+		if err != nil {
+			http.Error(w, "Invalid pet ID. It must be a UUID", http.StatusBadRequest)
+			return
+		}
+		entityID = id.String()
+	case audit.EntityBreed:
+		if _, err := types.ParseBreedID(entityID); err != nil {
+			http.Error(w, "Entity not found", http.StatusNotFound)
+			return
+		}
+	default:
+		http.Error(w, "Unknown entity type", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := ah.auditStore.GetAuditHistory(r.Context(), entityType, entityID)
+	if err != nil {
+		writeStoreError(w, err, "Entity not found", "Internal Server Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		log.Printf("Error al codificar el historial a JSON: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+type AuditStoreMock struct {
+	entries []types.AuditEntry
+}
+
+func (m *AuditStoreMock) GetAuditHistory(ctx context.Context, entityType, entityID string) ([]types.AuditEntry, error) {
+	var out []types.AuditEntry
+	for _, e := range m.entries {
+		if e.EntityType == entityType && e.EntityID == entityID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestGetAuditHistoryHandler(t *testing.T) {
+	auditStore := &AuditStoreMock{entries: []types.AuditEntry{
+		{ID: 1, EntityType: "pet", EntityID: petID1, Action: "create", Actor: "alice", After: json.RawMessage(`{"id":"p1"}`)},
+		{ID: 2, EntityType: "pet", EntityID: petID2, Action: "create", Actor: "bob"},
+		{ID: 3, EntityType: "pet", EntityID: petID1, Action: "delete", Actor: "bob", Before: json.RawMessage(`{"id":"p1"}`)},
+	}}
+	router := http.NewServeMux()
+	RegisterAdminRoutes(router, auditStore)
+
+	t.Run("history of one entity", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/admin/audit/pet/"+petID1, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		var got []types.AuditEntry
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("error decoding: %v", err)
+		}
+		if len(got) != 2 || got[0].Action != "create" || got[1].Action != "delete" {
+			t.Errorf("unexpected history: %+v", got)
+		}
+	})
+
+	t.Run("malformed pet ID", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/admin/audit/pet/p1", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("unknown entity type", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/admin/audit/owner/p1", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", rec.Code)
+		}
+	})
+}
@@ -2,12 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"log"
 	"net/http"
 	"path"
 
 	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
 )
 
 // BreedHandler es un struct que contendrá las dependencias (como el store) necesarias para los handlers de razas.
@@ -28,10 +28,9 @@ func NewBreedHandler(bs store.BreedStore) *BreedHandler {
 // Es un método en el BreedHandler, lo que nos da acceso a 'h.breedStore'.
 func (h *BreedHandler) GetBreedsHandler(w http.ResponseWriter, r *http.Request) {
 	// Obtenemos las razas desde nuestro store.
-	breeds, err := h.breedStore.GetBreeds()
+	breeds, err := h.breedStore.GetBreeds(r.Context())
 	if err != nil {
-		log.Printf("Error al obtener razas desde el store: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeStoreError(w, err, "Breed not found", "Internal Server Error")
 		return
 	}
 
@@ -48,17 +47,17 @@ func (h *BreedHandler) GetBreedsHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *BreedHandler) GetBreedByIDHandler(w http.ResponseWriter, r *http.Request) {
-	id := path.Base(r.URL.Path)
+	// Un slug mal formado no puede corresponder a ninguna raza: se responde 404 sin consultar el store.
+	id, err := types.ParseBreedID(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, "Breed not found", http.StatusNotFound)
+		return
+	}
 
-	breed, err := h.breedStore.GetBreedByID(id)
+	breed, err := h.breedStore.GetBreedByID(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			http.Error(w, "Breed not found", http.StatusNotFound)
-			return
-		} else {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
-		}
+		writeStoreError(w, err, "Breed not found", "Internal Server Error")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -12,7 +13,7 @@ import (
 
 type StoreMock struct{}
 
-func (sm *StoreMock) GetBreeds() ([]types.Breed, error) {
+func (sm *StoreMock) GetBreeds(ctx context.Context) ([]types.Breed, error) {
 	return []types.Breed{
 		{ID: "mock-breed-1", Name: "Mock Poodle", Temperament: "Mock Temp 1", Origin: "Mockland"},
 		{ID: "mock-breed-2", Name: "Mock Bulldog", Temperament: "Mock Temp 2", Origin: "Mockland"},
@@ -20,7 +21,7 @@ func (sm *StoreMock) GetBreeds() ([]types.Breed, error) {
 }
 
 // GetBreedByID implementa el método GetBreedByID de la interfaz BreedStore para el mock.
-func (m *StoreMock) GetBreedByID(id string) (*types.Breed, error) {
+func (m *StoreMock) GetBreedByID(ctx context.Context, id types.BreedID) (*types.Breed, error) {
 	if id == "mock-breed-1" {
 		return &types.Breed{ID: "mock-breed-1", Name: "Mock Poodle", Temperament: "Mock Temp 1", Origin: "Mockland"}, nil
 	}
@@ -94,7 +95,7 @@ func TestGetBreedByIDHandler(t *testing.T) {
 			t.Errorf("Error al decodificar la respuesta JSON: %v", err)
 		}
 
-		expectedID := "mock-breed-1"
+		expectedID := types.BreedID("mock-breed-1")
 		expectedName := "Mock Poodle"
 		if breed.ID != expectedID {
 			t.Errorf("ID de raza incorrecto: esperado '%s', obtenido '%s'", expectedID, breed.ID)
@@ -125,4 +126,16 @@ func TestGetBreedByIDHandler(t *testing.T) {
 			t.Errorf("Encabezado Content-Type incorrecto: esperado 'text/plain; charset=utf-8', obtenido '%s'", recorder.Header().Get("Content-Type"))
 		}
 	})
+
+	t.Run("should return 404 for a malformed slug without querying the store", func(t *testing.T) {
+		handler := NewBreedHandler(&StoreMock{})
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/api/v1/breeds/Not%20A%20Slug", nil)
+
+		handler.GetBreedByIDHandler(recorder, request)
+
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("Código de estado incorrecto para un slug mal formado: esperado %d, obtenido %d", http.StatusNotFound, recorder.Code)
+		}
+	})
 }
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// maxBulkItems es el máximo de elementos por lote.
+const maxBulkItems = 100
+
+// errBulkRejected deshace la transacción de un lote atómico en el que falló algún elemento.
+var errBulkRejected = errors.New("bulk operation rejected")
+
+// BulkHandler crea y borra mascotas en lote.
+type BulkHandler struct {
+	petStore   store.PetStore
+	breedStore store.BreedStore
+	transactor store.Transactor
+	// events recibe los cambios una vez confirmados, igual que desde PetHandler.
+	events events.Publisher
+	// now es la fecha con la que se calcula la edad de las mascotas.
+	now func() time.Time
+}
+
+func NewBulkHandler(ps store.PetStore, bs store.BreedStore, tr store.Transactor, pub events.Publisher) *BulkHandler {
+	return &BulkHandler{
+		petStore:   ps,
+		breedStore: bs,
+		transactor: tr,
+		events:     pub,
+		now:        time.Now,
+	}
+}
+
+// bulkPet es un elemento de un lote de altas ya validado.
+type bulkPet struct {
+	name    string
+	birth   time.Time
+	breedID types.BreedID
+}
+
+// CreatePetsHandler crea hasta 100 mascotas. Primero valida todos los elementos. En modo
+// "atomic" (por defecto), si alguno no es válido responde 422 sin crear ninguno y, si no,
+// los crea en una sola transacción y responde 201. En modo "partial" crea los válidos uno a
+// uno y responde 200 con el resultado de cada uno.
+// Ruta: POST /api/v1/pets/bulk
+func (bh *BulkHandler) CreatePetsHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var requestBody types.BulkCreatePetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+	mode, ok := bulkMode(w, requestBody.Mode, len(requestBody.Items))
+	if !ok {
+		return
+	}
+
+	results := make([]types.BulkResult, len(requestBody.Items))
+	pets := make([]bulkPet, len(requestBody.Items))
+	breeds := map[string]error{}
+	valid := true
+	for i, item := range requestBody.Items {
+		results[i] = types.BulkResult{Index: i}
+		pet, err := bh.checkItem(r.Context(), item, breeds)
+		var itemErr bulkItemError
+		if err != nil && mode == types.BulkAtomic && !errors.As(err, &itemErr) {
+			// No se pudo comprobar la raza: el lote entero falla por el store.
+			writeStoreError(w, err, "Breed not found", "Error at checking the breed")
+			return
+		}
+		if err != nil {
+			results[i].Status, results[i].Error = bulkError(err)
+			valid = false
+			continue
+		}
+		pets[i] = pet
+	}
+
+	if mode == types.BulkPartial {
+		for i, pet := range pets {
+			if results[i].Status != "" {
+				continue
+			}
+			created, err := bh.petStore.CreatePet(r.Context(), pet.name, pet.birth, pet.breedID)
+			if err != nil {
+				results[i].Status, results[i].Error = bulkError(err)
+				continue
+			}
+			bh.created(r, &results[i], created)
+		}
+		writeBulkResponse(w, http.StatusOK, results)
+		return
+	}
+
+	if !valid {
+		writeBulkResponse(w, http.StatusUnprocessableEntity, skipValid(results))
+		return
+	}
+	created := make([]*types.Pet, len(pets))
+	err := bh.transactor.WithinTx(r.Context(), func(tx store.Tx) error {
+		for i, pet := range pets {
+			p, err := tx.CreatePet(r.Context(), pet.name, pet.birth, pet.breedID)
+			if err != nil {
+				return err
+			}
+			created[i] = p
+		}
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, err, "Breed not found", "Error creating pets")
+		return
+	}
+	for i, p := range created {
+		bh.created(r, &results[i], p)
+	}
+	writeBulkResponse(w, http.StatusCreated, results)
+}
+
+// checkItem valida un elemento de un lote de altas. breeds guarda el resultado de
+// comprobar cada raza, para consultar una sola vez las que se repiten.
+func (bh *BulkHandler) checkItem(ctx context.Context, item types.CreatePetRequest, breeds map[string]error) (bulkPet, error) {
+	birth, err := time.Parse("2006-01-02", item.Birth)
+	if err != nil {
+		return bulkPet{}, invalidItem("Bad date of birth format. Use YYYY-MM-DD")
+	}
+	breedErr, checked := breeds[item.BreedID]
+	if !checked {
+		breedErr = bh.checkBreed(ctx, item.BreedID)
+		breeds[item.BreedID] = breedErr
+	}
+	if breedErr != nil {
+		return bulkPet{}, breedErr
+	}
+	return bulkPet{name: item.Name, birth: birth, breedID: types.BreedID(item.BreedID)}, nil
+}
+
+func (bh *BulkHandler) checkBreed(ctx context.Context, raw string) error {
+	breedID, err := types.ParseBreedID(raw)
+	if err != nil {
+		return invalidItem("Breed not found")
+	}
+	if _, err := bh.breedStore.GetBreedByID(ctx, breedID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return invalidItem("Breed not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (bh *BulkHandler) created(r *http.Request, res *types.BulkResult, p *types.Pet) {
+	publishPetEvent(bh.events, r, events.PetCreated, p)
+	pr := types.NewPetResponse(*p, bh.now())
+	res.Status, res.ID, res.Pet = types.BulkCreated, p.ID, &pr
+}
+
+// DeletePetsHandler borra hasta 100 mascotas por ID, sin comprobar su versión (como
+// If-Match: *). En modo "atomic" (por defecto), si algún ID no es válido o no existe
+// responde 422 sin borrar ninguna. En modo "partial" borra las que existan. En ambos casos
+// responde 200 con el resultado de cada ID.
+// Ruta: POST /api/v1/pets/bulk/delete
+func (bh *BulkHandler) DeletePetsHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var requestBody types.BulkDeletePetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+	mode, ok := bulkMode(w, requestBody.Mode, len(requestBody.IDs))
+	if !ok {
+		return
+	}
+
+	results := make([]types.BulkResult, len(requestBody.IDs))
+	ids := make([]types.PetID, len(requestBody.IDs))
+	seen := map[types.PetID]bool{}
+	valid := true
+	for i, raw := range requestBody.IDs {
+		results[i] = types.BulkResult{Index: i}
+		id, err := types.ParsePetID(raw)
+		switch {
+		case err != nil:
+			results[i].Status, results[i].Error = types.BulkInvalid, "Invalid pet ID. It must be a UUID"
+		case seen[id]:
+			results[i].Status, results[i].Error = types.BulkInvalid, "Duplicated pet ID"
+		default:
+			ids[i], seen[id] = id, true
+			results[i].ID = id
+			continue
+		}
+		valid = false
+	}
+
+	if mode == types.BulkPartial {
+		for i, id := range ids {
+			if results[i].Status != "" {
+				continue
+			}
+			pet, err := bh.petStore.DeletePet(r.Context(), id, store.AnyVersion)
+			if err != nil {
+				results[i].Status, results[i].Error = bulkError(err)
+				continue
+			}
+			bh.deleted(r, &results[i], pet)
+		}
+		writeBulkResponse(w, http.StatusOK, results)
+		return
+	}
+
+	if !valid {
+		writeBulkResponse(w, http.StatusUnprocessableEntity, skipValid(results))
+		return
+	}
+	deleted := make([]*types.Pet, len(ids))
+	err := bh.transactor.WithinTx(r.Context(), func(tx store.Tx) error {
+		rejected := false
+		for i, id := range ids {
+			var err error
+			deleted[i], err = tx.DeletePet(r.Context(), id, store.AnyVersion)
+			if errors.Is(err, store.ErrNotFound) {
+				results[i].Status = types.BulkNotFound
+				rejected = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if rejected {
+			return errBulkRejected
+		}
+		return nil
+	})
+	if errors.Is(err, errBulkRejected) {
+		writeBulkResponse(w, http.StatusUnprocessableEntity, skipValid(results))
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Error deleting pets")
+		return
+	}
+	for i := range results {
+		bh.deleted(r, &results[i], deleted[i])
+	}
+	writeBulkResponse(w, http.StatusOK, results)
+}
+
+func (bh *BulkHandler) deleted(r *http.Request, res *types.BulkResult, pet *types.Pet) {
+	publishPetEvent(bh.events, r, events.PetDeleted, pet)
+	res.Status = types.BulkDeleted
+}
+
+// bulkMode valida el modo y el tamaño del lote. Si no son válidos responde 400 o 413 y
+// devuelve ok=false.
+func bulkMode(w http.ResponseWriter, mode string, items int) (string, bool) {
+	switch mode {
+	case "":
+		mode = types.BulkAtomic
+	case types.BulkAtomic, types.BulkPartial:
+	default:
+		http.Error(w, `Mode must be "atomic" or "partial"`, http.StatusBadRequest)
+		return "", false
+	}
+	if items == 0 {
+		http.Error(w, "At least one item is required", http.StatusBadRequest)
+		return "", false
+	}
+	if items > maxBulkItems {
+		http.Error(w, "At most "+strconv.Itoa(maxBulkItems)+" items are allowed per request", http.StatusRequestEntityTooLarge)
+		return "", false
+	}
+	return mode, true
+}
+
+// bulkItemError es un error de validación de un elemento, con el mensaje para el cliente.
+type bulkItemError string
+
+func (e bulkItemError) Error() string { return string(e) }
+
+func invalidItem(msg string) error { return bulkItemError(msg) }
+
+// bulkError traduce el error de un elemento a su estado y mensaje.
+func bulkError(err error) (status, msg string) {
+	var itemErr bulkItemError
+	switch {
+	case errors.As(err, &itemErr):
+		return types.BulkInvalid, string(itemErr)
+	case errors.Is(err, store.ErrNotFound):
+		return types.BulkNotFound, ""
+	case errors.Is(err, store.ErrInvalidInput), errors.Is(err, store.ErrForeignKeyViolation):
+		return types.BulkInvalid, "Invalid input"
+	default:
+		log.Printf("Error del store en una operación en lote: %v", err)
+		return types.BulkFailed, "Error applying item"
+	}
+}
+
+// skipValid marca como omitidos los elementos de un lote atómico rechazado que no fallaron.
+func skipValid(results []types.BulkResult) []types.BulkResult {
+	for i := range results {
+		if results[i].Status == "" {
+			results[i].Status = types.BulkSkipped
+		}
+	}
+	return results
+}
+
+func writeBulkResponse(w http.ResponseWriter, status int, results []types.BulkResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(types.BulkResponse{Results: results}); err != nil {
+		log.Printf("Error al codificar los resultados del lote a JSON: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func TestBulkHandlers(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1"}}
+	newStore := func() *store.MemoryStore {
+		return store.NewMemoryStore(breeds,
+			types.Pet{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0], Version: 1},
+			types.Pet{ID: petID2, Name: "Rex", Birth: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0], Version: 1},
+		)
+	}
+	do := func(t *testing.T, s *store.MemoryStore, h func(*BulkHandler) http.HandlerFunc, body string) (int, []string, []string) {
+		t.Helper()
+		bus := events.NewBus()
+		var published []string
+		bus.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e.Type) })
+		rec := httptest.NewRecorder()
+		h(NewBulkHandler(s, s, s, bus))(rec, httptest.NewRequest("POST", "/api/v1/pets/bulk", strings.NewReader(body)))
+		var got types.BulkResponse
+		if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("error decoding: %v", err)
+			}
+		}
+		var statuses []string
+		for i, r := range got.Results {
+			if r.Index != i {
+				t.Errorf("expected index %d, got %d", i, r.Index)
+			}
+			statuses = append(statuses, r.Status)
+		}
+		return rec.Code, statuses, published
+	}
+	create := func(bh *BulkHandler) http.HandlerFunc { return bh.CreatePetsHandler }
+	del := func(bh *BulkHandler) http.HandlerFunc { return bh.DeletePetsHandler }
+	countPets := func(t *testing.T, s *store.MemoryStore) int {
+		t.Helper()
+		pets, err := s.GetPets(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(pets)
+	}
+
+	const items = `[
+		{"name":"A","birth":"2022-01-01","breedId":"b1"},
+		{"name":"B","birth":"not a date","breedId":"b1"},
+		{"name":"C","birth":"2022-01-01","breedId":"unknown"},
+		{"name":"D","birth":"2022-03-03","breedId":"b1"}
+	]`
+
+	t.Run("atomic create creates every pet", func(t *testing.T) {
+		s := newStore()
+		code, statuses, published := do(t, s, create, `{"items":[{"name":"A","birth":"2022-01-01","breedId":"b1"},{"name":"B","birth":"2022-02-02","breedId":"b1"}]}`)
+		if code != http.StatusCreated || strings.Join(statuses, ",") != "created,created" {
+			t.Fatalf("expected 201 and created,created, got %d and %v", code, statuses)
+		}
+		if countPets(t, s) != 4 || len(published) != 2 {
+			t.Errorf("expected 4 pets and 2 events, got %d and %d", countPets(t, s), len(published))
+		}
+	})
+
+	t.Run("atomic create rejects the batch if an item is invalid", func(t *testing.T) {
+		s := newStore()
+		code, statuses, published := do(t, s, create, `{"items":`+items+`}`)
+		if code != http.StatusUnprocessableEntity || strings.Join(statuses, ",") != "skipped,invalid,invalid,skipped" {
+			t.Fatalf("expected 422 and skipped,invalid,invalid,skipped, got %d and %v", code, statuses)
+		}
+		if countPets(t, s) != 2 || len(published) != 0 {
+			t.Errorf("expected no changes, got %d pets and %d events", countPets(t, s), len(published))
+		}
+	})
+
+	t.Run("partial create creates the valid pets", func(t *testing.T) {
+		s := newStore()
+		code, statuses, published := do(t, s, create, `{"mode":"partial","items":`+items+`}`)
+		if code != http.StatusOK || strings.Join(statuses, ",") != "created,invalid,invalid,created" {
+			t.Fatalf("expected 200 and created,invalid,invalid,created, got %d and %v", code, statuses)
+		}
+		if countPets(t, s) != 4 || len(published) != 2 {
+			t.Errorf("expected 4 pets and 2 events, got %d and %d", countPets(t, s), len(published))
+		}
+	})
+
+	const missing = "9f1c2b3a-4d5e-4f60-8a7b-1c2d3e4f5a6b"
+	t.Run("atomic delete", func(t *testing.T) {
+		s := newStore()
+		code, statuses, _ := do(t, s, del, `{"ids":["`+petID1+`","`+missing+`"]}`)
+		if code != http.StatusUnprocessableEntity || strings.Join(statuses, ",") != "skipped,notFound" {
+			t.Fatalf("expected 422 and skipped,notFound, got %d and %v", code, statuses)
+		}
+		if countPets(t, s) != 2 {
+			t.Errorf("expected the batch to be rolled back, got %d pets", countPets(t, s))
+		}
+
+		code, statuses, published := do(t, s, del, `{"ids":["`+petID1+`","`+petID2+`"]}`)
+		if code != http.StatusOK || strings.Join(statuses, ",") != "deleted,deleted" {
+			t.Fatalf("expected 200 and deleted,deleted, got %d and %v", code, statuses)
+		}
+		if countPets(t, s) != 0 || len(published) != 2 {
+			t.Errorf("expected no pets and 2 events, got %d and %d", countPets(t, s), len(published))
+		}
+	})
+
+	t.Run("partial delete", func(t *testing.T) {
+		s := newStore()
+		code, statuses, _ := do(t, s, del, `{"mode":"partial","ids":["`+petID1+`","`+missing+`","bad","`+petID1+`"]}`)
+		if code != http.StatusOK || strings.Join(statuses, ",") != "deleted,notFound,invalid,invalid" {
+			t.Fatalf("expected 200 and deleted,notFound,invalid,invalid, got %d and %v", code, statuses)
+		}
+		if countPets(t, s) != 1 {
+			t.Errorf("expected 1 pet left, got %d", countPets(t, s))
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		s := newStore()
+		for body, want := range map[string]int{
+			`{`:                            http.StatusBadRequest,
+			`{"items":[]}`:                 http.StatusBadRequest,
+			`{"mode":"some","items":[{}]}`: http.StatusBadRequest,
+			`{"items":[` + strings.Repeat(`{},`, maxBulkItems) + `{}]}`: http.StatusRequestEntityTooLarge,
+		} {
+			if code, _, _ := do(t, s, create, body); code != want {
+				t.Errorf("%.30s: expected %d, got %d", body, want, code)
+			}
+		}
+	})
+}
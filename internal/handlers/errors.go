@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+)
+
+// writeStoreError traduce un error del store a la respuesta HTTP adecuada.
+// notFound es el mensaje para store.ErrNotFound (p. ej. "Pet not found") y fallback el
+// mensaje de los errores internos no reconocidos, que además se registran en el log.
+func writeStoreError(w http.ResponseWriter, err error, notFound, fallback string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, store.ErrVersionConflict):
+		http.Error(w, "Resource was modified by another request", http.StatusPreconditionFailed)
+	case errors.Is(err, store.ErrUniqueViolation):
+		http.Error(w, "Resource already exists", http.StatusConflict)
+	case errors.Is(err, store.ErrForeignKeyViolation):
+		http.Error(w, "Resource conflicts with a related resource", http.StatusConflict)
+	case errors.Is(err, store.ErrInvalidInput):
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+	case errors.Is(err, store.ErrUnavailable), errors.Is(err, store.ErrCanceled):
+		log.Printf("Store no disponible: %v", err)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	default:
+		log.Printf("Error del store: %v", err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func TestWriteStoreError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{store.ErrNotFound, http.StatusNotFound},
+		{store.ErrVersionConflict, http.StatusPreconditionFailed},
+		{fmt.Errorf("insert: %w", store.ErrUniqueViolation), http.StatusConflict},
+		{fmt.Errorf("insert: %w", store.ErrForeignKeyViolation), http.StatusConflict},
+		{fmt.Errorf("query: %w", store.ErrInvalidInput), http.StatusBadRequest},
+		{fmt.Errorf("query: %w", store.ErrUnavailable), http.StatusServiceUnavailable},
+		{fmt.Errorf("query: %w", store.ErrCanceled), http.StatusServiceUnavailable},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeStoreError(rec, tt.err, "Pet not found", "Internal Server Error")
+		if rec.Code != tt.want {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestGetPetsHandlerStoreUnavailable(t *testing.T) {
+	handler := NewPetHandler(&unavailablePetStore{}, store.NewMemoryStore(nil), events.NewBus())
+
+	req, _ := http.NewRequest("GET", "/api/v1/pets", nil)
+	rec := httptest.NewRecorder()
+	handler.PetsHandler(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected a Retry-After header")
+	}
+}
+
+// unavailablePetStore simula una base de datos caída. Solo implementa GetPets: cualquier
+// otra llamada entra en pánico, así que también sirve para comprobar que un handler no
+// llega a consultar el store.
+type unavailablePetStore struct {
+	store.PetStore
+}
+
+func (s *unavailablePetStore) GetPets(ctx context.Context) ([]types.Pet, error) {
+	return nil, fmt.Errorf("failed to query pets: %w", store.ErrUnavailable)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// MedicalHandler expone el historial médico de las mascotas: visitas al veterinario y
+// enfermedades o alergias.
+type MedicalHandler struct {
+	medicalStore store.MedicalStore
+}
+
+func NewMedicalHandler(ms store.MedicalStore) *MedicalHandler {
+	return &MedicalHandler{
+		medicalStore: ms,
+	}
+}
+
+// GetHistoryHandler devuelve las visitas y condiciones de la mascota en una sola línea de tiempo.
+// Ruta: GET /api/v1/pets/{id}/medical
+func (mh *MedicalHandler) GetHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := petIDFromPathValue(w, r)
+	if !ok {
+		return
+	}
+
+	visits, err := mh.medicalStore.GetVetVisits(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Internal Server Error")
+		return
+	}
+	conditions, err := mh.medicalStore.GetConditions(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Internal Server Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(types.NewMedicalHistory(id, visits, conditions)); err != nil {
+		log.Printf("Error al codificar el historial médico a JSON: %v", err)
+	}
+}
+
+// AddVisitHandler registra una visita al veterinario.
+// Ruta: POST /api/v1/pets/{id}/medical/visits
+func (mh *MedicalHandler) AddVisitHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	id, ok := petIDFromPathValue(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody types.CreateVetVisitRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+
+	date, err := time.Parse(time.DateOnly, requestBody.Date)
+	if err != nil {
+		http.Error(w, "Bad visit date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(requestBody.Clinic) == "" || strings.TrimSpace(requestBody.Reason) == "" {
+		http.Error(w, "Clinic and reason are required", http.StatusBadRequest)
+		return
+	}
+	for _, a := range requestBody.Attachments {
+		if a.Name == "" || a.URL == "" || a.Size < 0 {
+			http.Error(w, "Attachments need a name, a URL and a non-negative size", http.StatusBadRequest)
+			return
+		}
+	}
+	var nextDue *time.Time
+	if requestBody.NextDue != "" {
+		d, err := time.Parse(time.DateOnly, requestBody.NextDue)
+		if err != nil {
+			http.Error(w, "Bad next due date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if !d.After(date) {
+			http.Error(w, "The next due date must be after the visit date", http.StatusBadRequest)
+			return
+		}
+		nextDue = &d
+	}
+
+	visit, err := mh.medicalStore.AddVetVisit(r.Context(), types.VetVisit{
+		PetID:       id,
+		Date:        date,
+		Clinic:      requestBody.Clinic,
+		Reason:      requestBody.Reason,
+		Diagnosis:   requestBody.Diagnosis,
+		Treatments:  requestBody.Treatments,
+		Attachments: requestBody.Attachments,
+		NextDue:     nextDue,
+	})
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Error adding vet visit")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(visit); err != nil {
+		log.Printf("Error encoding response for created vet visit: %v", err)
+	}
+}
+
+// AddConditionHandler registra una enfermedad o alergia.
+// Ruta: POST /api/v1/pets/{id}/medical/conditions
+func (mh *MedicalHandler) AddConditionHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	id, ok := petIDFromPathValue(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody types.CreateConditionRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+
+	kind := types.ConditionKind(requestBody.Kind)
+	if kind != types.KindCondition && kind != types.KindAllergy {
+		http.Error(w, `Kind must be "condition" or "allergy"`, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(requestBody.Name) == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	var since *time.Time
+	if requestBody.Since != "" {
+		d, err := time.Parse(time.DateOnly, requestBody.Since)
+		if err != nil {
+			http.Error(w, "Bad since date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		since = &d
+	}
+
+	condition, err := mh.medicalStore.AddCondition(r.Context(), types.Condition{
+		PetID: id,
+		Kind:  kind,
+		Name:  requestBody.Name,
+		Notes: requestBody.Notes,
+		Since: since,
+	})
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Error adding condition")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(condition); err != nil {
+		log.Printf("Error encoding response for created condition: %v", err)
+	}
+}
+
+// petIDFromPathValue es petIDFromPath para las rutas con el comodín {id}.
+func petIDFromPathValue(w http.ResponseWriter, r *http.Request) (types.PetID, bool) {
+	id, err := types.ParsePetID(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid pet ID. It must be a UUID", http.StatusBadRequest)
+		return "", false
+	}
+	return id, true
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func TestMedicalHandlers(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1"}}
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}}
+	s := store.NewMemoryStore(breeds, pets...)
+	// Las rutas de mascotas, del historial médico y de medicación conviven en el mismo router.
+	router := http.NewServeMux()
+	RegisterRoutes(router, s, s, events.NewBus())
+	RegisterMedicalRoutes(router, s)
+	RegisterMedicationRoutes(router, s)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("add a visit", func(t *testing.T) {
+		rec := serve("POST", "/api/v1/pets/"+petID1+"/medical/visits",
+			`{"date":"2024-06-10","clinic":"Vet","reason":"Limping","diagnosis":"Sprain","treatments":["Rest"],"attachments":[{"name":"x-ray.png","contentType":"image/png","size":1024,"url":"https://files.example.com/x-ray.png"}],"nextDue":"2025-06-10"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var got types.VetVisit
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("error decoding: %v", err)
+		}
+		if got.ID == "" || got.PetID != petID1 || got.Clinic != "Vet" || len(got.Attachments) != 1 ||
+			got.NextDue == nil || got.NextDue.Format(time.DateOnly) != "2025-06-10" {
+			t.Errorf("unexpected visit: %+v", got)
+		}
+	})
+
+	t.Run("add conditions", func(t *testing.T) {
+		rec := serve("POST", "/api/v1/pets/"+petID1+"/medical/conditions", `{"kind":"allergy","name":"Chicken","since":"2022-05-03"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		rec = serve("POST", "/api/v1/pets/"+petID1+"/medical/conditions", `{"kind":"condition","name":"Arthritis","notes":"Mild","since":"2024-08-01"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("timeline", func(t *testing.T) {
+		rec := serve("GET", "/api/v1/pets/"+petID1+"/medical", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		var got types.MedicalHistory
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("error decoding: %v", err)
+		}
+		var entries []string
+		for _, e := range got.Timeline {
+			entries = append(entries, e.Type+" "+e.Date.Format(time.DateOnly))
+		}
+		want := []string{"allergy 2022-05-03", "visit 2024-06-10", "condition 2024-08-01"}
+		if strings.Join(entries, ", ") != strings.Join(want, ", ") {
+			t.Errorf("expected timeline %v, got %v", want, entries)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name, path, body string
+		}{
+			{"bad visit date", "visits", `{"date":"10/06/2024","clinic":"Vet","reason":"Checkup"}`},
+			{"missing clinic", "visits", `{"date":"2024-06-10","reason":"Checkup"}`},
+			{"attachment without URL", "visits", `{"date":"2024-06-10","clinic":"Vet","reason":"Checkup","attachments":[{"name":"a.pdf"}]}`},
+			{"bad next due date", "visits", `{"date":"2024-06-10","clinic":"Vet","reason":"Vaccines","nextDue":"next year"}`},
+			{"next due before the visit", "visits", `{"date":"2024-06-10","clinic":"Vet","reason":"Vaccines","nextDue":"2024-06-10"}`},
+			{"unknown kind", "conditions", `{"kind":"injury","name":"Cut"}`},
+			{"missing name", "conditions", `{"kind":"allergy"}`},
+			{"bad since date", "conditions", `{"kind":"allergy","name":"Pollen","since":"yesterday"}`},
+			{"malformed body", "conditions", `{`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := serve("POST", "/api/v1/pets/"+petID1+"/medical/"+tt.path, tt.body)
+				if rec.Code != http.StatusBadRequest {
+					t.Errorf("expected 400, got %d", rec.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("unknown pet", func(t *testing.T) {
+		if rec := serve("GET", "/api/v1/pets/"+petID2+"/medical", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rec.Code)
+		}
+		rec := serve("POST", "/api/v1/pets/"+petID2+"/medical/visits", `{"date":"2024-06-10","clinic":"Vet","reason":"Checkup"}`)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("malformed pet ID", func(t *testing.T) {
+		if rec := serve("GET", "/api/v1/pets/p1/medical", ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+const (
+	// defaultDoseWindow es el periodo del calendario de tomas si el cliente no indica "to".
+	defaultDoseWindow = 7 * 24 * time.Hour
+	// maxDoseWindow y maxDoses acotan el trabajo de una consulta del calendario.
+	maxDoseWindow = 31 * 24 * time.Hour
+	maxDoses      = 500
+	// maxDoseHorizon es cuánto pueden alejarse de ahora el inicio del calendario y las
+	// tomas que se anotan: más lejos no tienen sentido y obligan a recorrer la pauta entera.
+	maxDoseHorizon = 366 * 24 * time.Hour
+)
+
+// MedicationHandler expone las pautas de medicación de las mascotas y su calendario de tomas.
+type MedicationHandler struct {
+	medicationStore store.MedicationStore
+	// now es el inicio por defecto del calendario de tomas.
+	now func() time.Time
+}
+
+func NewMedicationHandler(ms store.MedicationStore) *MedicationHandler {
+	return &MedicationHandler{
+		medicationStore: ms,
+		now:             time.Now,
+	}
+}
+
+// GetMedicationsHandler devuelve las pautas de la mascota.
+// Ruta: GET /api/v1/pets/{id}/medications
+func (mh *MedicationHandler) GetMedicationsHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := petIDFromPathValue(w, r)
+	if !ok {
+		return
+	}
+
+	meds, err := mh.medicationStore.GetMedications(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Internal Server Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(meds); err != nil {
+		log.Printf("Error al codificar las pautas de medicación a JSON: %v", err)
+	}
+}
+
+// AddMedicationHandler registra una pauta de medicación.
+// Ruta: POST /api/v1/pets/{id}/medications
+func (mh *MedicationHandler) AddMedicationHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	id, ok := petIDFromPathValue(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody types.CreateMedicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(requestBody.Drug) == "" || strings.TrimSpace(requestBody.Dosage) == "" {
+		http.Error(w, "Drug and dosage are required", http.StatusBadRequest)
+		return
+	}
+	start, err := time.Parse(time.RFC3339, requestBody.Start)
+	if err != nil {
+		http.Error(w, "Bad start format. Use RFC 3339 (e.g. 2024-01-01T08:00:00-03:00)", http.StatusBadRequest)
+		return
+	}
+	med := types.Medication{
+		PetID:     id,
+		Drug:      requestBody.Drug,
+		Dosage:    requestBody.Dosage,
+		Frequency: requestBody.Frequency,
+		TimeZone:  requestBody.TimeZone,
+		Start:     start,
+	}
+	if requestBody.End != "" {
+		end, err := time.Parse(time.RFC3339, requestBody.End)
+		if err != nil {
+			http.Error(w, "Bad end format. Use RFC 3339 (e.g. 2024-01-31T20:00:00-03:00)", http.StatusBadRequest)
+			return
+		}
+		if end.Before(start) {
+			http.Error(w, "End must not be before start", http.StatusBadRequest)
+			return
+		}
+		med.End = &end
+	}
+	if _, _, err := med.Schedule(); err != nil {
+		http.Error(w, "Invalid frequency or time zone: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := med.FirstDose(); !ok {
+		http.Error(w, "The frequency has no doses between start and end", http.StatusBadRequest)
+		return
+	}
+
+	created, err := mh.medicationStore.AddMedication(r.Context(), med)
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Error adding medication")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(created); err != nil {
+		log.Printf("Error encoding response for created medication: %v", err)
+	}
+}
+
+// GetDosesHandler devuelve las tomas de todas las pautas de la mascota entre from
+// (por defecto, ahora) y to (por defecto, una semana después), con su estado.
+// Ruta: GET /api/v1/pets/{id}/medications/doses?from=...&to=...
+func (mh *MedicationHandler) GetDosesHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := petIDFromPathValue(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, ok := doseWindow(w, r, mh.now())
+	if !ok {
+		return
+	}
+
+	meds, err := mh.medicationStore.GetMedications(r.Context(), id)
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Internal Server Error")
+		return
+	}
+	records, err := mh.medicationStore.GetDoseRecords(r.Context(), id, from, to)
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Internal Server Error")
+		return
+	}
+	doses, err := types.ScheduleDoses(meds, records, from, to, maxDoses)
+	if err != nil {
+		log.Printf("Error al calcular las tomas de la mascota %s: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doses); err != nil {
+		log.Printf("Error al codificar las tomas a JSON: %v", err)
+	}
+}
+
+// RecordDoseHandler anota que una toma prevista se dio o se saltó.
+// Ruta: POST /api/v1/pets/{id}/medications/{medicationId}/doses
+func (mh *MedicationHandler) RecordDoseHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	id, ok := petIDFromPathValue(w, r)
+	if !ok {
+		return
+	}
+	medicationID, err := types.ParseUUID(r.PathValue("medicationId"))
+	if err != nil {
+		http.Error(w, "Invalid medication ID. It must be a UUID", http.StatusBadRequest)
+		return
+	}
+
+	var requestBody types.RecordDoseRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+	status := types.DoseStatus(requestBody.Status)
+	if status != types.DoseGiven && status != types.DoseSkipped {
+		http.Error(w, `Status must be "given" or "skipped"`, http.StatusBadRequest)
+		return
+	}
+	scheduledAt, err := time.Parse(time.RFC3339, requestBody.ScheduledAt)
+	if err != nil {
+		http.Error(w, "Bad scheduledAt format. Use RFC 3339", http.StatusBadRequest)
+		return
+	}
+
+	med, err := mh.medicationStore.GetMedication(r.Context(), id, medicationID)
+	if err != nil {
+		writeStoreError(w, err, "Medication not found", "Internal Server Error")
+		return
+	}
+	if scheduledAt.Before(med.Start) || scheduledAt.Sub(mh.now()) > maxDoseHorizon || !med.IsDose(scheduledAt) {
+		http.Error(w, "scheduledAt is not a dose of this medication", http.StatusBadRequest)
+		return
+	}
+
+	dose, err := mh.medicationStore.RecordDose(r.Context(), types.DoseRecord{
+		MedicationID: medicationID,
+		ScheduledAt:  scheduledAt,
+		Status:       status,
+		Note:         requestBody.Note,
+	})
+	if errors.Is(err, store.ErrUniqueViolation) {
+		http.Error(w, "Dose already recorded", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err, "Medication not found", "Error recording dose")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(dose); err != nil {
+		log.Printf("Error encoding response for recorded dose: %v", err)
+	}
+}
+
+// doseWindow lee from y to de la query. Si faltan o no son válidos responde y devuelve ok=false.
+func doseWindow(w http.ResponseWriter, r *http.Request, now time.Time) (from, to time.Time, ok bool) {
+	from = now
+	if s := r.URL.Query().Get("from"); s != "" {
+		var err error
+		if from, err = time.Parse(time.RFC3339, s); err != nil {
+			http.Error(w, "Bad from format. Use RFC 3339", http.StatusBadRequest)
+			return from, to, false
+		}
+	}
+	if d := from.Sub(now); d > maxDoseHorizon || d < -maxDoseHorizon {
+		http.Error(w, "from must be at most a year away from now", http.StatusBadRequest)
+		return from, to, false
+	}
+	to = from.Add(defaultDoseWindow)
+	if s := r.URL.Query().Get("to"); s != "" {
+		var err error
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			http.Error(w, "Bad to format. Use RFC 3339", http.StatusBadRequest)
+			return from, to, false
+		}
+	}
+	if !to.After(from) || to.Sub(from) > maxDoseWindow {
+		http.Error(w, "to must be after from and at most 31 days later", http.StatusBadRequest)
+		return from, to, false
+	}
+	return from, to, true
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func TestMedicationHandlers(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1"}}
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}}
+	s := store.NewMemoryStore(breeds, pets...)
+	handler := NewMedicationHandler(s)
+	handler.now = func() time.Time { return time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC) }
+	router := http.NewServeMux()
+	router.HandleFunc("GET /api/v1/pets/{id}/medications", handler.GetMedicationsHandler)
+	router.HandleFunc("POST /api/v1/pets/{id}/medications", handler.AddMedicationHandler)
+	router.HandleFunc("GET /api/v1/pets/{id}/medications/doses", handler.GetDosesHandler)
+	router.HandleFunc("POST /api/v1/pets/{id}/medications/{medicationId}/doses", handler.RecordDoseHandler)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var med types.Medication
+	t.Run("add a medication", func(t *testing.T) {
+		rec := serve("POST", "/api/v1/pets/"+petID1+"/medications",
+			`{"drug":"Carprofen","dosage":"25 mg","frequency":"FREQ=DAILY;BYHOUR=8,20","start":"2024-01-01T08:00:00Z","end":"2024-01-03T20:00:00Z"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&med); err != nil {
+			t.Fatalf("error decoding: %v", err)
+		}
+		if med.ID == "" || med.TimeZone != "UTC" || med.End == nil {
+			t.Errorf("unexpected medication: %+v", med)
+		}
+	})
+
+	t.Run("list medications", func(t *testing.T) {
+		rec := serve("GET", "/api/v1/pets/"+petID1+"/medications", "")
+		var got []types.Medication
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("error decoding: %v", err)
+		}
+		if len(got) != 1 || got[0].ID != med.ID {
+			t.Errorf("unexpected medications: %+v", got)
+		}
+	})
+
+	t.Run("record doses", func(t *testing.T) {
+		dosesURL := "/api/v1/pets/" + petID1 + "/medications/" + med.ID + "/doses"
+		if rec := serve("POST", dosesURL, `{"scheduledAt":"2024-01-02T20:00:00Z","status":"given"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec := serve("POST", dosesURL, `{"scheduledAt":"2024-01-02T20:00:00Z","status":"skipped"}`); rec.Code != http.StatusConflict {
+			t.Errorf("expected 409 for a dose recorded twice, got %d", rec.Code)
+		}
+		if rec := serve("POST", dosesURL, `{"scheduledAt":"2024-01-02T21:00:00Z","status":"given"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a time that is not a dose, got %d", rec.Code)
+		}
+		if rec := serve("POST", dosesURL, `{"scheduledAt":"2024-01-04T08:00:00Z","status":"given"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a dose after the end, got %d", rec.Code)
+		}
+		if rec := serve("POST", dosesURL, `{"scheduledAt":"2024-01-02T08:00:00Z","status":"forgotten"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for an unknown status, got %d", rec.Code)
+		}
+		otherMed := "/api/v1/pets/" + petID1 + "/medications/00000000-0000-4000-8000-000000000000/doses"
+		if rec := serve("POST", otherMed, `{"scheduledAt":"2024-01-02T08:00:00Z","status":"given"}`); rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for an unknown medication, got %d", rec.Code)
+		}
+	})
+
+	t.Run("upcoming doses", func(t *testing.T) {
+		rec := serve("GET", "/api/v1/pets/"+petID1+"/medications/doses", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var got []types.Dose
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("error decoding: %v", err)
+		}
+		var doses []string
+		for _, d := range got {
+			doses = append(doses, d.ScheduledAt.Format("02 15h")+" "+string(d.Status))
+		}
+		// Desde "ahora" (el 2 a las 12) hasta el final de la pauta.
+		want := []string{"02 20h given", "03 08h pending", "03 20h pending"}
+		if strings.Join(doses, ", ") != strings.Join(want, ", ") {
+			t.Errorf("expected %v, got %v", want, doses)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name, method, target, body string
+		}{
+			{"missing drug", "POST", "/medications", `{"dosage":"1","frequency":"FREQ=DAILY","start":"2024-01-01T08:00:00Z"}`},
+			{"bad frequency", "POST", "/medications", `{"drug":"D","dosage":"1","frequency":"every day","start":"2024-01-01T08:00:00Z"}`},
+			{"unknown time zone", "POST", "/medications", `{"drug":"D","dosage":"1","frequency":"FREQ=DAILY","timeZone":"Mars/Olympus","start":"2024-01-01T08:00:00Z"}`},
+			{"bad start", "POST", "/medications", `{"drug":"D","dosage":"1","frequency":"FREQ=DAILY","start":"2024-01-01"}`},
+			{"end before start", "POST", "/medications", `{"drug":"D","dosage":"1","frequency":"FREQ=DAILY","start":"2024-01-02T08:00:00Z","end":"2024-01-01T08:00:00Z"}`},
+			{"window too long", "GET", "/medications/doses?from=2024-01-01T00:00:00Z&to=2024-03-01T00:00:00Z", ""},
+			{"bad from", "GET", "/medications/doses?from=yesterday", ""},
+			{"from too far", "GET", "/medications/doses?from=2400-01-01T00:00:00Z", ""},
+			{"no doses", "POST", "/medications", `{"drug":"D","dosage":"1","frequency":"FREQ=HOURLY;INTERVAL=24;BYHOUR=3;COUNT=5","start":"2024-01-01T08:00:00Z"}`},
+			{"dose too far", "POST", "/medications/" + med.ID + "/doses", `{"scheduledAt":"2400-01-01T08:00:00Z","status":"given"}`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if rec := serve(tt.method, "/api/v1/pets/"+petID1+tt.target, tt.body); rec.Code != http.StatusBadRequest {
+					t.Errorf("expected 400, got %d", rec.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("unknown pet", func(t *testing.T) {
+		if rec := serve("GET", "/api/v1/pets/"+petID2+"/medications/doses", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rec.Code)
+		}
+	})
+}
@@ -6,8 +6,11 @@ import (
 	"log"
 	"net/http"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/agugliotta/dog-app-bff/internal/events"
 	"github.com/agugliotta/dog-app-bff/internal/store"
 	"github.com/agugliotta/dog-app-bff/internal/types"
 )
@@ -15,25 +18,36 @@ import (
 type PetHandler struct {
 	petStore   store.PetStore
 	breedStore store.BreedStore
+	// events recibe los cambios de mascotas una vez confirmados.
+	events events.Publisher
+	// now es la fecha con la que se calcula la edad de las mascotas.
+	now func() time.Time
 }
 
-func NewPetHandler(ps store.PetStore, bs store.BreedStore) *PetHandler {
+func NewPetHandler(ps store.PetStore, bs store.BreedStore, pub events.Publisher) *PetHandler {
 	return &PetHandler{
 		petStore:   ps,
 		breedStore: bs,
+		events:     pub,
+		now:        time.Now,
 	}
 }
 
 func (ph *PetHandler) getPetsHandler(w http.ResponseWriter, r *http.Request) {
-	pets, err := ph.petStore.GetPets()
+	pets, err := ph.petStore.GetPets(r.Context())
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		writeStoreError(w, err, "Pet not found", "Internal Server Error")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 
-	err = json.NewEncoder(w).Encode(pets)
+	now := ph.now()
+	responses := make([]types.PetResponse, len(pets))
+	for i, p := range pets {
+		responses[i] = types.NewPetResponse(p, now)
+	}
+	err = json.NewEncoder(w).Encode(responses)
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
@@ -41,21 +55,20 @@ func (ph *PetHandler) getPetsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ph *PetHandler) GetPetByIDHandler(w http.ResponseWriter, r *http.Request) {
-	id := path.Base(r.URL.Path)
-	pet, err := ph.petStore.GetPetByID(id)
+	id, ok := petIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	pet, err := ph.petStore.GetPetByID(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			http.Error(w, "Pet not found", http.StatusNotFound)
-			return
-		} else {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
-		}
+		writeStoreError(w, err, "Pet not found", "Internal Server Error")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", petETag(pet))
 
-	err = json.NewEncoder(w).Encode(pet)
+	err = json.NewEncoder(w).Encode(types.NewPetResponse(*pet, ph.now()))
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
@@ -72,9 +85,8 @@ func (ph *PetHandler) createPetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = ph.breedStore.GetBreedByID(requestBody.BreedID)
-	if err != nil {
-		http.Error(w, "Error at checking the breed", http.StatusBadRequest)
+	breedID, ok := ph.checkBreed(w, r, requestBody.BreedID)
+	if !ok {
 		return
 	}
 
@@ -84,17 +96,18 @@ func (ph *PetHandler) createPetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newPet, err := ph.petStore.CreatePet(requestBody.Name, birth, requestBody.BreedID)
+	newPet, err := ph.petStore.CreatePet(r.Context(), requestBody.Name, birth, breedID)
 	if err != nil {
-		log.Printf("Error creating pet in store: %v", err)
-		http.Error(w, "Error creating pet", http.StatusInternalServerError)
+		writeStoreError(w, err, "Breed not found", "Error creating pet")
 		return
 	}
+	publishPetEvent(ph.events, r, events.PetCreated, newPet)
 
 	// 5. Enviar la respuesta exitosa.
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", petETag(newPet))
 	w.WriteHeader(http.StatusCreated) // El código 201 es estándar para 'Created'.
-	if err := json.NewEncoder(w).Encode(newPet); err != nil {
+	if err := json.NewEncoder(w).Encode(types.NewPetResponse(*newPet, ph.now())); err != nil {
 		log.Printf("Error encoding response for created pet: %v", err)
 	}
 }
@@ -111,3 +124,157 @@ func (ph *PetHandler) PetsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+// PetByIDHandler despacha las solicitudes a /api/v1/pets/{id} según el método.
+func (ph *PetHandler) PetByIDHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ph.GetPetByIDHandler(w, r)
+
+	case http.MethodPut:
+		ph.updatePetHandler(w, r)
+
+	case http.MethodDelete:
+		ph.deletePetHandler(w, r)
+
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (ph *PetHandler) updatePetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	id, ok := petIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody types.UpdatePetRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+
+	breedID, ok := ph.checkBreed(w, r, requestBody.BreedID)
+	if !ok {
+		return
+	}
+
+	birth, err := time.Parse("2006-01-02", requestBody.Birth)
+	if err != nil {
+		http.Error(w, "Bad date of birth format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	pet, err := ph.petStore.UpdatePet(r.Context(), id, version, requestBody.Name, birth, breedID)
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Error updating pet")
+		return
+	}
+	publishPetEvent(ph.events, r, events.PetUpdated, pet)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", petETag(pet))
+	if err := json.NewEncoder(w).Encode(types.NewPetResponse(*pet, ph.now())); err != nil {
+		log.Printf("Error encoding response for updated pet: %v", err)
+	}
+}
+
+func (ph *PetHandler) deletePetHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := petIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	pet, err := ph.petStore.DeletePet(r.Context(), id, version)
+	if err != nil {
+		writeStoreError(w, err, "Pet not found", "Error deleting pet")
+		return
+	}
+	publishPetEvent(ph.events, r, events.PetDeleted, pet)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishPetEvent publica en pub un cambio ya confirmado de pet: la mascota después del
+// cambio o, en los borrados, antes de borrarla. De una mascota borrada solo se envían el ID
+// y el dueño.
+func publishPetEvent(pub events.Publisher, r *http.Request, typ string, pet *types.Pet) {
+	var payload any
+	if typ != events.PetDeleted {
+		payload = pet
+	}
+	e, err := events.New(r.Context(), typ, pet.ID.String(), payload)
+	if err != nil {
+		log.Printf("Error al crear el evento %s de la mascota %s: %v", typ, pet.ID, err)
+		return
+	}
+	e.Owner = pet.Owner
+	pub.Publish(r.Context(), e)
+}
+
+// petIDFromPath extrae el ID de la mascota de la URL. Si no es un UUID válido responde 400
+// sin consultar la base de datos y devuelve ok=false.
+func petIDFromPath(w http.ResponseWriter, r *http.Request) (types.PetID, bool) {
+	id, err := types.ParsePetID(path.Base(r.URL.Path))
+	if err != nil {
+		http.Error(w, "Invalid pet ID. It must be a UUID", http.StatusBadRequest)
+		return "", false
+	}
+	return id, true
+}
+
+// checkBreed valida la raza indicada en el cuerpo de la solicitud: tanto un slug mal formado
+// como una raza inexistente se responden con 400. Devuelve ok=false si ya respondió.
+func (ph *PetHandler) checkBreed(w http.ResponseWriter, r *http.Request, raw string) (types.BreedID, bool) {
+	breedID, err := types.ParseBreedID(raw)
+	if err != nil {
+		http.Error(w, "Error at checking the breed", http.StatusBadRequest)
+		return "", false
+	}
+	if _, err := ph.breedStore.GetBreedByID(r.Context(), breedID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Error at checking the breed", http.StatusBadRequest)
+		} else {
+			writeStoreError(w, err, "Breed not found", "Error at checking the breed")
+		}
+		return "", false
+	}
+	return breedID, true
+}
+
+// petETag construye el ETag fuerte de una mascota a partir de su versión.
+func petETag(p *types.Pet) string {
+	return `"` + strconv.FormatInt(p.Version, 10) + `"`
+}
+
+// ifMatchVersion exige el encabezado If-Match y devuelve la versión que contiene.
+// "*" equivale a store.AnyVersion. Si falta o es inválido responde y devuelve ok=false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return 0, false
+	}
+	if ifMatch == "*" {
+		return store.AnyVersion, true
+	}
+
+	// Solo se admite un ETag fuerte; un ETag débil nunca coincide en If-Match (RFC 9110).
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		http.Error(w, "Pet was modified by another request", http.StatusPreconditionFailed)
+		return 0, false
+	}
+	return version, true
+}
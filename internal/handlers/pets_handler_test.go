@@ -2,106 +2,30 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
-	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/events"
 	"github.com/agugliotta/dog-app-bff/internal/store"
 	"github.com/agugliotta/dog-app-bff/internal/types"
 )
 
-type PetStoreMock struct {
-	pets   []types.Pet
-	breeds []types.Breed
-	fail   bool
-}
-
-func (m *PetStoreMock) GetPets() ([]types.Pet, error) {
-	if m.fail {
-		return nil, errors.New("store error")
-	}
-	return m.pets, nil
-}
-
-func (m *PetStoreMock) GetPetByID(id string) (*types.Pet, error) {
-	if m.fail {
-		return nil, errors.New("store error")
-	}
-	for _, p := range m.pets {
-		if p.ID == id {
-			return &p, nil
-		}
-	}
-	return nil, store.ErrNotFound
-}
-
-func (m *PetStoreMock) CreatePet(name string, birth time.Time, breedID string) (*types.Pet, error) {
-	if m.fail {
-		return nil, errors.New("store error")
-	}
-	var breed types.Breed
-	found := false
-	for _, b := range m.breeds {
-		if b.ID == breedID {
-			breed = b
-			found = true
-			break
-		}
-	}
-	if !found {
-		return nil, store.ErrNotFound
-	}
-	pet := types.Pet{
-		ID:    "new-pet-id",
-		Name:  name,
-		Birth: birth,
-		Breed: breed,
-	}
-	m.pets = append(m.pets, pet)
-	return &pet, nil
-}
-
-func (m *PetStoreMock) DeletePet(id string) error {
-	index := -1
-	for i, p := range m.pets {
-		if p.ID == id {
-			index = i
-		}
-	}
-	if index == -1 {
-		return store.ErrNotFound
-	}
-	m.pets = slices.Delete(m.pets, index, index+1)
-	return nil
-}
-
-type BreedStoreMock struct {
-	breeds []types.Breed
-}
-
-func (m *BreedStoreMock) GetBreedByID(id string) (*types.Breed, error) {
-	for _, b := range m.breeds {
-		if b.ID == id {
-			return &b, nil
-		}
-	}
-	return nil, store.ErrNotFound
-}
-
-func (m *BreedStoreMock) GetBreeds() ([]types.Breed, error) {
-	return m.breeds, nil
-}
+const (
+	petID1 = "0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41"
+	petID2 = "7d3f1e2a-8b4c-4d5e-9f6a-0b1c2d3e4f5a"
+)
 
 func TestGetPetsHandler(t *testing.T) {
 	breeds := []types.Breed{{ID: "b1", Name: "Breed1", Temperament: "T1", Origin: "O1"}}
-	pets := []types.Pet{{ID: "p1", Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}}
-	petStore := &PetStoreMock{pets: pets, breeds: breeds}
-	breedStore := &BreedStoreMock{breeds: breeds}
-	handler := NewPetHandler(petStore, breedStore)
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}}
+	s := store.NewMemoryStore(breeds, pets...)
+	handler := NewPetHandler(s, s, events.NewBus())
 
 	req, _ := http.NewRequest("GET", "/api/v1/pets", nil)
 	rec := httptest.NewRecorder()
@@ -117,20 +41,19 @@ func TestGetPetsHandler(t *testing.T) {
 	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
 		t.Errorf("error decoding response: %v", err)
 	}
-	if len(got) != 1 || got[0].ID != "p1" {
+	if len(got) != 1 || got[0].ID != petID1 {
 		t.Errorf("unexpected pets: %+v", got)
 	}
 }
 
 func TestGetPetByIDHandler(t *testing.T) {
 	breeds := []types.Breed{{ID: "b1", Name: "Breed1", Temperament: "T1", Origin: "O1"}}
-	pets := []types.Pet{{ID: "p1", Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}}
-	petStore := &PetStoreMock{pets: pets, breeds: breeds}
-	breedStore := &BreedStoreMock{breeds: breeds}
-	handler := NewPetHandler(petStore, breedStore)
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0], Version: 1}}
+	s := store.NewMemoryStore(breeds, pets...)
+	handler := NewPetHandler(s, s, events.NewBus())
 
 	t.Run("found", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/api/v1/pets/p1", nil)
+		req, _ := http.NewRequest("GET", "/api/v1/pets/"+petID1, nil)
 		rec := httptest.NewRecorder()
 		handler.GetPetByIDHandler(rec, req)
 		if rec.Code != http.StatusOK {
@@ -140,13 +63,30 @@ func TestGetPetByIDHandler(t *testing.T) {
 		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
 			t.Errorf("error decoding: %v", err)
 		}
-		if got.ID != "p1" {
+		if got.ID != petID1 {
 			t.Errorf("unexpected pet: %+v", got)
 		}
+		if rec.Header().Get("ETag") != `"1"` {
+			t.Errorf("expected ETag \"1\", got %q", rec.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("includes the computed age", func(t *testing.T) {
+		handler.now = func() time.Time { return time.Date(2023, 7, 15, 0, 0, 0, 0, time.UTC) }
+		req, _ := http.NewRequest("GET", "/api/v1/pets/"+petID1, nil)
+		rec := httptest.NewRecorder()
+		handler.GetPetByIDHandler(rec, req)
+		var got types.PetResponse
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("error decoding: %v", err)
+		}
+		if got.Age != (types.Age{Years: 3, Months: 6}) || got.HumanYears != 31 || got.LifeStage != types.StageAdult {
+			t.Errorf("unexpected computed age: %+v %d %s", got.Age, got.HumanYears, got.LifeStage)
+		}
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		req, _ := http.NewRequest("GET", "/api/v1/pets/doesnotexist", nil)
+		req, _ := http.NewRequest("GET", "/api/v1/pets/"+petID2, nil)
 		rec := httptest.NewRecorder()
 		handler.GetPetByIDHandler(rec, req)
 		if rec.Code != http.StatusNotFound {
@@ -156,13 +96,31 @@ func TestGetPetByIDHandler(t *testing.T) {
 			t.Errorf("unexpected body: %q", rec.Body.String())
 		}
 	})
+
+	t.Run("malformed ID is rejected before the store", func(t *testing.T) {
+		handler := NewPetHandler(&unavailablePetStore{}, s, events.NewBus())
+		req, _ := http.NewRequest("GET", "/api/v1/pets/not-a-uuid", nil)
+		rec := httptest.NewRecorder()
+		handler.GetPetByIDHandler(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("upper case UUID is normalized", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/pets/"+strings.ToUpper(petID1), nil)
+		rec := httptest.NewRecorder()
+		handler.GetPetByIDHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", rec.Code)
+		}
+	})
 }
 
 func TestCreatePetHandler(t *testing.T) {
 	breeds := []types.Breed{{ID: "b1", Name: "Breed1", Temperament: "T1", Origin: "O1"}}
-	petStore := &PetStoreMock{breeds: breeds}
-	breedStore := &BreedStoreMock{breeds: breeds}
-	handler := NewPetHandler(petStore, breedStore)
+	s := store.NewMemoryStore(breeds)
+	handler := NewPetHandler(s, s, events.NewBus())
 
 	t.Run("success", func(t *testing.T) {
 		reqBody := types.CreatePetRequest{
@@ -204,6 +162,16 @@ func TestCreatePetHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("malformed breed", func(t *testing.T) {
+		body, _ := json.Marshal(types.CreatePetRequest{Name: "Fido", Birth: "2020-01-01", BreedID: "Not A Slug"})
+		req, _ := http.NewRequest("POST", "/api/v1/pets", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.PetsHandler(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", rec.Code)
+		}
+	})
+
 	t.Run("bad date", func(t *testing.T) {
 		reqBody := types.CreatePetRequest{
 			Name:    "Fido",
@@ -234,3 +202,166 @@ func TestCreatePetHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdatePetHandler(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1"}, {ID: "b2", Name: "Breed2"}}
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0], Version: 1}}
+	s := store.NewMemoryStore(breeds, pets...)
+	handler := NewPetHandler(s, s, events.NewBus())
+
+	update := func(ifMatch string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(types.UpdatePetRequest{Name: "Rex", Birth: "2021-03-04", BreedID: "b2"})
+		req, _ := http.NewRequest("PUT", "/api/v1/pets/"+petID1, bytes.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.PetByIDHandler(rec, req)
+		return rec
+	}
+
+	t.Run("missing If-Match", func(t *testing.T) {
+		if rec := update(""); rec.Code != http.StatusPreconditionRequired {
+			t.Errorf("expected 428, got %d", rec.Code)
+		}
+	})
+
+	t.Run("current version", func(t *testing.T) {
+		rec := update(`"1"`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("ETag") != `"2"` {
+			t.Errorf("expected new ETag \"2\", got %q", rec.Header().Get("ETag"))
+		}
+		var got types.Pet
+		json.NewDecoder(rec.Body).Decode(&got)
+		if got.Name != "Rex" || got.Breed.ID != "b2" {
+			t.Errorf("unexpected pet: %+v", got)
+		}
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		if rec := update(`"1"`); rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("expected 412, got %d", rec.Code)
+		}
+	})
+
+	t.Run("weak ETag never matches", func(t *testing.T) {
+		if rec := update(`W/"2"`); rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("expected 412, got %d", rec.Code)
+		}
+	})
+}
+
+func TestDeletePetHandler(t *testing.T) {
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Version: 3}}
+	s := store.NewMemoryStore(nil, pets...)
+	handler := NewPetHandler(s, s, events.NewBus())
+
+	del := func(id, ifMatch string) int {
+		req, _ := http.NewRequest("DELETE", "/api/v1/pets/"+id, nil)
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		handler.PetByIDHandler(rec, req)
+		return rec.Code
+	}
+
+	if code := del(petID1, `"2"`); code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412, got %d", code)
+	}
+	if code := del(petID1, `"3"`); code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", code)
+	}
+	if code := del(petID1, "*"); code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", code)
+	}
+	if code := del("p1", "*"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed ID, got %d", code)
+	}
+}
+
+func TestPetHandlerPublishesEvents(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1"}}
+	s := store.NewMemoryStore(breeds)
+	bus := events.NewBus()
+	var got []events.Event
+	bus.Subscribe(func(ctx context.Context, e events.Event) { got = append(got, e) })
+	handler := NewPetHandler(s, s, bus)
+
+	// alice crea la mascota y bob la borra: los dos eventos son de la mascota de alice.
+	as := func(req *http.Request, subject string) *http.Request {
+		return req.WithContext(audit.WithMeta(req.Context(), audit.Meta{Actor: subject, Subject: subject}))
+	}
+	body, _ := json.Marshal(types.CreatePetRequest{Name: "Fido", Birth: "2020-01-01", BreedID: "b1"})
+	req, _ := http.NewRequest("POST", "/api/v1/pets", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.PetsHandler(rec, as(req, "alice"))
+	var pet types.Pet
+	json.NewDecoder(rec.Body).Decode(&pet)
+	if pet.Owner != "alice" {
+		t.Errorf("expected alice as the owner, got %q", pet.Owner)
+	}
+
+	// Un cambio rechazado no publica nada.
+	req, _ = http.NewRequest("DELETE", "/api/v1/pets/"+pet.ID.String(), nil)
+	req.Header.Set("If-Match", `"99"`)
+	handler.PetByIDHandler(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/pets/"+pet.ID.String(), nil)
+	req.Header.Set("If-Match", "*")
+	handler.PetByIDHandler(httptest.NewRecorder(), as(req, "bob"))
+
+	if len(got) != 2 || got[0].Type != events.PetCreated || got[1].Type != events.PetDeleted {
+		t.Fatalf("expected pet.created and pet.deleted, got %+v", got)
+	}
+	var data types.Pet
+	if err := json.Unmarshal(got[0].Data, &data); err != nil || data.ID != pet.ID || data.Name != "Fido" {
+		t.Errorf("unexpected data of pet.created %s (%v)", got[0].Data, err)
+	}
+	if got[1].EntityID != pet.ID.String() || got[1].Data != nil {
+		t.Errorf("unexpected pet.deleted %+v", got[1])
+	}
+	if got[0].Owner != "alice" || got[1].Owner != "alice" {
+		t.Errorf("expected both events owned by alice, got %q and %q", got[0].Owner, got[1].Owner)
+	}
+}
+
+func TestPetRoutesMethodNotAllowed(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1"}}
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}}
+	s := store.NewMemoryStore(breeds, pets...)
+	bus := events.NewBus()
+	router := http.NewServeMux()
+	RegisterRoutes(router, s, s, bus)
+	RegisterMedicalRoutes(router, s)
+	RegisterBulkRoutes(router, s, s, s, bus)
+	RegisterStreamRoutes(router, events.NewLog(10), time.Minute)
+
+	tests := []struct {
+		method, target string
+		code           int
+		allow          string
+	}{
+		{"GET", "/api/v1/pets/" + petID1, http.StatusOK, ""},
+		{"PATCH", "/api/v1/pets/" + petID1, http.StatusMethodNotAllowed, "GET"},
+		{"PUT", "/api/v1/pets/" + petID1 + "/medical", http.StatusMethodNotAllowed, "GET"},
+		{"DELETE", "/api/v1/pets/bulk", http.StatusMethodNotAllowed, "POST"},
+		{"GET", "/api/v1/pets/bulk", http.StatusMethodNotAllowed, "POST"},
+		{"PUT", "/api/v1/pets/events", http.StatusMethodNotAllowed, "GET"},
+		{"DELETE", "/api/v1/pets", http.StatusMethodNotAllowed, "GET"},
+		{"GET", "/api/v1/pets/" + petID1 + "/unknown", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+			if allow := rec.Header().Get("Allow"); tt.allow != "" && !strings.Contains(allow, tt.allow) {
+				t.Errorf("expected Allow to contain %s, got %q", tt.allow, allow)
+			}
+		})
+	}
+}
@@ -2,16 +2,19 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/agugliotta/dog-app-bff/internal/events"
 	"github.com/agugliotta/dog-app-bff/internal/store"
 )
 
 // RegisterRoutes es la función principal para registrar todos los handlers con el router HTTP.
-// Recibe el http.ServeMux estándar y el store de la aplicación.
-func RegisterRoutes(router *http.ServeMux, bs store.BreedStore, ps store.PetStore) {
+// Recibe el http.ServeMux estándar, el store de la aplicación y el bus donde se publican
+// los cambios.
+func RegisterRoutes(router *http.ServeMux, bs store.BreedStore, ps store.PetStore, pub events.Publisher) {
 	// Crea una instancia del handler de razas, inyectando el store.
 	breedHandler := NewBreedHandler(bs)
-	petHandler := NewPetHandler(ps, bs)
+	petHandler := NewPetHandler(ps, bs, pub)
 
 	// Registra el handler para la ruta /api/v1/breeds.
 	// Como usamos http.ServeMux, no especificamos métodos aquí, se hará dentro del handler si es necesario.
@@ -23,6 +26,107 @@ func RegisterRoutes(router *http.ServeMux, bs store.BreedStore, ps store.PetStor
 	// Si es "/api/v1/breeds/algo", GetBreedByIDHandler la maneja.
 	router.HandleFunc("/api/v1/breeds/", breedHandler.GetBreedByIDHandler)
 
-	router.HandleFunc("/api/v1/pets/", petHandler.GetPetByIDHandler)
-	router.HandleFunc("/api/v1/pets", petHandler.PetsHandler)
+	// Las rutas de mascotas llevan el método para que ServeMux responda 405 con Allow a los
+	// métodos que no existen, también en las rutas anidadas bajo /api/v1/pets/{id}.
+	router.HandleFunc("GET /api/v1/pets", petHandler.getPetsHandler)
+	router.HandleFunc("POST /api/v1/pets", petHandler.createPetHandler)
+	router.HandleFunc("GET /api/v1/pets/{id}", petHandler.GetPetByIDHandler)
+	router.HandleFunc("PUT /api/v1/pets/{id}", petHandler.updatePetHandler)
+	router.HandleFunc("DELETE /api/v1/pets/{id}", petHandler.deletePetHandler)
+
+	// Las rutas fijas de un segmento bajo /api/v1/pets/ (ver RegisterBulkRoutes y
+	// RegisterStreamRoutes) también coinciden con /api/v1/pets/{id}. Se reservan con los
+	// métodos de {id} que no admiten para que respondan 405 en lugar de leer "bulk" o
+	// "events" como un ID.
+	for path, allowed := range map[string]string{
+		"/api/v1/pets/bulk":   http.MethodPost,
+		"/api/v1/pets/events": http.MethodGet,
+	} {
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+			if method != allowed {
+				router.Handle(method+" "+path, methodNotAllowed(allowed))
+			}
+		}
+	}
+}
+
+// methodNotAllowed responde 405 indicando en Allow el método que admite la ruta.
+func methodNotAllowed(allowed string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allowed)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	})
+}
+
+// RegisterStreamRoutes registra el stream de eventos de las mascotas. l debe recibir los
+// eventos publicados (ver events.Log). Cada cliente recibe solo los cambios de las mascotas
+// de su sujeto (ver middleware.SubjectFromContext), así que el llamador debe exigir uno.
+func RegisterStreamRoutes(router *http.ServeMux, l *events.Log, heartbeat time.Duration) {
+	streamHandler := NewStreamHandler(l, heartbeat)
+
+	router.HandleFunc("GET /api/v1/pets/events", streamHandler.PetEventsHandler)
+}
+
+// RegisterBulkRoutes registra las operaciones en lote sobre las mascotas.
+func RegisterBulkRoutes(router *http.ServeMux, ps store.PetStore, bs store.BreedStore, tr store.Transactor, pub events.Publisher) {
+	bulkHandler := NewBulkHandler(ps, bs, tr, pub)
+
+	router.HandleFunc("POST /api/v1/pets/bulk", bulkHandler.CreatePetsHandler)
+	router.HandleFunc("POST /api/v1/pets/bulk/delete", bulkHandler.DeletePetsHandler)
+}
+
+// RegisterTransferRoutes registra la exportación de mascotas y razas y la importación de mascotas.
+func RegisterTransferRoutes(router *http.ServeMux, ps store.PetStore, bs store.BreedStore, tr store.Transactor, pub events.Publisher) {
+	transferHandler := NewTransferHandler(ps, bs, tr, pub)
+
+	router.HandleFunc("GET /api/v1/export/pets", transferHandler.ExportPetsHandler)
+	router.HandleFunc("GET /api/v1/export/breeds", transferHandler.ExportBreedsHandler)
+	router.HandleFunc("POST /api/v1/import/pets", transferHandler.ImportPetsHandler)
+}
+
+// RegisterSyncRoutes registra la sincronización de mascotas para clientes sin conexión.
+func RegisterSyncRoutes(router *http.ServeMux, ss store.SyncStore, ps store.PetStore, bs store.BreedStore, pub events.Publisher) {
+	syncHandler := NewSyncHandler(ss, ps, bs, pub)
+
+	router.HandleFunc("GET /api/v1/sync", syncHandler.GetChangesHandler)
+	router.HandleFunc("POST /api/v1/sync", syncHandler.PushChangesHandler)
+}
+
+// RegisterAdminRoutes registra las rutas de administración. El llamador es responsable
+// de proteger el router con la autorización adecuada.
+func RegisterAdminRoutes(router *http.ServeMux, as store.AuditStore) {
+	auditHandler := NewAuditHandler(as)
+
+	router.HandleFunc("GET /api/v1/admin/audit/{entityType}/{entityID}", auditHandler.GetHistoryHandler)
+}
+
+// RegisterWebhookRoutes registra las rutas de administración de los webhooks. Como
+// RegisterAdminRoutes, el llamador es responsable de proteger el router. deliver indica si
+// el planificador que reparte los eventos está en marcha; sin él se rechazan las altas.
+func RegisterWebhookRoutes(router *http.ServeMux, ws store.WebhookStore, deliver bool) {
+	webhookHandler := NewWebhookHandler(ws, deliver)
+
+	router.HandleFunc("GET /api/v1/admin/webhooks", webhookHandler.GetWebhooksHandler)
+	router.HandleFunc("POST /api/v1/admin/webhooks", webhookHandler.CreateWebhookHandler)
+	router.HandleFunc("DELETE /api/v1/admin/webhooks/{id}", webhookHandler.DeleteWebhookHandler)
+	router.HandleFunc("GET /api/v1/admin/webhooks/{id}/deliveries", webhookHandler.GetDeliveriesHandler)
+}
+
+// RegisterMedicalRoutes registra las rutas del historial médico, anidadas bajo cada mascota.
+func RegisterMedicalRoutes(router *http.ServeMux, ms store.MedicalStore) {
+	medicalHandler := NewMedicalHandler(ms)
+
+	router.HandleFunc("GET /api/v1/pets/{id}/medical", medicalHandler.GetHistoryHandler)
+	router.HandleFunc("POST /api/v1/pets/{id}/medical/visits", medicalHandler.AddVisitHandler)
+	router.HandleFunc("POST /api/v1/pets/{id}/medical/conditions", medicalHandler.AddConditionHandler)
+}
+
+// RegisterMedicationRoutes registra las rutas de las pautas de medicación y su calendario de tomas.
+func RegisterMedicationRoutes(router *http.ServeMux, ms store.MedicationStore) {
+	medicationHandler := NewMedicationHandler(ms)
+
+	router.HandleFunc("GET /api/v1/pets/{id}/medications", medicationHandler.GetMedicationsHandler)
+	router.HandleFunc("POST /api/v1/pets/{id}/medications", medicationHandler.AddMedicationHandler)
+	router.HandleFunc("GET /api/v1/pets/{id}/medications/doses", medicationHandler.GetDosesHandler)
+	router.HandleFunc("POST /api/v1/pets/{id}/medications/{medicationId}/doses", medicationHandler.RecordDoseHandler)
 }
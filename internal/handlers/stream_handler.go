@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/middleware"
+)
+
+const (
+	// streamBuffer es cuántos eventos puede tener pendientes un cliente lento antes de
+	// que se le corte la conexión (y se reconecte con Last-Event-ID).
+	streamBuffer = 64
+	// streamRetry es la espera que se pide a EventSource antes de reconectar.
+	streamRetry = 3 * time.Second
+	// resetEvent avisa al cliente de que se perdió eventos y debe releer las mascotas.
+	resetEvent = "reset"
+)
+
+// StreamHandler emite los cambios de las mascotas como Server-Sent Events.
+type StreamHandler struct {
+	log *events.Log
+	// heartbeat es cada cuánto se envía un comentario para que los proxies no cierren la
+	// conexión por inactividad.
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(l *events.Log, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		log:       l,
+		heartbeat: heartbeat,
+	}
+}
+
+// PetEventsHandler mantiene abierta la respuesta y escribe cada evento de las mascotas del
+// sujeto autenticado con su ID. Al reconectar, el cliente manda el último ID recibido en
+// Last-Event-ID (o en el parámetro lastEventId) y recibe los eventos posteriores que sigan
+// en el registro; si ya no están, recibe un evento "reset". Solo lo pueden abrir los
+// sujetos autenticados (ver RegisterStreamRoutes).
+// Ruta: GET /api/v1/pets/events
+func (sh *StreamHandler) PetEventsHandler(w http.ResponseWriter, r *http.Request) {
+	subject := middleware.SubjectFromContext(r.Context())
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+
+	rc := http.NewResponseController(w)
+	backlog, sub, ok := sh.log.Subscribe(lastID, streamBuffer)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Evita que nginx acumule la respuesta antes de reenviarla.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if !ok {
+		// "id:" vacío borra el Last-Event-ID del cliente: se reanuda desde aquí.
+		io.WriteString(w, "id:\nevent: "+resetEvent+"\ndata: {}\n\n")
+	}
+	for _, e := range backlog {
+		if !ownedBy(e, subject) {
+			continue
+		}
+		if err := writePetEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		if errors.Is(err, http.ErrNotSupported) {
+			log.Printf("El ResponseWriter no admite Flush: no se puede emitir el stream de eventos")
+		}
+		return
+	}
+
+	heartbeat := time.NewTicker(sh.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, open := <-sub.C:
+			if !open {
+				// El cliente no leía al ritmo de los eventos.
+				return
+			}
+			if !ownedBy(e, subject) {
+				continue
+			}
+			if err := writePetEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// ownedBy indica si e es de una mascota de subject. Los eventos de las mascotas sin dueño
+// (ver types.Pet.Owner) no se envían a nadie.
+func ownedBy(e events.Event, subject string) bool {
+	return subject != "" && e.Owner == subject
+}
+
+// streamEvent es el data de un evento del stream. A diferencia de events.Event, no lleva el
+// actor (el sujeto o la IP de quien hizo el cambio) ni el ID de la solicitud: el stream lo
+// recibe el dueño de la mascota, que puede no ser quien la cambió.
+type streamEvent struct {
+	Type  string `json:"type"`
+	PetID string `json:"petId"`
+	// Version es la versión de la mascota después del cambio; no se envía en los borrados.
+	Version int64           `json:"version,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// writePetEvent escribe e en formato SSE si es de una mascota.
+func writePetEvent(w io.Writer, e events.Event) error {
+	if e.EntityType() != audit.EntityPet {
+		return nil
+	}
+	se := streamEvent{Type: e.Type, PetID: e.EntityID, Payload: e.Data}
+	if len(e.Data) > 0 {
+		var pet struct {
+			Version int64 `json:"version"`
+		}
+		if err := json.Unmarshal(e.Data, &pet); err != nil {
+			log.Printf("Error al leer la versión del evento %s: %v", e.ID, err)
+			return nil
+		}
+		se.Version = pet.Version
+	}
+	data, err := json.Marshal(se)
+	if err != nil {
+		log.Printf("Error al codificar el evento %s a JSON: %v", e.ID, err)
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/middleware"
+)
+
+// openStream conecta con el stream y devuelve un lector de sus bloques ("id: ...\nevent: ...").
+func openStream(t *testing.T, url, lastEventID string) func() string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(resp.Body)
+		var block []string
+		for sc.Scan() {
+			if sc.Text() != "" {
+				block = append(block, sc.Text())
+				continue
+			}
+			lines <- strings.Join(block, "|")
+			block = nil
+		}
+	}()
+	return func() string {
+		t.Helper()
+		select {
+		case b := <-lines:
+			// Solo se compara hasta el evento; data se comprueba aparte.
+			b, _, _ = strings.Cut(b, "|data: {\"type\"")
+			return b
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the stream")
+			return ""
+		}
+	}
+}
+
+func TestPetEventsHandler(t *testing.T) {
+	ctx := context.Background()
+	l := events.NewLog(3)
+	router := http.NewServeMux()
+	RegisterStreamRoutes(router, l, 50*time.Millisecond)
+	// El cliente es alice: los eventos de las mascotas de bob no le llegan.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r.WithContext(middleware.WithSubject(r.Context(), "alice")))
+	}))
+	defer srv.Close()
+	url := srv.URL + "/api/v1/pets/events"
+
+	l.Publish(ctx, events.Event{ID: "e1", Type: events.PetCreated, Owner: "alice"})
+	l.Publish(ctx, events.Event{ID: "e2", Type: events.PetUpdated, Owner: "alice"})
+
+	t.Run("live events and heartbeats", func(t *testing.T) {
+		next := openStream(t, url, "")
+		if b := next(); b != "retry: 3000" {
+			t.Errorf("expected the retry interval, got %q", b)
+		}
+		if b := next(); b != ": heartbeat" {
+			t.Errorf("expected a heartbeat, got %q", b)
+		}
+		l.Publish(ctx, events.Event{ID: "bob", Type: events.PetCreated, EntityID: "p2", Owner: "bob"})
+		l.Publish(ctx, events.Event{ID: "e3", Type: events.PetDeleted, EntityID: "p1", Owner: "alice"})
+		for b := next(); b != "id: e3|event: pet.deleted"; b = next() {
+			if b != ": heartbeat" {
+				t.Fatalf("expected e3, got %q", b)
+			}
+		}
+	})
+
+	t.Run("resume with Last-Event-ID", func(t *testing.T) {
+		next := openStream(t, url, "e2")
+		next()
+		if b := next(); b != "id: e3|event: pet.deleted" {
+			t.Errorf("expected to resume with e3 skipping the event of bob, got %q", b)
+		}
+	})
+
+	t.Run("reset when the last ID left the log", func(t *testing.T) {
+		next := openStream(t, url, "e1")
+		next()
+		if b := next(); b != "id:|event: reset|data: {}" {
+			t.Errorf("expected a reset, got %q", b)
+		}
+	})
+}
+
+func TestWritePetEvent(t *testing.T) {
+	for _, tt := range []struct {
+		e    events.Event
+		want string
+	}{
+		{
+			events.Event{ID: "e1", Type: events.PetCreated, EntityID: "p1", Actor: "ip:203.0.113.7", RequestID: "r1", Data: []byte(`{"id":"p1","version":1}`)},
+			"id: e1\nevent: pet.created\ndata: {\"type\":\"pet.created\",\"petId\":\"p1\",\"version\":1,\"payload\":{\"id\":\"p1\",\"version\":1}}\n\n",
+		},
+		{
+			events.Event{ID: "e2", Type: events.PetDeleted, EntityID: "p1", Actor: "alice"},
+			"id: e2\nevent: pet.deleted\ndata: {\"type\":\"pet.deleted\",\"petId\":\"p1\"}\n\n",
+		},
+		{events.Event{ID: "e3", Type: "breed.updated", EntityID: "poodle"}, ""},
+	} {
+		var b strings.Builder
+		if err := writePetEvent(&b, tt.e); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("event %s: expected %q, got %q", tt.e.ID, tt.want, b.String())
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+const (
+	// defaultSyncChanges y maxSyncChanges acotan los cambios que se leen por solicitud.
+	defaultSyncChanges = 500
+	maxSyncChanges     = 1000
+	// maxSyncMutations es el máximo de cambios del cliente por solicitud.
+	maxSyncMutations = 100
+)
+
+// errMissingBaseVersion es el error de un "update" o "delete" sin BaseVersion: sin ella, el
+// cambio pisaría en silencio lo que otro cliente hizo mientras este estaba sin conexión.
+const errMissingBaseVersion = "baseVersion is required for update and delete"
+
+// SyncHandler sincroniza las mascotas con clientes que trabajan sin conexión: les envía los
+// cambios desde su último token y aplica los que hicieron ellos.
+type SyncHandler struct {
+	syncStore  store.SyncStore
+	petStore   store.PetStore
+	breedStore store.BreedStore
+	// events recibe los cambios aplicados, igual que desde PetHandler.
+	events events.Publisher
+	// now es la fecha con la que se calcula la edad de las mascotas.
+	now func() time.Time
+}
+
+func NewSyncHandler(ss store.SyncStore, ps store.PetStore, bs store.BreedStore, pub events.Publisher) *SyncHandler {
+	return &SyncHandler{
+		syncStore:  ss,
+		petStore:   ps,
+		breedStore: bs,
+		events:     pub,
+		now:        time.Now,
+	}
+}
+
+// GetChangesHandler devuelve los cambios de las mascotas posteriores a "token". Sin token
+// devuelve todas las mascotas. Cada mascota aparece una sola vez, con su último estado; las
+// borradas aparecen como "delete". "limit" va de 1 a 1000 (por defecto, 500) y cuenta los
+// cambios leídos, no las mascotas devueltas.
+// Ruta: GET /api/v1/sync
+func (sh *SyncHandler) GetChangesHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultSyncChanges
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxSyncChanges {
+			http.Error(w, "Limit must be a number between 1 and "+strconv.Itoa(maxSyncChanges), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var resp *types.SyncResponse
+	var err error
+	if token := r.URL.Query().Get("token"); token == "" {
+		resp, err = sh.snapshot(r.Context())
+	} else {
+		since, perr := strconv.ParseInt(token, 10, 64)
+		if perr != nil || since < 0 {
+			http.Error(w, "Invalid sync token", http.StatusBadRequest)
+			return
+		}
+		resp, err = sh.changes(r.Context(), since, limit)
+	}
+	if err != nil {
+		writeStoreError(w, err, "Not found", "Error reading pet changes")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error al codificar los cambios a JSON: %v", err)
+	}
+}
+
+// snapshot devuelve todas las mascotas como "upsert" junto con el token desde el que seguir.
+func (sh *SyncHandler) snapshot(ctx context.Context) (*types.SyncResponse, error) {
+	pets, token, err := sh.syncStore.PetSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := sh.now()
+	resp := &types.SyncResponse{Changes: make([]types.SyncChange, 0, len(pets)), SyncToken: formatSyncToken(token)}
+	for _, p := range pets {
+		pr := types.NewPetResponse(p, now)
+		resp.Changes = append(resp.Changes, types.SyncChange{Op: types.SyncUpsert, ID: p.ID, Pet: &pr})
+	}
+	return resp, nil
+}
+
+// changes devuelve los cambios posteriores a since, reducidos al último estado de cada mascota.
+func (sh *SyncHandler) changes(ctx context.Context, since int64, limit int) (*types.SyncResponse, error) {
+	changes, err := sh.syncStore.PetChanges(ctx, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cada mascota queda en la posición de su último cambio, para que el orden siga siendo
+	// el de los cambios.
+	last := make(map[types.PetID]int, len(changes))
+	for i, c := range changes {
+		last[c.PetID] = i
+	}
+	now := sh.now()
+	resp := &types.SyncResponse{Changes: []types.SyncChange{}, SyncToken: formatSyncToken(since), HasMore: len(changes) == limit}
+	for i, c := range changes {
+		if last[c.PetID] != i {
+			continue
+		}
+		if c.Deleted {
+			resp.Changes = append(resp.Changes, types.SyncChange{Op: types.SyncDelete, ID: c.PetID})
+		} else {
+			pr := types.NewPetResponse(*c.Pet, now)
+			resp.Changes = append(resp.Changes, types.SyncChange{Op: types.SyncUpsert, ID: c.PetID, Pet: &pr})
+		}
+	}
+	if len(changes) > 0 {
+		resp.SyncToken = formatSyncToken(changes[len(changes)-1].Seq)
+	}
+	return resp, nil
+}
+
+// PushChangesHandler aplica los cambios hechos sin conexión, en orden y cada uno por
+// separado: que uno falle no impide aplicar los siguientes. Responde 200 con el resultado
+// de cada cambio. Las mascotas que cambiaron en el servidor desde BaseVersion se devuelven
+// como "conflict" con su estado actual, para que el cliente decida.
+// Ruta: POST /api/v1/sync
+func (sh *SyncHandler) PushChangesHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var requestBody types.SyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+	if len(requestBody.Mutations) > maxSyncMutations {
+		http.Error(w, "At most "+strconv.Itoa(maxSyncMutations)+" mutations are allowed per request", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	resp := types.SyncPushResponse{Results: make([]types.SyncResult, 0, len(requestBody.Mutations))}
+	for _, m := range requestBody.Mutations {
+		res := sh.apply(r, m)
+		res.ClientID = m.ClientID
+		resp.Results = append(resp.Results, res)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error al codificar los resultados de la sincronización a JSON: %v", err)
+	}
+}
+
+// apply aplica un cambio del cliente y publica el evento si se confirmó.
+func (sh *SyncHandler) apply(r *http.Request, m types.SyncMutation) types.SyncResult {
+	ctx := r.Context()
+	switch m.Op {
+	case types.SyncCreate:
+		birth, breedID, res, ok := sh.checkPetFields(ctx, m)
+		if !ok {
+			return res
+		}
+		pet, err := sh.petStore.CreatePet(ctx, m.Name, birth, breedID)
+		if err != nil {
+			return sh.failed(ctx, "", err)
+		}
+		publishPetEvent(sh.events, r, events.PetCreated, pet)
+		return sh.applied(pet)
+
+	case types.SyncUpdate:
+		id, err := types.ParsePetID(m.ID)
+		if err != nil {
+			return invalidMutation("Invalid pet ID. It must be a UUID")
+		}
+		if m.BaseVersion <= 0 {
+			return invalidMutation(errMissingBaseVersion)
+		}
+		birth, breedID, res, ok := sh.checkPetFields(ctx, m)
+		if !ok {
+			return res
+		}
+		pet, err := sh.petStore.UpdatePet(ctx, id, m.BaseVersion, m.Name, birth, breedID)
+		if err != nil {
+			return sh.failed(ctx, id, err)
+		}
+		publishPetEvent(sh.events, r, events.PetUpdated, pet)
+		return sh.applied(pet)
+
+	case types.SyncDelete:
+		id, err := types.ParsePetID(m.ID)
+		if err != nil {
+			return invalidMutation("Invalid pet ID. It must be a UUID")
+		}
+		if m.BaseVersion <= 0 {
+			return invalidMutation(errMissingBaseVersion)
+		}
+		pet, err := sh.petStore.DeletePet(ctx, id, m.BaseVersion)
+		if errors.Is(err, store.ErrNotFound) {
+			// Ya estaba borrada: el resultado es el que quería el cliente.
+			return types.SyncResult{Status: types.SyncApplied}
+		}
+		if err != nil {
+			return sh.failed(ctx, id, err)
+		}
+		publishPetEvent(sh.events, r, events.PetDeleted, pet)
+		return types.SyncResult{Status: types.SyncApplied}
+
+	default:
+		return invalidMutation(`Op must be "create", "update" or "delete"`)
+	}
+}
+
+// checkPetFields valida la fecha de nacimiento y la raza de un "create" o "update". Si no
+// son válidas devuelve ok=false y el resultado con el que responder.
+func (sh *SyncHandler) checkPetFields(ctx context.Context, m types.SyncMutation) (time.Time, types.BreedID, types.SyncResult, bool) {
+	birth, err := time.Parse("2006-01-02", m.Birth)
+	if err != nil {
+		return time.Time{}, "", invalidMutation("Bad date of birth format. Use YYYY-MM-DD"), false
+	}
+	breedID, err := types.ParseBreedID(m.BreedID)
+	if err != nil {
+		return time.Time{}, "", invalidMutation("Breed not found"), false
+	}
+	if _, err := sh.breedStore.GetBreedByID(ctx, breedID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return time.Time{}, "", invalidMutation("Breed not found"), false
+		}
+		return time.Time{}, "", sh.failed(ctx, "", err), false
+	}
+	return birth, breedID, types.SyncResult{}, true
+}
+
+func (sh *SyncHandler) applied(p *types.Pet) types.SyncResult {
+	pr := types.NewPetResponse(*p, sh.now())
+	return types.SyncResult{Status: types.SyncApplied, Pet: &pr}
+}
+
+// failed traduce el error del store de un cambio de la mascota id. En un conflicto de
+// versión incluye la mascota actual; si ya no existe, el conflicto va sin mascota.
+func (sh *SyncHandler) failed(ctx context.Context, id types.PetID, err error) types.SyncResult {
+	switch {
+	case errors.Is(err, store.ErrVersionConflict):
+		current, gerr := sh.petStore.GetPetByID(ctx, id)
+		if gerr != nil {
+			if !errors.Is(gerr, store.ErrNotFound) {
+				log.Printf("Error al leer la mascota %s en conflicto: %v", id, gerr)
+			}
+			return types.SyncResult{Status: types.SyncConflict}
+		}
+		res := sh.applied(current)
+		res.Status = types.SyncConflict
+		return res
+	case errors.Is(err, store.ErrNotFound):
+		// Se modificó una mascota que otro cliente borró.
+		return types.SyncResult{Status: types.SyncConflict}
+	case errors.Is(err, store.ErrInvalidInput), errors.Is(err, store.ErrForeignKeyViolation):
+		return invalidMutation("Invalid input")
+	default:
+		log.Printf("Error del store al sincronizar: %v", err)
+		return types.SyncResult{Status: types.SyncFailed, Error: "Error applying mutation"}
+	}
+}
+
+func invalidMutation(msg string) types.SyncResult {
+	return types.SyncResult{Status: types.SyncInvalid, Error: msg}
+}
+
+// formatSyncToken codifica el token que se entrega al cliente. Es la posición del último
+// cambio leído, pero para el cliente es opaco.
+func formatSyncToken(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
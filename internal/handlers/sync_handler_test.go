@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func TestSyncHandlers(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1"}, {ID: "b2", Name: "Breed2"}}
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0], Version: 1}}
+	s := store.NewMemoryStore(breeds, pets...)
+	bus := events.NewBus()
+	var published []string
+	bus.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e.Type) })
+	handler := NewSyncHandler(s, s, s, bus)
+
+	pull := func(t *testing.T, query string) (int, types.SyncResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.GetChangesHandler(rec, httptest.NewRequest("GET", "/api/v1/sync"+query, nil))
+		var got types.SyncResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("error decoding: %v", err)
+			}
+		}
+		return rec.Code, got
+	}
+	push := func(t *testing.T, body string) (int, types.SyncPushResponse) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.PushChangesHandler(rec, httptest.NewRequest("POST", "/api/v1/sync", strings.NewReader(body)))
+		var got types.SyncPushResponse
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("error decoding: %v", err)
+			}
+		}
+		return rec.Code, got
+	}
+
+	code, snap := pull(t, "")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(snap.Changes) != 1 || snap.Changes[0].Op != types.SyncUpsert || snap.Changes[0].Pet.Name != "Fido" || snap.SyncToken == "" {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+
+	var created types.PetID
+	t.Run("push applies mutations independently", func(t *testing.T) {
+		code, got := push(t, `{"mutations":[
+			{"clientId":"c1","op":"create","name":"Rex","birth":"2021-05-05","breedId":"b2"},
+			{"clientId":"c2","op":"update","id":"`+petID1+`","baseVersion":1,"name":"Fido II","birth":"2020-01-01","breedId":"b1"},
+			{"clientId":"c3","op":"update","id":"`+petID1+`","baseVersion":1,"name":"Stale","birth":"2020-01-01","breedId":"b1"},
+			{"clientId":"c4","op":"create","name":"Bad","birth":"yesterday","breedId":"b1"},
+			{"clientId":"c5","op":"create","name":"Bad","birth":"2020-01-01","breedId":"unknown"},
+			{"clientId":"c6","op":"delete","id":"`+petID2+`","baseVersion":1},
+			{"clientId":"c7","op":"update","id":"`+petID2+`","baseVersion":1,"name":"Gone","birth":"2020-01-01","breedId":"b1"},
+			{"clientId":"c8","op":"rename"},
+			{"clientId":"c9","op":"update","id":"`+petID1+`","name":"Blind","birth":"2020-01-01","breedId":"b1"},
+			{"clientId":"c10","op":"delete","id":"`+petID1+`"}
+		]}`)
+		if code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		var statuses []string
+		for _, r := range got.Results {
+			statuses = append(statuses, r.ClientID+"="+r.Status)
+		}
+		want := "c1=applied c2=applied c3=conflict c4=invalid c5=invalid c6=applied c7=conflict c8=invalid c9=invalid c10=invalid"
+		if strings.Join(statuses, " ") != want {
+			t.Fatalf("expected %s, got %s", want, strings.Join(statuses, " "))
+		}
+		if p := got.Results[2].Pet; p == nil || p.Name != "Fido II" || p.Version != 2 {
+			t.Errorf("expected the conflict to include the current pet, got %+v", p)
+		}
+		if got.Results[6].Pet != nil {
+			t.Errorf("expected no pet in the conflict on a missing pet, got %+v", got.Results[6].Pet)
+		}
+		created = got.Results[0].Pet.ID
+		if strings.Join(published, ",") != events.PetCreated+","+events.PetUpdated {
+			t.Errorf("expected created and updated events, got %v", published)
+		}
+	})
+
+	t.Run("pull returns the changes since the token", func(t *testing.T) {
+		if _, err := s.DeletePet(context.Background(), created, store.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		code, got := pull(t, "?token="+snap.SyncToken)
+		if code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		var ops []string
+		for _, c := range got.Changes {
+			ops = append(ops, c.Op+" "+string(c.ID))
+		}
+		// El alta y el borrado de Rex se reducen a su lápida, después de la edición de Fido.
+		want := "upsert " + petID1 + ",delete " + string(created)
+		if strings.Join(ops, ",") != want {
+			t.Errorf("expected %s, got %s", want, strings.Join(ops, ","))
+		}
+		if got.HasMore || got.SyncToken == snap.SyncToken {
+			t.Errorf("unexpected token %q (hasMore=%v)", got.SyncToken, got.HasMore)
+		}
+
+		_, again := pull(t, "?token="+got.SyncToken)
+		if len(again.Changes) != 0 || again.SyncToken != got.SyncToken {
+			t.Errorf("expected no more changes, got %+v", again)
+		}
+	})
+
+	t.Run("pull pages with limit", func(t *testing.T) {
+		_, first := pull(t, "?limit=1&token="+snap.SyncToken)
+		if len(first.Changes) != 1 || !first.HasMore {
+			t.Fatalf("expected one change and more to come, got %+v", first)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, q := range []string{"?token=abc", "?token=-1", "?limit=0", "?limit=1001"} {
+			if code, _ := pull(t, q); code != http.StatusBadRequest {
+				t.Errorf("%s: expected 400, got %d", q, code)
+			}
+		}
+		if code, _ := push(t, `{`); code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", code)
+		}
+		many := `{"mutations":[` + strings.Repeat(`{"op":"delete"},`, maxSyncMutations) + `{"op":"delete"}]}`
+		if code, _ := push(t, many); code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected 413, got %d", code)
+		}
+	})
+}
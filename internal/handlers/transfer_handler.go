@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/transfer"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+const (
+	// maxImportBytes y maxImportRows acotan el tamaño de un CSV importado.
+	maxImportBytes = 10 << 20
+	maxImportRows  = 10000
+)
+
+// TransferHandler exporta mascotas y razas e importa mascotas desde CSV.
+type TransferHandler struct {
+	petStore   store.PetStore
+	breedStore store.BreedStore
+	transactor store.Transactor
+	// events recibe las mascotas importadas, igual que desde PetHandler.
+	events events.Publisher
+}
+
+func NewTransferHandler(ps store.PetStore, bs store.BreedStore, tr store.Transactor, pub events.Publisher) *TransferHandler {
+	return &TransferHandler{
+		petStore:   ps,
+		breedStore: bs,
+		transactor: tr,
+		events:     pub,
+	}
+}
+
+// ExportPetsHandler exporta todas las mascotas, con su raza, en el formato de "format": "csv"
+// (por defecto) o "ndjson".
+// Ruta: GET /api/v1/export/pets
+func (th *TransferHandler) ExportPetsHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	pets, err := th.petStore.GetPets(r.Context())
+	if err != nil {
+		writeStoreError(w, err, "Not found", "Internal Server Error")
+		return
+	}
+	startExport(w, format, "pets")
+	if err := transfer.WritePets(w, format, pets); err != nil {
+		// La respuesta ya empezó: solo queda cortarla.
+		log.Printf("Error al exportar las mascotas: %v", err)
+	}
+}
+
+// ExportBreedsHandler exporta todas las razas, como ExportPetsHandler.
+// Ruta: GET /api/v1/export/breeds
+func (th *TransferHandler) ExportBreedsHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	breeds, err := th.breedStore.GetBreeds(r.Context())
+	if err != nil {
+		writeStoreError(w, err, "Not found", "Internal Server Error")
+		return
+	}
+	startExport(w, format, "breeds")
+	if err := transfer.WriteBreeds(w, format, breeds); err != nil {
+		log.Printf("Error al exportar las razas: %v", err)
+	}
+}
+
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = transfer.FormatCSV
+	}
+	if _, err := transfer.ContentType(format); err != nil {
+		http.Error(w, `Format must be "csv" or "ndjson"`, http.StatusBadRequest)
+		return "", false
+	}
+	return format, true
+}
+
+// startExport escribe los encabezados de una exportación. El cuerpo se envía a medida que
+// se codifica, sin acumularlo en memoria.
+func startExport(w http.ResponseWriter, format, name string) {
+	contentType, _ := transfer.ContentType(format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+"."+format+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// ImportPetsHandler importa mascotas del CSV del cuerpo (hasta 10 MB y 10000 filas). Las
+// columnas se asocian a name, birth y breed por su encabezado, o con parámetros
+// "map=<encabezado>:<campo>". "delimiter" cambia el separador (p. ej. "%3B" para ";"). Con
+// "dryRun=true" solo valida. En modo "atomic" (por defecto), si alguna fila tiene errores
+// responde 422 sin importar ninguna y, si no, las importa en una transacción y responde
+// 201. En modo "partial" importa las filas válidas y responde 200. La respuesta es siempre
+// el informe con los errores de cada fila.
+// Ruta: POST /api/v1/import/pets
+func (th *TransferHandler) ImportPetsHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	query := r.URL.Query()
+	mode := query.Get("mode")
+	switch mode {
+	case "":
+		mode = types.BulkAtomic
+	case types.BulkAtomic, types.BulkPartial:
+	default:
+		http.Error(w, `Mode must be "atomic" or "partial"`, http.StatusBadRequest)
+		return
+	}
+	dryRun := query.Get("dryRun") == "true"
+
+	opts := transfer.Options{Mapping: transfer.Mapping{}, MaxRows: maxImportRows}
+	for _, m := range query["map"] {
+		header, field, ok := strings.Cut(m, ":")
+		if !ok {
+			http.Error(w, "Map must be <header>:<field>", http.StatusBadRequest)
+			return
+		}
+		opts.Mapping[header] = field
+	}
+	if d := query.Get("delimiter"); d != "" {
+		comma, size := utf8.DecodeRuneInString(d)
+		if size != len(d) || comma == '"' || comma == '\n' || comma == '\r' {
+			http.Error(w, "Delimiter must be a single character", http.StatusBadRequest)
+			return
+		}
+		opts.Comma = comma
+	}
+
+	breeds, err := th.breedStore.GetBreeds(r.Context())
+	if err != nil {
+		writeStoreError(w, err, "Not found", "Error at checking the breed")
+		return
+	}
+	res, err := transfer.ReadPets(http.MaxBytesReader(w, r.Body, maxImportBytes), breeds, opts)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "The file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Is(err, transfer.ErrInvalidFile) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error reading the body of the request", http.StatusBadRequest)
+		return
+	}
+
+	report := transfer.Report{
+		Rows:           res.Total,
+		DryRun:         dryRun,
+		Created:        []transfer.CreatedPet{},
+		Errors:         res.Errors,
+		IgnoredColumns: res.IgnoredColumns,
+	}
+	switch {
+	case dryRun:
+		writeImportReport(w, http.StatusOK, report)
+		return
+	case mode == types.BulkAtomic && len(res.Errors) > 0:
+		writeImportReport(w, http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	var created []*types.Pet
+	if mode == types.BulkPartial {
+		for _, row := range res.Rows {
+			pet, err := th.petStore.CreatePet(r.Context(), row.Name, row.Birth, row.BreedID)
+			if err != nil {
+				_, msg := bulkError(err)
+				report.Errors = append(report.Errors, transfer.RowError{Line: row.Line, Error: msg})
+				continue
+			}
+			created = append(created, pet)
+			report.Created = append(report.Created, transfer.CreatedPet{Line: row.Line, ID: pet.ID})
+		}
+	} else {
+		err := th.transactor.WithinTx(r.Context(), func(tx store.Tx) error {
+			for _, row := range res.Rows {
+				pet, err := tx.CreatePet(r.Context(), row.Name, row.Birth, row.BreedID)
+				if err != nil {
+					return err
+				}
+				created = append(created, pet)
+				report.Created = append(report.Created, transfer.CreatedPet{Line: row.Line, ID: pet.ID})
+			}
+			return nil
+		})
+		if err != nil {
+			writeStoreError(w, err, "Breed not found", "Error importing pets")
+			return
+		}
+	}
+	for _, pet := range created {
+		publishPetEvent(th.events, r, events.PetCreated, pet)
+	}
+	report.Imported = len(created)
+
+	status := http.StatusOK
+	if mode == types.BulkAtomic {
+		status = http.StatusCreated
+	}
+	writeImportReport(w, status, report)
+}
+
+func writeImportReport(w http.ResponseWriter, status int, report transfer.Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error al codificar el informe de importación a JSON: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/transfer"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func TestExportHandlers(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1", Size: types.SizeSmall}}
+	pets := []types.Pet{{ID: petID1, Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0], Version: 1}}
+	s := store.NewMemoryStore(breeds, pets...)
+	handler := NewTransferHandler(s, s, s, events.NewBus())
+
+	t.Run("pets as csv", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ExportPetsHandler(rec, httptest.NewRequest("GET", "/api/v1/export/pets", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+			t.Errorf("unexpected Content-Type %q", ct)
+		}
+		if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="pets.csv"` {
+			t.Errorf("unexpected Content-Disposition %q", cd)
+		}
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if len(lines) != 2 || !strings.HasPrefix(lines[1], petID1+",Fido,2020-01-01,b1,Breed1,small,1,") {
+			t.Errorf("unexpected body:\n%s", rec.Body.String())
+		}
+	})
+
+	t.Run("breeds as ndjson", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ExportBreedsHandler(rec, httptest.NewRequest("GET", "/api/v1/export/breeds?format=ndjson", nil))
+		if rec.Header().Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("unexpected Content-Type %q", rec.Header().Get("Content-Type"))
+		}
+		var got types.Breed
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.ID != "b1" {
+			t.Errorf("unexpected body %q (%v)", rec.Body.String(), err)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ExportPetsHandler(rec, httptest.NewRequest("GET", "/api/v1/export/pets?format=xml", nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestImportPetsHandler(t *testing.T) {
+	breeds := []types.Breed{{ID: "b1", Name: "Breed One"}}
+	const valid = "Name,Birth,Breed\nFido,2020-01-01,b1\nRex,2021-02-02,breed one\n"
+	const withErrors = valid + "Bad,someday,b1\n"
+
+	do := func(t *testing.T, query, body string) (*store.MemoryStore, int, transfer.Report, []string) {
+		t.Helper()
+		s := store.NewMemoryStore(breeds)
+		bus := events.NewBus()
+		var published []string
+		bus.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e.Type) })
+		rec := httptest.NewRecorder()
+		NewTransferHandler(s, s, s, bus).ImportPetsHandler(rec, httptest.NewRequest("POST", "/api/v1/import/pets"+query, strings.NewReader(body)))
+		var report transfer.Report
+		if rec.Header().Get("Content-Type") == "application/json" {
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatalf("error decoding: %v", err)
+			}
+		}
+		return s, rec.Code, report, published
+	}
+	countPets := func(t *testing.T, s *store.MemoryStore) int {
+		t.Helper()
+		pets, err := s.GetPets(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(pets)
+	}
+
+	t.Run("atomic import", func(t *testing.T) {
+		s, code, report, published := do(t, "", valid)
+		if code != http.StatusCreated || report.Rows != 2 || report.Imported != 2 || len(report.Created) != 2 || report.Created[1].Line != 3 {
+			t.Fatalf("unexpected response %d: %+v", code, report)
+		}
+		if countPets(t, s) != 2 || len(published) != 2 {
+			t.Errorf("expected 2 pets and 2 events, got %d and %d", countPets(t, s), len(published))
+		}
+	})
+
+	t.Run("atomic import with errors imports nothing", func(t *testing.T) {
+		s, code, report, _ := do(t, "", withErrors)
+		if code != http.StatusUnprocessableEntity || report.Imported != 0 || len(report.Errors) != 1 || report.Errors[0].Line != 4 || report.Errors[0].Column != "Birth" {
+			t.Fatalf("unexpected response %d: %+v", code, report)
+		}
+		if countPets(t, s) != 0 {
+			t.Errorf("expected no pets, got %d", countPets(t, s))
+		}
+	})
+
+	t.Run("partial import", func(t *testing.T) {
+		s, code, report, _ := do(t, "?mode=partial", withErrors)
+		if code != http.StatusOK || report.Rows != 3 || report.Imported != 2 || len(report.Errors) != 1 {
+			t.Fatalf("unexpected response %d: %+v", code, report)
+		}
+		if countPets(t, s) != 2 {
+			t.Errorf("expected 2 pets, got %d", countPets(t, s))
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		s, code, report, _ := do(t, "?dryRun=true", withErrors)
+		if code != http.StatusOK || !report.DryRun || report.Imported != 0 || len(report.Errors) != 1 {
+			t.Fatalf("unexpected response %d: %+v", code, report)
+		}
+		if countPets(t, s) != 0 {
+			t.Errorf("expected no pets, got %d", countPets(t, s))
+		}
+	})
+
+	t.Run("mapping and delimiter", func(t *testing.T) {
+		_, code, report, _ := do(t, "?delimiter=%3B&map=Perro:name&map=Tipo:breed", "Perro;Birth;Tipo\nFido;2020-01-01;b1\n")
+		if code != http.StatusCreated || report.Imported != 1 {
+			t.Fatalf("unexpected response %d: %+v", code, report)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for query, body := range map[string]string{
+			"?mode=some":         valid,
+			"?map=name":          valid,
+			"?delimiter=%3B%3B":  valid,
+			"":                   "Name,Breed\nFido,b1\n",
+			"?map=Missing:birth": valid,
+		} {
+			if _, code, _, _ := do(t, query, body); code != http.StatusBadRequest {
+				t.Errorf("%q: expected 400, got %d", query, code)
+			}
+		}
+	})
+}
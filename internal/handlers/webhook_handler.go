@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+const (
+	// defaultDeliveries y maxDeliveries acotan el registro de entregas que se devuelve.
+	defaultDeliveries = 50
+	maxDeliveries     = 200
+)
+
+// WebhookHandler expone a los administradores las suscripciones a webhooks y sus entregas.
+type WebhookHandler struct {
+	webhookStore store.WebhookStore
+	// deliver indica si esta instancia corre el planificador que reparte los eventos. Sin
+	// él no se aceptan suscripciones nuevas: nunca recibirían nada.
+	deliver bool
+}
+
+func NewWebhookHandler(ws store.WebhookStore, deliver bool) *WebhookHandler {
+	return &WebhookHandler{
+		webhookStore: ws,
+		deliver:      deliver,
+	}
+}
+
+// CreateWebhookHandler crea una suscripción. Es la única respuesta que incluye el secreto.
+// Ruta: POST /api/v1/admin/webhooks
+func (wh *WebhookHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	if !wh.deliver {
+		http.Error(w, "Webhooks are disabled because the scheduler that delivers them is off (SCHEDULER_ENABLED=false)", http.StatusServiceUnavailable)
+		return
+	}
+
+	var requestBody types.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Error decoding the body of the request", http.StatusBadRequest)
+		return
+	}
+
+	u, err := url.Parse(requestBody.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "URL must be an absolute http or https URL", http.StatusBadRequest)
+		return
+	}
+	filters := requestBody.Events
+	if len(filters) == 0 {
+		filters = []string{"*"}
+	}
+	for _, f := range filters {
+		if !validEventFilter(f) {
+			http.Error(w, "Unknown event filter "+strconv.Quote(f)+". Use one of "+strings.Join(events.Types, ", ")+", <entity>.* or *", http.StatusBadRequest)
+			return
+		}
+	}
+	secret := requestBody.Secret
+	if secret == "" {
+		secret = newWebhookSecret()
+	}
+
+	created, err := wh.webhookStore.CreateWebhook(r.Context(), types.Webhook{URL: u.String(), Events: filters, Secret: secret})
+	if err != nil {
+		writeStoreError(w, err, "Webhook not found", "Error creating webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(created); err != nil {
+		log.Printf("Error encoding response for created webhook: %v", err)
+	}
+}
+
+// validEventFilter acepta "*", un tipo de evento conocido o "<entidad>.*" de una entidad
+// que publica eventos.
+func validEventFilter(f string) bool {
+	if f == "*" || slices.Contains(events.Types, f) {
+		return true
+	}
+	entity, ok := strings.CutSuffix(f, ".*")
+	return ok && slices.ContainsFunc(events.Types, func(t string) bool { return strings.HasPrefix(t, entity+".") })
+}
+
+// newWebhookSecret genera un secreto aleatorio de 256 bits.
+func newWebhookSecret() string {
+	var b [32]byte
+	rand.Read(b[:])
+	return "whsec_" + hex.EncodeToString(b[:])
+}
+
+// GetWebhooksHandler devuelve las suscripciones, sin sus secretos.
+// Ruta: GET /api/v1/admin/webhooks
+func (wh *WebhookHandler) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	hooks, err := wh.webhookStore.GetWebhooks(r.Context())
+	if err != nil {
+		writeStoreError(w, err, "Webhook not found", "Internal Server Error")
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(hooks); err != nil {
+		log.Printf("Error al codificar los webhooks a JSON: %v", err)
+	}
+}
+
+// DeleteWebhookHandler borra la suscripción y su registro de entregas.
+// Ruta: DELETE /api/v1/admin/webhooks/{id}
+func (wh *WebhookHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := types.ParseUUID(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID. It must be a UUID", http.StatusBadRequest)
+		return
+	}
+	if err := wh.webhookStore.DeleteWebhook(r.Context(), id); err != nil {
+		writeStoreError(w, err, "Webhook not found", "Error deleting webhook")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveriesHandler devuelve las últimas entregas del webhook, de la más reciente a la
+// más antigua. "limit" va de 1 a 200 (por defecto, 50).
+// Ruta: GET /api/v1/admin/webhooks/{id}/deliveries
+func (wh *WebhookHandler) GetDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := types.ParseUUID(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID. It must be a UUID", http.StatusBadRequest)
+		return
+	}
+	limit := defaultDeliveries
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxDeliveries {
+			http.Error(w, "Limit must be a number between 1 and "+strconv.Itoa(maxDeliveries), http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := wh.webhookStore.GetDeliveries(r.Context(), id, limit)
+	if err != nil {
+		writeStoreError(w, err, "Webhook not found", "Internal Server Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		log.Printf("Error al codificar las entregas a JSON: %v", err)
+	}
+}
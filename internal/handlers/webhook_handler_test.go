@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func TestWebhookHandlers(t *testing.T) {
+	s := store.NewMemoryStore(nil)
+	router := http.NewServeMux()
+	RegisterWebhookRoutes(router, s, true)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("POST", "/api/v1/admin/webhooks", `{"url":"https://vet.example.com/hooks","events":["pet.created","pet.*","breed.*"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var created types.Webhook
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	if !strings.HasPrefix(created.Secret, "whsec_") || len(created.Events) != 3 {
+		t.Errorf("expected a generated secret and the filters, got %+v", created)
+	}
+
+	rec = do("POST", "/api/v1/admin/webhooks", `{"url":"http://insurer.example.com","secret":"mine"}`)
+	var all types.Webhook
+	json.NewDecoder(rec.Body).Decode(&all)
+	if rec.Code != http.StatusCreated || all.Secret != "mine" || len(all.Events) != 1 || all.Events[0] != "*" {
+		t.Errorf("expected a catch-all webhook with the given secret, got %d %+v", rec.Code, all)
+	}
+
+	for name, body := range map[string]string{
+		"relative URL":   `{"url":"/hooks"}`,
+		"other scheme":   `{"url":"ftp://example.com"}`,
+		"unknown event":  `{"url":"https://example.com","events":["pet.renamed"]}`,
+		"unknown entity": `{"url":"https://example.com","events":["owner.*"]}`,
+		"breed deleted":  `{"url":"https://example.com","events":["breed.deleted"]}`,
+		"malformed body": `{"url":`,
+	} {
+		if rec := do("POST", "/api/v1/admin/webhooks", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+
+	rec = do("GET", "/api/v1/admin/webhooks", "")
+	var list []types.Webhook
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != created.ID || list[0].Secret != "" || list[1].Secret != "" {
+		t.Errorf("expected both webhooks without secrets, got %+v", list)
+	}
+
+	for range 3 {
+		if _, err := s.AddDelivery(context.Background(), types.WebhookDelivery{WebhookID: created.ID, EventID: "e", EventType: "pet.created", Payload: []byte("{}")}); err != nil {
+			t.Fatalf("AddDelivery failed: %v", err)
+		}
+	}
+	rec = do("GET", "/api/v1/admin/webhooks/"+created.ID+"/deliveries?limit=2", "")
+	var deliveries []types.WebhookDelivery
+	if err := json.NewDecoder(rec.Body).Decode(&deliveries); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != 3 {
+		t.Errorf("expected the two newest deliveries, got %+v", deliveries)
+	}
+	if rec := do("GET", "/api/v1/admin/webhooks/"+created.ID+"/deliveries?limit=1000", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a limit too high, got %d", rec.Code)
+	}
+	if rec := do("GET", "/api/v1/admin/webhooks/w1/deliveries", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed ID, got %d", rec.Code)
+	}
+
+	if rec := do("DELETE", "/api/v1/admin/webhooks/"+created.ID, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+	if rec := do("DELETE", "/api/v1/admin/webhooks/"+created.ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if rec := do("GET", "/api/v1/admin/webhooks/"+created.ID+"/deliveries", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for the deliveries of a deleted webhook, got %d", rec.Code)
+	}
+}
+
+func TestWebhookHandlersWithoutScheduler(t *testing.T) {
+	s := store.NewMemoryStore(nil)
+	router := http.NewServeMux()
+	RegisterWebhookRoutes(router, s, false)
+
+	req, _ := http.NewRequest("POST", "/api/v1/admin/webhooks", strings.NewReader(`{"url":"https://vet.example.com/hooks"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "SCHEDULER_ENABLED") {
+		t.Errorf("expected 503 naming SCHEDULER_ENABLED, got %d: %s", rec.Code, rec.Body)
+	}
+	if list, _ := s.GetWebhooks(context.Background()); len(list) != 0 {
+		t.Errorf("expected no webhooks, got %+v", list)
+	}
+}
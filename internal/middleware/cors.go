@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig define qué orígenes, métodos y encabezados se permiten en solicitudes cross-origin.
+type CORSConfig struct {
+	// AllowedOrigins acepta orígenes exactos ("https://app.example.com"),
+	// comodines de subdominio ("https://*.example.com") o "*" para cualquier origen.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials permite cookies y credenciales, solo para los orígenes indicados
+	// explícitamente o con comodín de subdominio: con "*" se ignora (ver setOrigin).
+	AllowCredentials bool
+	// MaxAge indica cuánto tiempo puede el navegador cachear la respuesta preflight.
+	MaxAge time.Duration
+}
+
+// DefaultCORSMethods son los métodos permitidos cuando la configuración no indica ninguno.
+var DefaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// DefaultCORSHeaders son los encabezados permitidos cuando la configuración no indica ninguno.
+var DefaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "If-Match"}
+
+type cors struct {
+	allowAll         bool
+	origins          []string
+	wildcards        []wildcardOrigin
+	methods          []string
+	headers          []string
+	allowAllHeaders  bool
+	exposed          string
+	allowCredentials bool
+	maxAge           string
+}
+
+// wildcardOrigin representa un patrón "scheme://*.dominio" ya separado en prefijo y sufijo.
+type wildcardOrigin struct {
+	prefix string // "https://"
+	suffix string // ".example.com"
+}
+
+func (w wildcardOrigin) match(origin string) bool {
+	if !strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+		return false
+	}
+	// Debe haber al menos un carácter de subdominio entre el prefijo y el sufijo.
+	sub := origin[len(w.prefix) : len(origin)-len(w.suffix)]
+	return sub != "" && !strings.ContainsAny(sub, "/:")
+}
+
+// CORS devuelve un middleware que aplica la política cross-origin descrita por cfg.
+// Las solicitudes preflight (OPTIONS con Access-Control-Request-Method) se responden
+// directamente con 204 y no llegan al handler envuelto.
+func CORS(cfg CORSConfig) Middleware {
+	c := &cors{
+		headers:          make([]string, 0, len(cfg.AllowedHeaders)),
+		allowCredentials: cfg.AllowCredentials,
+	}
+
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.ToLower(strings.TrimSpace(o))
+		switch {
+		case o == "":
+			continue
+		case o == "*":
+			c.allowAll = true
+		case strings.Contains(o, "://*."):
+			i := strings.Index(o, "*")
+			c.wildcards = append(c.wildcards, wildcardOrigin{prefix: o[:i], suffix: o[i+1:]})
+		default:
+			c.origins = append(c.origins, o)
+		}
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = DefaultCORSMethods
+	}
+	for _, m := range methods {
+		c.methods = append(c.methods, strings.ToUpper(strings.TrimSpace(m)))
+	}
+
+	allowedHeaders := cfg.AllowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = DefaultCORSHeaders
+	}
+	for _, h := range allowedHeaders {
+		if h == "*" {
+			c.allowAllHeaders = true
+			continue
+		}
+		c.headers = append(c.headers, http.CanonicalHeaderKey(strings.TrimSpace(h)))
+	}
+
+	if c.allowAll && c.allowCredentials {
+		log.Printf("CORS: se ignora AllowCredentials porque se permite cualquier origen")
+		c.allowCredentials = false
+	}
+
+	c.exposed = strings.Join(cfg.ExposedHeaders, ", ")
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	return c.handler
+}
+
+func (c *cors) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, r, origin)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if origin != "" && c.originAllowed(origin) {
+			c.setOrigin(w, origin)
+			if c.exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", c.exposed)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	// Si algo no está permitido respondemos sin encabezados CORS: el navegador bloqueará la solicitud.
+	if origin == "" || !c.originAllowed(origin) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if !slices.Contains(c.methods, method) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	requested := parseHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+	if !c.headersAllowed(requested) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.setOrigin(w, origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+	if len(requested) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if c.maxAge != "" {
+		h.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setOrigin escribe Access-Control-Allow-Origin. Con "*" nunca se permiten credenciales:
+// devolver el origen de la solicitud junto con Allow-Credentials dejaría a cualquier sitio
+// leer las respuestas autenticadas del usuario.
+func (c *cors) setOrigin(w http.ResponseWriter, origin string) {
+	if c.allowAll {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) originAllowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if slices.Contains(c.origins, origin) {
+		return true
+	}
+	for _, w := range c.wildcards {
+		if w.match(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) headersAllowed(requested []string) bool {
+	if c.allowAllHeaders {
+		return true
+	}
+	for _, h := range requested {
+		if !slices.Contains(c.headers, h) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseHeaderList(v string) []string {
+	var out []string
+	for _, h := range strings.Split(v, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" {
+			out = append(out, http.CanonicalHeaderKey(h))
+		}
+	}
+	return out
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestCORSSimpleRequest(t *testing.T) {
+	h := CORS(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.dogs.dev"},
+		ExposedHeaders: []string{"ETag"},
+	})(okHandler)
+
+	tests := []struct {
+		name   string
+		origin string
+		want   string
+	}{
+		{"exact origin", "https://app.example.com", "https://app.example.com"},
+		{"wildcard subdomain", "https://beta.dogs.dev", "https://beta.dogs.dev"},
+		{"nested subdomain", "https://a.b.dogs.dev", "https://a.b.dogs.dev"},
+		{"bare wildcard domain", "https://dogs.dev", ""},
+		{"other scheme", "http://beta.dogs.dev", ""},
+		{"unknown origin", "https://evil.com", ""},
+		{"no origin", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/pets", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected 200, got %d", rec.Code)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Errorf("Access-Control-Allow-Origin: expected %q, got %q", tt.want, got)
+			}
+			if tt.want != "" && rec.Header().Get("Access-Control-Expose-Headers") != "ETag" {
+				t.Errorf("expected exposed headers, got %q", rec.Header().Get("Access-Control-Expose-Headers"))
+			}
+			if rec.Header().Get("Vary") != "Origin" {
+				t.Errorf("expected Vary: Origin, got %q", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCORSWildcardWithCredentials(t *testing.T) {
+	t.Run("without credentials uses *", func(t *testing.T) {
+		h := CORS(CORSConfig{AllowedOrigins: []string{"*"}})(okHandler)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", "https://any.site")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("expected *, got %q", got)
+		}
+	})
+
+	t.Run("with credentials never allows them", func(t *testing.T) {
+		h := CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})(okHandler)
+		for _, preflight := range []bool{false, true} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if preflight {
+				req.Method = http.MethodOptions
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			req.Header.Set("Origin", "https://any.site")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+				t.Errorf("preflight %v: expected *, got %q", preflight, got)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+				t.Errorf("preflight %v: expected no credentials, got %q", preflight, got)
+			}
+		}
+	})
+
+	t.Run("credentials with explicit origins echo the origin", func(t *testing.T) {
+		h := CORS(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})(okHandler)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("expected echoed origin, got %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("expected credentials true, got %q", got)
+		}
+	})
+}
+
+func TestCORSPreflight(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	h := CORS(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "Idempotency-Key"},
+		MaxAge:         time.Hour,
+	})(next)
+
+	preflight := func(origin, method, headers string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/pets", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		rec := preflight("https://app.example.com", "POST", "content-type, idempotency-key")
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("expected 204, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("unexpected origin %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+			t.Errorf("unexpected methods %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, Idempotency-Key" {
+			t.Errorf("unexpected headers %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Max-Age"); got != "3600" {
+			t.Errorf("unexpected max age %q", got)
+		}
+	})
+
+	t.Run("disallowed method", func(t *testing.T) {
+		rec := preflight("https://app.example.com", "DELETE", "")
+		if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("expected no CORS headers for disallowed method")
+		}
+	})
+
+	t.Run("disallowed header", func(t *testing.T) {
+		rec := preflight("https://app.example.com", "POST", "X-Secret")
+		if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("expected no CORS headers for disallowed header")
+		}
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		rec := preflight("https://evil.com", "GET", "")
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("expected 204, got %d", rec.Code)
+		}
+		if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("expected no CORS headers for disallowed origin")
+		}
+	})
+
+	if called {
+		t.Errorf("preflight requests must not reach the wrapped handler")
+	}
+}
+
+func TestCORSPlainOptionsPassesThrough(t *testing.T) {
+	called := false
+	h := CORS(CORSConfig{AllowedOrigins: []string{"*"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if !called {
+		t.Errorf("OPTIONS without Access-Control-Request-Method should reach the handler")
+	}
+}
@@ -0,0 +1,280 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader es el encabezado con el que el cliente identifica un reintento.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength evita que un cliente use claves arbitrariamente grandes.
+const maxIdempotencyKeyLength = 255
+
+// defaultIdempotencyMaxBody es el tamaño máximo por defecto del cuerpo de una solicitud
+// con Idempotency-Key y de la respuesta que se guarda.
+const defaultIdempotencyMaxBody = 1 << 20
+
+// idempotencySweepInterval es cada cuánto elimina MemoryIdempotencyStore las claves expiradas.
+const idempotencySweepInterval = time.Minute
+
+// replayedHeaders son los encabezados de la respuesta original que se reproducen; el resto
+// (CORS, rate limit...) los vuelven a calcular los demás middlewares en cada solicitud.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// IdempotentResponse es la respuesta guardada para poder reproducirla en los reintentos.
+type IdempotentResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// IdempotencyRecord es lo que se guarda por cada clave. Response es nil mientras la
+// primera solicitud sigue en curso.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *IdempotentResponse
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore guarda las respuestas por clave. Igual que RateLimitStore, la versión en
+// memoria sirve para una instancia y un backend compartido debe implementar esta interfaz.
+type IdempotencyStore interface {
+	// Begin reserva la clave si no existe (o expiró) y devuelve nil. Si ya existe, devuelve
+	// el registro guardado para que el llamador decida si reproducirlo o rechazarlo.
+	Begin(key, fingerprint string, expiresAt time.Time) (*IdempotencyRecord, error)
+	// Complete guarda la respuesta final de una clave reservada.
+	Complete(key string, resp *IdempotentResponse) error
+	// Release libera una clave reservada sin guardar respuesta, para permitir reintentarla.
+	Release(key string) error
+}
+
+// MemoryIdempotencyStore es un IdempotencyStore en memoria.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*IdempotencyRecord
+	now     func() time.Time
+	// nextSweep es cuándo toca volver a eliminar las claves expiradas.
+	nextSweep time.Time
+}
+
+// NewMemoryIdempotencyStore crea un store de idempotencia en memoria.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]*IdempotencyRecord), now: time.Now}
+}
+
+func (s *MemoryIdempotencyStore) Begin(key, fingerprint string, expiresAt time.Time) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.After(s.nextSweep) {
+		s.sweep(now)
+		s.nextSweep = now.Add(idempotencySweepInterval)
+	}
+
+	if rec, ok := s.records[key]; ok && !now.After(rec.ExpiresAt) {
+		cp := *rec
+		return &cp, nil
+	}
+	s.records[key] = &IdempotencyRecord{Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	return nil, nil
+}
+
+// sweep elimina las claves expiradas. Begin no lo hace en cada llamada para no recorrer
+// todas las claves en cada solicitud; mientras tanto, ignora las expiradas que encuentra.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	for k, rec := range s.records {
+		if now.After(rec.ExpiresAt) {
+			delete(s.records, k)
+		}
+	}
+}
+
+func (s *MemoryIdempotencyStore) Complete(key string, resp *IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[key]; ok {
+		rec.Response = resp
+	}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// IdempotencyConfig configura el middleware de idempotencia.
+type IdempotencyConfig struct {
+	Store IdempotencyStore
+	// TTL es el tiempo durante el que se recuerda la respuesta de una clave.
+	TTL time.Duration
+	// MaxBodyBytes limita el cuerpo de las solicitudes con Idempotency-Key, que se lee entero
+	// para calcular su huella, y la respuesta que se guarda: una más grande llega al cliente
+	// pero no se guarda. Cero es 1 MiB.
+	MaxBodyBytes int64
+	Now          func() time.Time
+}
+
+// Idempotency hace que las solicitudes POST con Idempotency-Key se ejecuten una sola vez por
+// cliente y clave: los reintentos reciben la respuesta original (estado y cuerpo), un reintento
+// con un cuerpo distinto recibe 422 y uno concurrente con la original recibe 409.
+// Las respuestas 5xx no se guardan, para que el cliente pueda reintentar, y tampoco las
+// que superan MaxBodyBytes. Una solicitud con Idempotency-Key y un cuerpo mayor recibe 413.
+func Idempotency(cfg IdempotencyConfig) Middleware {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryIdempotencyStore()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaultIdempotencyMaxBody
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idemKey := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || idemKey == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(idemKey) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, cfg.MaxBodyBytes))
+			r.Body.Close()
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body too large for an Idempotency-Key", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Error reading the body of the request", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// La clave se guarda por cliente (dueño) y ruta, así dos usuarios no colisionan.
+			key := clientKey(r) + "|" + r.URL.Path + "|" + idemKey
+			fingerprint := requestFingerprint(r, body)
+
+			rec, err := cfg.Store.Begin(key, fingerprint, cfg.Now().Add(cfg.TTL))
+			if err != nil {
+				log.Printf("Error en el store de idempotencia: %v", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			if rec != nil {
+				switch {
+				case rec.Fingerprint != fingerprint:
+					http.Error(w, "Idempotency-Key was already used with a different request body", http.StatusUnprocessableEntity)
+				case rec.Response == nil:
+					http.Error(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+				default:
+					replay(w, rec.Response)
+				}
+				return
+			}
+
+			cw := &captureWriter{ResponseWriter: w, status: http.StatusOK, limit: int(cfg.MaxBodyBytes)}
+			defer func() {
+				if p := recover(); p != nil {
+					cfg.Store.Release(key)
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(cw, r)
+
+			if cw.status >= http.StatusInternalServerError || cw.truncated {
+				if err := cfg.Store.Release(key); err != nil {
+					log.Printf("Error liberando la clave de idempotencia: %v", err)
+				}
+				return
+			}
+			resp := &IdempotentResponse{
+				StatusCode: cw.status,
+				Header:     make(http.Header),
+				Body:       cw.body.Bytes(),
+			}
+			for _, h := range replayedHeaders {
+				if v := w.Header().Values(h); len(v) > 0 {
+					resp.Header[h] = v
+				}
+			}
+			if err := cfg.Store.Complete(key, resp); err != nil {
+				log.Printf("Error guardando la respuesta idempotente: %v", err)
+			}
+		})
+	}
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, resp *IdempotentResponse) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.StatusCode)
+	w.Write(resp.Body)
+}
+
+// captureWriter escribe la respuesta al cliente y a la vez guarda una copia de hasta limit
+// bytes; si la respuesta es mayor, deja de copiarla y marca truncated.
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	limit       int
+	truncated   bool
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.status = status
+		c.wroteHeader = true
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.truncated {
+		if c.body.Len()+len(b) > c.limit {
+			c.truncated = true
+			c.body = bytes.Buffer{}
+		} else {
+			c.body.Write(b)
+		}
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// Unwrap permite a http.ResponseController acceder al ResponseWriter original.
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+type contextKey string
+
+const (
+	subjectKey  contextKey = "subject"
+	clientIPKey contextKey = "clientIP"
+)
+
+// IdentityConfig describe cómo identificar al cliente de una solicitud.
+type IdentityConfig struct {
+	// TrustedProxies son las redes (p. ej. el balanceador o el API gateway) cuyos
+	// encabezados X-Forwarded-For y SubjectHeader se consideran fiables.
+	TrustedProxies []netip.Prefix
+	// SubjectHeader es el encabezado en el que el gateway deja el usuario ya autenticado.
+	// Si está vacío, ninguna solicitud tiene sujeto autenticado.
+	SubjectHeader string
+}
+
+// Identity resuelve la IP real del cliente y, si viene de un proxy de confianza,
+// el sujeto autenticado; ambos quedan disponibles en el contexto de la solicitud.
+func Identity(cfg IdentityConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			ctx = context.WithValue(ctx, clientIPKey, ClientIP(r, cfg.TrustedProxies))
+			if cfg.SubjectHeader != "" && fromTrustedProxy(r, cfg.TrustedProxies) {
+				if sub := strings.TrimSpace(r.Header.Get(cfg.SubjectHeader)); sub != "" {
+					ctx = WithSubject(ctx, sub)
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WithSubject devuelve una copia del contexto con el sujeto autenticado.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey, subject)
+}
+
+// SubjectFromContext devuelve el sujeto autenticado, o "" si la solicitud es anónima.
+func SubjectFromContext(ctx context.Context) string {
+	sub, _ := ctx.Value(subjectKey).(string)
+	return sub
+}
+
+// ClientIPFromContext devuelve la IP resuelta por Identity, o "" si no se ejecutó.
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
+
+// ClientIP devuelve la IP del cliente. X-Forwarded-For solo se tiene en cuenta cuando la
+// conexión viene de un proxy de confianza, y se recorre de derecha a izquierda saltando
+// los proxies de confianza para que un cliente no pueda falsificar su IP.
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	remote := remoteAddr(r)
+	if !remote.IsValid() {
+		return r.RemoteAddr
+	}
+	if !isTrusted(remote, trusted) {
+		return remote.String()
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = ip.Unmap()
+		if !isTrusted(ip, trusted) {
+			return ip.String()
+		}
+	}
+	return remote.String()
+}
+
+func fromTrustedProxy(r *http.Request, trusted []netip.Prefix) bool {
+	remote := remoteAddr(r)
+	return remote.IsValid() && isTrusted(remote, trusted)
+}
+
+func remoteAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return ip.Unmap()
+}
+
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, p := range trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePrefixes convierte una lista de IPs o rangos CIDR en prefijos de red.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// RequireSubject restringe el acceso a los sujetos indicados: responde 401 si la solicitud
+// es anónima y 403 si el sujeto no está en la lista. Debe ir después de Identity.
+func RequireSubject(allowed []string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sub := SubjectFromContext(r.Context())
+			if sub == "" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !slices.Contains(allowed, sub) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAuthenticated restringe el acceso a las solicitudes con sujeto autenticado:
+// responde 401 a las anónimas. Debe ir después de Identity.
+func RequireAuthenticated() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if SubjectFromContext(r.Context()) == "" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
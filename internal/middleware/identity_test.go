@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParsePrefixes([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("ParsePrefixes: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"direct client", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"untrusted proxy ignores XFF", "203.0.113.7:5000", "1.2.3.4", "203.0.113.7"},
+		{"trusted proxy uses XFF", "10.0.0.5:5000", "198.51.100.9", "198.51.100.9"},
+		{"skips trusted hops", "10.0.0.5:5000", "198.51.100.9, 192.0.2.1, 10.1.1.1", "198.51.100.9"},
+		{"spoofed leftmost entry", "10.0.0.5:5000", "6.6.6.6, 198.51.100.9", "198.51.100.9"},
+		{"garbage XFF", "10.0.0.5:5000", "not-an-ip", "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := ClientIP(req, trusted); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIdentitySubject(t *testing.T) {
+	trusted, _ := ParsePrefixes([]string{"10.0.0.0/8"})
+	var subject string
+	h := Identity(IdentityConfig{TrustedProxies: trusted, SubjectHeader: "X-User"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = SubjectFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.5:1"
+	req.Header.Set("X-User", "alice")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if subject != "alice" {
+		t.Errorf("expected subject from trusted proxy, got %q", subject)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.7:1"
+	req.Header.Set("X-User", "alice")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if subject != "" {
+		t.Errorf("subject header from untrusted client must be ignored, got %q", subject)
+	}
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import "net/http"
+
+// Middleware envuelve un http.Handler para añadir comportamiento antes o después de él.
+type Middleware func(http.Handler) http.Handler
+
+// Chain aplica los middlewares en orden, de modo que el primero de la lista
+// es el más externo (el primero en ver la solicitud).
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit describe un token bucket: se reponen Requests tokens cada Per, con una capacidad de Burst.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// ParseLimit interpreta un límite con el formato "N/duración" (p. ej. "10/1m" o "5/1s").
+// La ráfaga permitida es igual a N.
+func ParseLimit(s string) (Limit, error) {
+	n, per, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected N/duration", s)
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad duration", s)
+	}
+	return Limit{Requests: requests, Per: d, Burst: requests}, nil
+}
+
+// ratePerSecond devuelve la velocidad de reposición de tokens.
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// RateLimitResult es la decisión tomada por un RateLimitStore para una solicitud.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // tiempo hasta que el bucket vuelva a estar lleno
+	RetryAfter time.Duration // tiempo hasta que haya un token disponible (solo si !Allowed)
+}
+
+// RateLimitStore guarda el estado de los buckets. La implementación en memoria sirve para
+// una única instancia; con varias réplicas se necesita un backend compartido (p. ej. Redis)
+// que implemente esta misma interfaz de forma atómica.
+type RateLimitStore interface {
+	Take(key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // momento en que el bucket estará lleno de nuevo
+}
+
+// MemoryRateLimitStore es un RateLimitStore en memoria protegido por un mutex.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+// NewMemoryRateLimitStore crea un store de buckets en memoria.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*bucket)}
+}
+
+// sweepEvery indica cada cuántas llamadas se eliminan los buckets inactivos.
+const sweepEvery = 1024
+
+func (s *MemoryRateLimitStore) Take(key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	rate := limit.ratePerSecond()
+	capacity := float64(limit.Burst)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.last).Seconds()
+		if elapsed > 0 {
+			b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+			b.last = now
+		}
+	}
+
+	res := RateLimitResult{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = secondsToDuration((capacity - b.tokens) / rate)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep elimina los buckets que ya se habrían rellenado por completo: son equivalentes a uno nuevo.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for k, b := range s.buckets {
+		if now.After(b.full) {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RouteLimit aplica un límite específico a una ruta. Path que termina en "/" actúa como
+// prefijo; Method vacío coincide con cualquier método.
+type RouteLimit struct {
+	Method string
+	Path   string
+	Limit  Limit
+}
+
+func (rl RouteLimit) matches(r *http.Request) bool {
+	if rl.Method != "" && rl.Method != r.Method {
+		return false
+	}
+	if strings.HasSuffix(rl.Path, "/") {
+		return strings.HasPrefix(r.URL.Path, rl.Path)
+	}
+	return r.URL.Path == rl.Path
+}
+
+// RateLimitConfig configura el middleware de rate limiting.
+type RateLimitConfig struct {
+	// Default se aplica a las rutas sin límite propio. Con Requests == 0 no se limitan.
+	Default Limit
+	// Routes se evalúan en orden; gana la primera que coincida.
+	Routes []RouteLimit
+	Store  RateLimitStore
+	// Now permite inyectar el reloj en los tests.
+	Now func() time.Time
+}
+
+// RateLimit devuelve un middleware que limita las solicitudes por cliente y por ruta.
+// El cliente es el sujeto autenticado o, si no hay, su IP (ver Identity). Las respuestas
+// incluyen los encabezados RateLimit-* y, al rechazar con 429, Retry-After.
+func RateLimit(cfg RateLimitConfig) Middleware {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryRateLimitStore()
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, scope := cfg.Default, "default"
+			for _, rl := range cfg.Routes {
+				if rl.matches(r) {
+					limit, scope = rl.Limit, rl.Method+" "+rl.Path
+					break
+				}
+			}
+			if limit.Requests <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := scope + "|" + clientKey(r)
+			res, err := cfg.Store.Take(key, limit, cfg.Now())
+			if err != nil {
+				// Si el backend falla preferimos dejar pasar la solicitud antes que tumbar la API.
+				log.Printf("Error en el rate limiter: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Per.Seconds())))
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientKey(r *http.Request) string {
+	if sub := SubjectFromContext(r.Context()); sub != "" {
+		return "sub:" + sub
+	}
+	if ip := ClientIPFromContext(r.Context()); ip != "" {
+		return "ip:" + ip
+	}
+	return "ip:" + ClientIP(r, nil)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit("10/1m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.Requests != 10 || l.Per != time.Minute || l.Burst != 10 {
+		t.Errorf("unexpected limit: %+v", l)
+	}
+
+	for _, bad := range []string{"", "10", "0/1m", "x/1m", "10/abc", "10/-1s"} {
+		if _, err := ParseLimit(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreRefill(t *testing.T) {
+	s := NewMemoryRateLimitStore()
+	limit := Limit{Requests: 2, Per: 2 * time.Second, Burst: 2}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		res, _ := s.Take("k", limit, now)
+		if !res.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	res, _ := s.Take("k", limit, now)
+	if res.Allowed {
+		t.Fatalf("third request should be rejected")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("expected RetryAfter 1s, got %v", res.RetryAfter)
+	}
+
+	res, _ = s.Take("k", limit, now.Add(time.Second))
+	if !res.Allowed {
+		t.Errorf("request after refill should be allowed")
+	}
+
+	res, _ = s.Take("other", limit, now)
+	if !res.Allowed || res.Remaining != 1 {
+		t.Errorf("buckets must be independent per key, got %+v", res)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := Chain(okHandler,
+		Identity(IdentityConfig{SubjectHeader: "X-User"}),
+		RateLimit(RateLimitConfig{
+			Default: Limit{Requests: 100, Per: time.Minute, Burst: 100},
+			Routes: []RouteLimit{
+				{Method: http.MethodPost, Path: "/api/v1/pets", Limit: Limit{Requests: 1, Per: time.Minute, Burst: 1}},
+			},
+			Now: func() time.Time { return now },
+		}),
+	)
+
+	do := func(method, path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/api/v1/pets", "203.0.113.1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec.Header().Get("RateLimit-Limit") != "1" || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("unexpected headers: %v", rec.Header())
+	}
+	if rec.Header().Get("RateLimit-Policy") != "1;w=60" {
+		t.Errorf("unexpected policy %q", rec.Header().Get("RateLimit-Policy"))
+	}
+
+	rec = do(http.MethodPost, "/api/v1/pets", "203.0.113.1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected Retry-After 60, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	// Otras rutas y otros clientes tienen su propio bucket.
+	if rec := do(http.MethodGet, "/api/v1/pets", "203.0.113.1"); rec.Code != http.StatusOK {
+		t.Errorf("GET should use the default limit, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/v1/pets", "203.0.113.2"); rec.Code != http.StatusOK {
+		t.Errorf("another client should not be limited, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	h := RateLimit(RateLimitConfig{})(okHandler)
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("expected unlimited request, got %d %v", rec.Code, rec.Header())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+)
+
+// RequestIDHeader es el encabezado con el que se propaga el identificador de la solicitud.
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDKey contextKey = "requestID"
+
+// maxRequestIDLength limita el tamaño de un X-Request-ID recibido del cliente.
+const maxRequestIDLength = 128
+
+// RequestID asigna un identificador a cada solicitud (reutilizando X-Request-ID si el cliente
+// o el proxy ya lo envían), lo devuelve en la respuesta y lo guarda en el contexto.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > maxRequestIDLength {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+		})
+	}
+}
+
+// RequestIDFromContext devuelve el identificador asignado por RequestID, o "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AuditContext traslada el sujeto (o, si es anónimo, la IP) y el request ID al contexto de
+// auditoría que usa el store al registrar cambios. Debe ir después de Identity y RequestID.
+func AuditContext() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			subject := SubjectFromContext(ctx)
+			actor := subject
+			if actor == "" {
+				actor = "ip:" + ClientIPFromContext(ctx)
+			}
+			ctx = audit.WithMeta(ctx, audit.Meta{Actor: actor, RequestID: RequestIDFromContext(ctx), Subject: subject})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+)
+
+func TestRequestIDAndAuditContext(t *testing.T) {
+	var meta audit.Meta
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta = audit.FromContext(r.Context())
+	}), RequestID(), Identity(IdentityConfig{}), AuditContext())
+
+	t.Run("generates an ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "203.0.113.1:1"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		id := rec.Header().Get(RequestIDHeader)
+		if len(id) != 32 {
+			t.Errorf("expected generated request ID, got %q", id)
+		}
+		if meta.RequestID != id || meta.Actor != "ip:203.0.113.1" || meta.Subject != "" {
+			t.Errorf("unexpected audit meta: %+v", meta)
+		}
+	})
+
+	t.Run("reuses the incoming ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(RequestIDHeader, "abc-123")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Header().Get(RequestIDHeader) != "abc-123" || meta.RequestID != "abc-123" {
+			t.Errorf("expected request ID to be propagated, got %q", rec.Header().Get(RequestIDHeader))
+		}
+	})
+
+	t.Run("authenticated subject", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req = req.WithContext(WithSubject(req.Context(), "alice"))
+		AuditContext()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			meta = audit.FromContext(r.Context())
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		if meta.Actor != "alice" || meta.Subject != "alice" {
+			t.Errorf("expected alice as actor and subject, got %+v", meta)
+		}
+	})
+}
+
+func TestRequireSubject(t *testing.T) {
+	h := RequireSubject([]string{"admin"})(okHandler)
+
+	for _, tt := range []struct {
+		subject string
+		want    int
+	}{
+		{"", http.StatusUnauthorized},
+		{"alice", http.StatusForbidden},
+		{"admin", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit/pet/p1", nil)
+		if tt.subject != "" {
+			req = req.WithContext(WithSubject(req.Context(), tt.subject))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("subject %q: expected %d, got %d", tt.subject, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestRequireAuthenticated(t *testing.T) {
+	h := RequireAuthenticated()(okHandler)
+
+	for subject, want := range map[string]int{"": http.StatusUnauthorized, "alice": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/pets/events", nil)
+		if subject != "" {
+			req = req.WithContext(WithSubject(req.Context(), subject))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("subject %q: expected %d, got %d", subject, want, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+)
+
+// ReadYourWrites abre una sesión del store por solicitud: si la solicitud escribe, sus
+// lecturas posteriores van al primario en lugar de a una réplica que quizá aún no tiene el cambio.
+func ReadYourWrites() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(store.WithSession(r.Context())))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package notify envía a los dueños los avisos del planificador (cumpleaños, tomas de
+// medicación...). Cada canal implementa Notifier; New elige el configurado.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Notification es un aviso para el dueño de una mascota. ID es estable entre reintentos
+// (la entrega es "al menos una vez"), así que el receptor puede descartar duplicados.
+type Notification struct {
+	ID      string      `json:"id"`
+	Kind    string      `json:"kind"`
+	PetID   types.PetID `json:"petId"`
+	Subject string      `json:"subject"`
+	Body    string      `json:"body"`
+	// At es el momento al que se refiere el aviso (p. ej. la hora de la toma).
+	At time.Time `json:"at"`
+}
+
+// Notifier entrega un aviso. Un error hace que el planificador lo reintente más tarde.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Config elige y configura el canal de los avisos.
+type Config struct {
+	// Kind es "log" (por defecto), "webhook" o "email".
+	Kind           string
+	WebhookURL     string
+	WebhookTimeout time.Duration
+	// SMTPAddr es el servidor SMTP, sin autenticación ni TLS: pensado para un servidor local
+	// de pruebas como MailHog o Mailpit, o para un relay de la propia red.
+	SMTPAddr string
+	From     string
+	To       []string
+}
+
+// New crea el Notifier de cfg.Kind.
+func New(cfg Config) (Notifier, error) {
+	switch cfg.Kind {
+	case "", "log":
+		return NewLogNotifier(log.Default()), nil
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("the webhook notifier needs a URL")
+		}
+		return NewWebhookNotifier(cfg.WebhookURL, &http.Client{Timeout: cfg.WebhookTimeout}), nil
+	case "email":
+		if cfg.SMTPAddr == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return nil, fmt.Errorf("the email notifier needs an SMTP address, a sender and at least one recipient")
+		}
+		return NewEmailNotifier(cfg.SMTPAddr, cfg.From, cfg.To), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", cfg.Kind)
+	}
+}
+
+// LogNotifier escribe los avisos en el log. Es el canal por defecto en desarrollo.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (l *LogNotifier) Notify(ctx context.Context, n Notification) error {
+	l.logger.Printf("Aviso %s (%s) para la mascota %s: %s. %s", n.ID, n.Kind, n.PetID, n.Subject, n.Body)
+	return nil
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNotification = Notification{
+	ID:      "birthday:p1:2025",
+	Kind:    "birthday",
+	PetID:   "p1",
+	Subject: "¡Feliz cumpleaños, Fido!",
+	Body:    "Fido turns 5 today.",
+	At:      time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC),
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Notification
+	var key string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Idempotency-Key")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(srv.URL, srv.Client())
+	if err := n.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if key != testNotification.ID || got.Subject != testNotification.Subject || !got.At.Equal(testNotification.At) {
+		t.Errorf("unexpected delivery %q %+v", key, got)
+	}
+
+	status = http.StatusBadGateway
+	if err := n.Notify(context.Background(), testNotification); err == nil {
+		t.Error("expected an error for a 502 response")
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+
+	n := NewEmailNotifier(addr, "bff@example.com", []string{"owner@example.com", "vet@example.com"})
+	if err := n.Notify(context.Background(), testNotification); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	select {
+	case msg := <-received:
+		for _, want := range []string{
+			"MAIL FROM:<bff@example.com>",
+			"RCPT TO:<owner@example.com>",
+			"RCPT TO:<vet@example.com>",
+			"Subject: =?utf-8?q?=C2=A1Feliz_cumplea=C3=B1os,_Fido!?=",
+			"Message-ID: <birthday.p1.2025@dog-app-bff>",
+			"Fido turns 5 today.",
+		} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("expected the session to contain %q, got:\n%s", want, msg)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the SMTP server received nothing")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{}); err != nil {
+		t.Errorf("expected the log notifier by default, got %v", err)
+	}
+	for _, cfg := range []Config{
+		{Kind: "webhook"},
+		{Kind: "email", SMTPAddr: "localhost:1025"},
+		{Kind: "sms"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v): expected an error", cfg)
+		}
+	}
+}
+
+// fakeSMTPServer atiende una única sesión SMTP y envía por el canal todo lo que recibió.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		var session strings.Builder
+		reply("220 localhost ESMTP")
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			session.WriteString(line)
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case inData:
+				if cmd == "." {
+					inData = false
+					reply("250 OK")
+				}
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				received <- session.String()
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
@@ -0,0 +1,323 @@
+// Package recurrence interpreta un subconjunto de las reglas de repetición de iCalendar
+// (RRULE, RFC 5545) suficiente para pautas de medicación: "FREQ=DAILY;BYHOUR=8,20",
+// "FREQ=HOURLY;INTERVAL=8", "FREQ=WEEKLY;BYDAY=MO,TH;BYHOUR=9" o "FREQ=MONTHLY;COUNT=6".
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule indica que la regla no se puede interpretar o usa algo no soportado.
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Frequency es la unidad en la que se repite la regla.
+type Frequency string
+
+const (
+	Hourly  Frequency = "HOURLY"
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// Rule es una regla ya interpretada. Las partes BY* vacías toman el valor del inicio
+// (dtstart): una regla diaria sin BYHOUR se repite a la hora de dtstart.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	ByHour   []int
+	ByMinute []int
+	// Count limita el número total de repeticiones desde dtstart; 0 es sin límite.
+	Count int
+	// Until es el último instante posible; cero es sin límite.
+	Until time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Parse interpreta una regla como "FREQ=DAILY;INTERVAL=2;BYHOUR=8". Admite el prefijo
+// "RRULE:" y las partes FREQ, INTERVAL, BYDAY (sin ordinales), BYHOUR, BYMINUTE, COUNT y
+// UNTIL (en UTC, "20240131T235959Z"). Cualquier otra parte es un error, para no ignorar en
+// silencio una pauta que el cliente cree haber indicado.
+func Parse(s string) (Rule, error) {
+	r := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return r, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || value == "" {
+			return r, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return r, fmt.Errorf("%w: %s appears twice", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			switch r.Freq {
+			case Hourly, Daily, Weekly, Monthly:
+			default:
+				err = fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parseInt(value, 1, 1000)
+		case "COUNT":
+			r.Count, err = parseInt(value, 1, 10000)
+		case "UNTIL":
+			r.Until, err = time.Parse("20060102T150405Z", value)
+		case "BYHOUR":
+			r.ByHour, err = parseList(value, 0, 23)
+		case "BYMINUTE":
+			r.ByMinute, err = parseList(value, 0, 59)
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := weekdays[strings.ToUpper(d)]
+				if !ok {
+					err = fmt.Errorf("unsupported BYDAY value %q", d)
+					break
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		default:
+			err = fmt.Errorf("unsupported part %s", name)
+		}
+		if err != nil {
+			return r, fmt.Errorf("%w: %w", ErrInvalidRule, err)
+		}
+	}
+
+	if r.Freq == "" {
+		return r, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return r, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+	slices.Sort(r.ByHour)
+	slices.Sort(r.ByMinute)
+	return r, nil
+}
+
+func parseInt(s string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%q is not a number between %d and %d", s, lo, hi)
+	}
+	return n, nil
+}
+
+func parseList(s string, lo, hi int) ([]int, error) {
+	var list []int
+	for _, v := range strings.Split(s, ",") {
+		n, err := parseInt(v, lo, hi)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(list, n) {
+			list = append(list, n)
+		}
+	}
+	return list, nil
+}
+
+// maxEmptyPeriods es cuántos periodos seguidos sin repeticiones recorre Between antes de
+// concluir que la regla ya no tiene más. Las reglas soportadas se repiten con un ciclo de
+// como mucho 168 periodos (una horaria, por las horas de la semana), así que una regla que
+// pasa más sin repeticiones no tiene ninguna, como "FREQ=HOURLY;INTERVAL=24;BYHOUR=3"
+// empezada a las 8.
+const maxEmptyPeriods = 1000
+
+// maxPeriods acota los periodos que recorre una llamada a Between. Con COUNT hay que contar
+// desde dtstart; el límite alcanza para COUNT=10000 de cualquier regla no horaria y de una
+// horaria con una sola hora al día, y corta las demás en lugar de tardar segundos.
+const maxPeriods = 500_000
+
+// Between devuelve, en orden, las repeticiones t con from <= t < to de la regla empezada
+// en dtstart, como mucho limit (0 es sin límite). Las horas se interpretan en la zona
+// horaria de dtstart, de modo que "BYHOUR=8" sigue siendo las 8 de la mañana después de
+// un cambio de horario. dtstart solo es una repetición si cumple la regla.
+func (r Rule) Between(dtstart, from, to time.Time, limit int) []time.Time {
+	var out []time.Time
+	seen, empty := 0, 0
+	period := 0
+	if r.Count == 0 {
+		// Sin COUNT no hace falta contar desde el principio: se salta a un periodo justo
+		// anterior a from.
+		period = r.periodsBefore(dtstart, from)
+	}
+	for last := period + maxPeriods; period < last; period++ {
+		start := r.periodStart(dtstart, period)
+		if !start.Before(to) || (!r.Until.IsZero() && start.After(r.Until)) {
+			return out
+		}
+		found := false
+		for _, t := range r.expand(dtstart, start) {
+			if t.Before(dtstart) {
+				continue
+			}
+			found = true
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return out
+			}
+			seen++
+			if r.Count > 0 && seen > r.Count {
+				return out
+			}
+			if !t.Before(to) {
+				return out
+			}
+			if !t.Before(from) {
+				out = append(out, t)
+				if limit > 0 && len(out) >= limit {
+					return out
+				}
+			}
+		}
+		if found {
+			empty = 0
+		} else if empty++; empty >= maxEmptyPeriods {
+			return out
+		}
+	}
+	return out
+}
+
+// Includes indica si t es una de las repeticiones de la regla.
+func (r Rule) Includes(dtstart, t time.Time) bool {
+	got := r.Between(dtstart, t, t.Add(time.Nanosecond), 1)
+	return len(got) == 1 && got[0].Equal(t)
+}
+
+// periodStart devuelve el inicio del periodo n (contando desde el de dtstart): la hora,
+// el día, el lunes de la semana o el día 1 del mes, en la zona horaria de dtstart.
+func (r Rule) periodStart(dtstart time.Time, n int) time.Time {
+	y, m, d := dtstart.Date()
+	loc := dtstart.Location()
+	step := n * r.Interval
+	switch r.Freq {
+	case Hourly:
+		return time.Date(y, m, d, dtstart.Hour(), 0, 0, 0, loc).Add(time.Duration(step) * time.Hour)
+	case Daily:
+		return time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+	case Weekly:
+		monday := d - (int(dtstart.Weekday())+6)%7
+		return time.Date(y, m, monday+7*step, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+	}
+}
+
+// periodsBefore devuelve un número de periodo cuyo inicio no es posterior a from.
+func (r Rule) periodsBefore(dtstart, from time.Time) int {
+	if !from.After(dtstart) {
+		return 0
+	}
+	var units int
+	switch r.Freq {
+	case Hourly:
+		units = int(from.Sub(dtstart) / time.Hour)
+	case Daily:
+		units = daysBetween(dtstart, from.In(dtstart.Location()))
+	case Weekly:
+		units = daysBetween(dtstart, from.In(dtstart.Location())) / 7
+	default:
+		f := from.In(dtstart.Location())
+		units = (f.Year()-dtstart.Year())*12 + int(f.Month()-dtstart.Month())
+	}
+	// Un periodo de margen cubre los cambios de horario y el redondeo.
+	return max(units/r.Interval-1, 0)
+}
+
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da) / (24 * time.Hour))
+}
+
+// expand devuelve, en orden, los candidatos del periodo que empieza en start.
+func (r Rule) expand(dtstart, start time.Time) []time.Time {
+	loc := dtstart.Location()
+	minutes := r.ByMinute
+	if len(minutes) == 0 {
+		minutes = []int{dtstart.Minute()}
+	}
+
+	if r.Freq == Hourly {
+		var out []time.Time
+		if !r.matchesDay(dtstart, start) || (len(r.ByHour) > 0 && !slices.Contains(r.ByHour, start.Hour())) {
+			return nil
+		}
+		for _, minute := range minutes {
+			out = append(out, start.Add(time.Duration(minute)*time.Minute+time.Duration(dtstart.Second())*time.Second))
+		}
+		return out
+	}
+
+	hours := r.ByHour
+	if len(hours) == 0 {
+		hours = []int{dtstart.Hour()}
+	}
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{start}
+	case Weekly:
+		for i := range 7 {
+			days = append(days, start.AddDate(0, 0, i))
+		}
+	default:
+		if len(r.ByDay) == 0 {
+			// Un mes sin el día de dtstart (p. ej. el 31) no tiene repetición, como en RFC 5545.
+			day := time.Date(start.Year(), start.Month(), dtstart.Day(), 0, 0, 0, 0, loc)
+			if day.Month() == start.Month() {
+				days = []time.Time{day}
+			}
+		} else {
+			for day := start; day.Month() == start.Month(); day = day.AddDate(0, 0, 1) {
+				days = append(days, day)
+			}
+		}
+	}
+
+	var out []time.Time
+	for _, day := range days {
+		if !r.matchesDay(dtstart, day) {
+			continue
+		}
+		for _, h := range hours {
+			for _, minute := range minutes {
+				out = append(out, time.Date(day.Year(), day.Month(), day.Day(), h, minute, dtstart.Second(), 0, loc))
+			}
+		}
+	}
+	return out
+}
+
+// matchesDay aplica BYDAY. En una regla semanal sin BYDAY solo vale el día de la semana de dtstart.
+func (r Rule) matchesDay(dtstart, day time.Time) bool {
+	if len(r.ByDay) > 0 {
+		return slices.Contains(r.ByDay, day.Weekday())
+	}
+	if r.Freq == Weekly {
+		return day.Weekday() == dtstart.Weekday()
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package recurrence
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func at(y int, m time.Month, d, h, minute int) time.Time {
+	return time.Date(y, m, d, h, minute, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse("RRULE:FREQ=weekly;INTERVAL=2;BYDAY=MO,th;BYHOUR=20,8;BYMINUTE=30;COUNT=10")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if r.Freq != Weekly || r.Interval != 2 || r.Count != 10 ||
+		!slices.Equal(r.ByDay, []time.Weekday{time.Monday, time.Thursday}) ||
+		!slices.Equal(r.ByHour, []int{8, 20}) || !slices.Equal(r.ByMinute, []int{30}) {
+		t.Errorf("unexpected rule %+v", r)
+	}
+
+	for _, s := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=DAILY;BYDAY=1MO",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240101T000000Z",
+		"FREQ=DAILY;UNTIL=2024-01-01",
+		"FREQ",
+	} {
+		if _, err := Parse(s); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q): expected ErrInvalidRule, got %v", s, err)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	start := at(2024, time.January, 1, 8, 0) // lunes
+	tests := []struct {
+		name     string
+		rule     string
+		dtstart  time.Time // cero es start
+		from, to time.Time
+		want     []time.Time
+	}{
+		{
+			"daily at the start time", "FREQ=DAILY", time.Time{},
+			start, at(2024, time.January, 4, 0, 0),
+			[]time.Time{at(2024, time.January, 1, 8, 0), at(2024, time.January, 2, 8, 0), at(2024, time.January, 3, 8, 0)},
+		},
+		{
+			"twice a day, from a later date", "FREQ=DAILY;BYHOUR=8,20", time.Time{},
+			at(2024, time.March, 10, 12, 0), at(2024, time.March, 11, 12, 0),
+			[]time.Time{at(2024, time.March, 10, 20, 0), at(2024, time.March, 11, 8, 0)},
+		},
+		{
+			"every 8 hours", "FREQ=HOURLY;INTERVAL=8", time.Time{},
+			start, at(2024, time.January, 2, 9, 0),
+			[]time.Time{at(2024, time.January, 1, 8, 0), at(2024, time.January, 1, 16, 0), at(2024, time.January, 2, 0, 0), at(2024, time.January, 2, 8, 0)},
+		},
+		{
+			"weekly on some days", "FREQ=WEEKLY;BYDAY=MO,TH", time.Time{},
+			start, at(2024, time.January, 12, 0, 0),
+			[]time.Time{at(2024, time.January, 1, 8, 0), at(2024, time.January, 4, 8, 0), at(2024, time.January, 8, 8, 0), at(2024, time.January, 11, 8, 0)},
+		},
+		{
+			"every other week", "FREQ=WEEKLY;INTERVAL=2", time.Time{},
+			start, at(2024, time.February, 1, 0, 0),
+			[]time.Time{at(2024, time.January, 1, 8, 0), at(2024, time.January, 15, 8, 0), at(2024, time.January, 29, 8, 0)},
+		},
+		{
+			"monthly skips short months", "FREQ=MONTHLY", at(2024, time.January, 31, 9, 0),
+			at(2024, time.January, 31, 9, 0), at(2024, time.June, 1, 0, 0),
+			[]time.Time{at(2024, time.January, 31, 9, 0), at(2024, time.March, 31, 9, 0), at(2024, time.May, 31, 9, 0)},
+		},
+		{
+			"count is counted from the start", "FREQ=DAILY;COUNT=3", time.Time{},
+			at(2024, time.January, 2, 0, 0), at(2024, time.February, 1, 0, 0),
+			[]time.Time{at(2024, time.January, 2, 8, 0), at(2024, time.January, 3, 8, 0)},
+		},
+		{
+			"until is inclusive", "FREQ=DAILY;UNTIL=20240103T080000Z", time.Time{},
+			start, at(2024, time.February, 1, 0, 0),
+			[]time.Time{at(2024, time.January, 1, 8, 0), at(2024, time.January, 2, 8, 0), at(2024, time.January, 3, 8, 0)},
+		},
+		{
+			"earlier hours on the first day are skipped", "FREQ=DAILY;BYHOUR=6,12", time.Time{},
+			start, at(2024, time.January, 2, 7, 0),
+			[]time.Time{at(2024, time.January, 1, 12, 0), at(2024, time.January, 2, 6, 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			dtstart := tt.dtstart
+			if dtstart.IsZero() {
+				dtstart = start
+			}
+			got := r.Between(dtstart, tt.from, tt.to, 0)
+			if !slices.EqualFunc(got, tt.want, time.Time.Equal) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBetweenLimit(t *testing.T) {
+	r, _ := Parse("FREQ=HOURLY")
+	got := r.Between(at(2024, time.January, 1, 0, 0), at(2024, time.January, 1, 0, 0), at(2025, time.January, 1, 0, 0), 5)
+	if len(got) != 5 {
+		t.Errorf("expected 5 occurrences, got %d", len(got))
+	}
+}
+
+// Las horas se mantienen en la hora local al cambiar el horario de verano.
+func TestBetweenKeepsLocalTimeAcrossDST(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	r, _ := Parse("FREQ=DAILY;BYHOUR=8")
+	dtstart := time.Date(2024, time.March, 30, 8, 0, 0, 0, madrid)
+	got := r.Between(dtstart, dtstart, dtstart.AddDate(0, 0, 2), 0)
+	if len(got) != 2 || got[1].Hour() != 8 || got[1].Sub(got[0]) != 23*time.Hour {
+		t.Errorf("expected 8:00 on both days, got %v", got)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	r, _ := Parse("FREQ=DAILY;BYHOUR=8,20")
+	start := at(2024, time.January, 1, 8, 0)
+	if !r.Includes(start, at(2024, time.January, 5, 20, 0)) {
+		t.Error("expected 20:00 to be a dose")
+	}
+	if r.Includes(start, at(2024, time.January, 5, 21, 0)) {
+		t.Error("expected 21:00 not to be a dose")
+	}
+	if r.Includes(start, at(2023, time.December, 31, 20, 0)) {
+		t.Error("expected no dose before the start")
+	}
+}
+
+func TestBetweenWithoutOccurrences(t *testing.T) {
+	// Empezada a las 8 y cada 24 horas, la regla nunca cae a las 3: Between no debe
+	// recorrer los periodos hasta to.
+	r, err := Parse("FREQ=HOURLY;INTERVAL=24;BYHOUR=3;COUNT=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := at(2024, time.January, 1, 8, 0)
+	begin := time.Now()
+	if got := r.Between(start, at(2400, time.January, 1, 0, 0), at(2400, time.January, 2, 0, 0), 0); len(got) != 0 {
+		t.Errorf("expected no occurrences, got %v", got)
+	}
+	if r.Includes(start, at(2400, time.January, 1, 3, 0)) {
+		t.Error("expected 3:00 not to be an occurrence")
+	}
+	if elapsed := time.Since(begin); elapsed > 100*time.Millisecond {
+		t.Errorf("Between took %v", elapsed)
+	}
+
+	// Una regla con huecos largos pero con repeticiones sigue encontrándolas.
+	r, _ = Parse("FREQ=HOURLY;INTERVAL=5;BYDAY=SU;BYHOUR=3;COUNT=3")
+	if got := r.Between(start, start, at(2025, time.January, 1, 0, 0), 0); len(got) != 3 {
+		t.Errorf("expected 3 occurrences, got %v", got)
+	}
+}
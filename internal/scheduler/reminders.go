@@ -0,0 +1,294 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/notify"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Tipos de trabajo de los recordatorios.
+const (
+	KindBirthday = "birthday"
+	KindDose     = "medication-dose"
+	KindVaccine  = "vaccine-due"
+)
+
+const (
+	// reminderHour es la hora (UTC) de los avisos de un día, el cumpleaños o el vencimiento
+	// de una vacuna: las mascotas no guardan la zona horaria de su dueño.
+	reminderHour = 9
+	// maxDosesPerMedication acota las tomas que se planifican de una pauta en cada vuelta.
+	maxDosesPerMedication = 100
+)
+
+// ReminderConfig ajusta los recordatorios.
+type ReminderConfig struct {
+	// Lookahead es hasta dónde se planifica en cada vuelta. Debe ser mayor que
+	// Config.PlanInterval para no dejar huecos.
+	Lookahead time.Duration
+	// DoseLead es con cuánta antelación se avisa de una toma.
+	DoseLead time.Duration
+}
+
+// DefaultReminderConfig devuelve la configuración por defecto de los recordatorios.
+func DefaultReminderConfig() ReminderConfig {
+	return ReminderConfig{Lookahead: 24 * time.Hour, DoseLead: 15 * time.Minute}
+}
+
+// Reminders planifica y envía los recordatorios de los dueños: el cumpleaños de cada
+// mascota (a partir de Pet.Birth), cada toma de medicación pendiente y el vencimiento de
+// cada vacuna (a partir de VetVisit.NextDue).
+type Reminders struct {
+	pets     store.PetStore
+	medical  store.MedicalStore
+	meds     store.MedicationStore
+	notifier notify.Notifier
+	cfg      ReminderConfig
+}
+
+func NewReminders(ps store.PetStore, mds store.MedicalStore, ms store.MedicationStore, n notify.Notifier, cfg ReminderConfig) *Reminders {
+	return &Reminders{pets: ps, medical: mds, meds: ms, notifier: n, cfg: cfg}
+}
+
+// birthdayPayload, dosePayload y vaccinePayload son los Payload de KindBirthday, KindDose
+// y KindVaccine.
+type birthdayPayload struct {
+	PetID types.PetID `json:"petId"`
+	Year  int         `json:"year"`
+}
+
+type dosePayload struct {
+	PetID        types.PetID `json:"petId"`
+	MedicationID string      `json:"medicationId"`
+	ScheduledAt  time.Time   `json:"scheduledAt"`
+}
+
+type vaccinePayload struct {
+	PetID   types.PetID `json:"petId"`
+	VisitID string      `json:"visitId"`
+}
+
+// Register añade a s los Handler y el planificador de los recordatorios.
+func (r *Reminders) Register(s *Scheduler) {
+	s.Handle(KindBirthday, r.sendBirthday)
+	s.Handle(KindDose, r.sendDose)
+	s.Handle(KindVaccine, r.sendVaccine)
+	s.AddPlanner(func(ctx context.Context, now time.Time) error {
+		return r.Plan(ctx, s, now)
+	})
+}
+
+// Plan encola los cumpleaños, las tomas y los vencimientos de vacunas de las próximas
+// Lookahead horas. Las claves de los trabajos identifican el aviso, así que planificar
+// varias veces no lo duplica.
+func (r *Reminders) Plan(ctx context.Context, s *Scheduler, now time.Time) error {
+	pets, err := r.pets.GetPets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pets: %w", err)
+	}
+	for _, p := range pets {
+		if at, ok := nextBirthday(p.Birth, now); ok && at.Sub(now) <= r.cfg.Lookahead {
+			key := fmt.Sprintf("%s:%s:%d", KindBirthday, p.ID, at.Year())
+			if _, err := s.Enqueue(ctx, KindBirthday, key, at, birthdayPayload{PetID: p.ID, Year: at.Year()}); err != nil {
+				return err
+			}
+		}
+
+		visits, err := r.medical.GetVetVisits(ctx, p.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			// La mascota se borró mientras se planificaba.
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list vet visits of pet %s: %w", p.ID, err)
+		}
+		for _, v := range visits {
+			if at, ok := vaccineDue(v, now); ok && at.Sub(now) <= r.cfg.Lookahead {
+				key := fmt.Sprintf("%s:%s", KindVaccine, v.ID)
+				if _, err := s.Enqueue(ctx, KindVaccine, key, at, vaccinePayload{PetID: p.ID, VisitID: v.ID}); err != nil {
+					return err
+				}
+			}
+		}
+
+		meds, err := r.meds.GetMedications(ctx, p.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			// La mascota se borró mientras se planificaba.
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list medications of pet %s: %w", p.ID, err)
+		}
+		for _, m := range meds {
+			doses, err := m.DosesBetween(now, now.Add(r.cfg.Lookahead), maxDosesPerMedication)
+			if err != nil {
+				return fmt.Errorf("medication %s: %w", m.ID, err)
+			}
+			for _, at := range doses {
+				key := fmt.Sprintf("%s:%s:%d", KindDose, m.ID, at.Unix())
+				payload := dosePayload{PetID: p.ID, MedicationID: m.ID, ScheduledAt: at.UTC()}
+				if _, err := s.Enqueue(ctx, KindDose, key, at.Add(-r.cfg.DoseLead), payload); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// nextBirthday devuelve el momento del aviso del próximo cumpleaños a partir del día de
+// now (incluido). Los nacidos un 29 de febrero lo celebran el 28 en los años no bisiestos.
+func nextBirthday(birth, now time.Time) (time.Time, bool) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for year := now.Year(); year <= now.Year()+1; year++ {
+		if year <= birth.Year() {
+			continue
+		}
+		day := birth.Day()
+		if birth.Month() == time.February && day == 29 && !isLeap(year) {
+			day = 28
+		}
+		d := time.Date(year, birth.Month(), day, 0, 0, 0, 0, time.UTC)
+		if !d.Before(today) {
+			return d.Add(reminderHour * time.Hour), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// vaccineDue devuelve el momento del aviso del vencimiento de la vacuna de v, si vence a
+// partir del día de now (incluido).
+func vaccineDue(v types.VetVisit, now time.Time) (time.Time, bool) {
+	if v.NextDue == nil {
+		return time.Time{}, false
+	}
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v.NextDue.Before(today) {
+		return time.Time{}, false
+	}
+	return v.NextDue.Add(reminderHour * time.Hour), true
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func (r *Reminders) sendBirthday(ctx context.Context, job types.Job) error {
+	var p birthdayPayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+	pet, err := r.pets.GetPetByID(ctx, p.PetID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Si se corrigió la fecha de nacimiento después de planificar, el aviso ya no vale.
+	if at, ok := nextBirthday(pet.Birth, job.RunAt); !ok || !at.Equal(job.RunAt) {
+		return nil
+	}
+
+	age := p.Year - pet.Birth.Year()
+	return r.notifier.Notify(ctx, notify.Notification{
+		ID:      job.Key,
+		Kind:    KindBirthday,
+		PetID:   pet.ID,
+		Subject: fmt.Sprintf("Happy birthday, %s!", pet.Name),
+		Body:    fmt.Sprintf("%s turns %d today.", pet.Name, age),
+		At:      job.RunAt,
+	})
+}
+
+func (r *Reminders) sendDose(ctx context.Context, job types.Job) error {
+	var p dosePayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+	med, err := r.meds.GetMedication(ctx, p.PetID, p.MedicationID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// No se avisa de las tomas que ya se anotaron (dadas o saltadas).
+	records, err := r.meds.GetDoseRecords(ctx, p.PetID, p.ScheduledAt, p.ScheduledAt.Add(time.Nanosecond))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, d := range records {
+		if d.MedicationID == med.ID {
+			return nil
+		}
+	}
+	pet, err := r.pets.GetPetByID(ctx, p.PetID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, loc, err := med.Schedule()
+	if err != nil {
+		return Permanent(err)
+	}
+	return r.notifier.Notify(ctx, notify.Notification{
+		ID:      job.Key,
+		Kind:    KindDose,
+		PetID:   pet.ID,
+		Subject: fmt.Sprintf("%s: %s due at %s", pet.Name, med.Drug, p.ScheduledAt.In(loc).Format("15:04")),
+		Body:    fmt.Sprintf("Time to give %s %s of %s.", pet.Name, med.Dosage, med.Drug),
+		At:      p.ScheduledAt,
+	})
+}
+
+func (r *Reminders) sendVaccine(ctx context.Context, job types.Job) error {
+	var p vaccinePayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+	pet, err := r.pets.GetPetByID(ctx, p.PetID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	visits, err := r.medical.GetVetVisits(ctx, p.PetID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(visits, func(v types.VetVisit) bool { return v.ID == p.VisitID })
+	if idx < 0 || visits[idx].NextDue == nil {
+		return nil
+	}
+	v := visits[idx]
+
+	return r.notifier.Notify(ctx, notify.Notification{
+		ID:      job.Key,
+		Kind:    KindVaccine,
+		PetID:   pet.ID,
+		Subject: fmt.Sprintf("%s: vaccine due today", pet.Name),
+		Body: fmt.Sprintf("%s is due for the next dose of the vaccine given at %s on %s (%s).",
+			pet.Name, v.Clinic, v.Date.Format(time.DateOnly), v.Reason),
+		At: job.RunAt,
+	})
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/notify"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+type recordingNotifier struct {
+	sent []notify.Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n notify.Notification) error {
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func TestReminders(t *testing.T) {
+	ctx := context.Background()
+	breeds := []types.Breed{{ID: "b1", Name: "Breed1"}}
+	fido := types.Pet{ID: "11111111-1111-4111-8111-111111111111", Name: "Fido", Birth: time.Date(2020, time.March, 3, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}
+	rex := types.Pet{ID: "22222222-2222-4222-8222-222222222222", Name: "Rex", Birth: time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}
+	ms := store.NewMemoryStore(breeds, fido, rex)
+
+	carprofen, err := ms.AddMedication(ctx, types.Medication{PetID: fido.ID, Drug: "Carprofen", Dosage: "25 mg", Frequency: "FREQ=DAILY;BYHOUR=8", Start: time.Date(2025, time.March, 1, 8, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("AddMedication failed: %v", err)
+	}
+	drops, err := ms.AddMedication(ctx, types.Medication{PetID: rex.ID, Drug: "Drops", Dosage: "2 drops", Frequency: "FREQ=DAILY;BYHOUR=20", Start: time.Date(2025, time.March, 1, 20, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("AddMedication failed: %v", err)
+	}
+	// La vacuna de Rex vence hoy; la de Fido, dentro de un año.
+	due, later := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC), time.Date(2026, time.March, 3, 0, 0, 0, 0, time.UTC)
+	vaccine, err := ms.AddVetVisit(ctx, types.VetVisit{PetID: rex.ID, Date: time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC), Clinic: "Vet", Reason: "Rabies", NextDue: &due})
+	if err != nil {
+		t.Fatalf("AddVetVisit failed: %v", err)
+	}
+	if _, err := ms.AddVetVisit(ctx, types.VetVisit{PetID: fido.ID, Date: due, Clinic: "Vet", Reason: "Rabies", NextDue: &later}); err != nil {
+		t.Fatalf("AddVetVisit failed: %v", err)
+	}
+	// La toma de esta noche ya está anotada: no se avisa.
+	if _, err := ms.RecordDose(ctx, types.DoseRecord{MedicationID: drops.ID, ScheduledAt: time.Date(2025, time.March, 2, 20, 0, 0, 0, time.UTC), Status: types.DoseGiven}); err != nil {
+		t.Fatalf("RecordDose failed: %v", err)
+	}
+
+	s, _, now := newTestScheduler()
+	s.jobs = ms
+	notifier := &recordingNotifier{}
+	NewReminders(ms, ms, ms, notifier, DefaultReminderConfig()).Register(s)
+
+	// Planificar dos veces no duplica los avisos.
+	for range 2 {
+		if err := s.Plan(ctx); err != nil {
+			t.Fatalf("Plan failed: %v", err)
+		}
+	}
+	*now = time.Date(2025, time.March, 3, 10, 0, 0, 0, time.UTC)
+	runOnce(t, s)
+
+	var got []string
+	for _, n := range notifier.sent {
+		got = append(got, n.ID+" "+n.Subject)
+	}
+	// El cumpleaños y la vacuna se avisan a la misma hora, en cualquier orden.
+	if len(got) > 1 {
+		slices.Sort(got[1:])
+	}
+	want := []string{
+		"medication-dose:" + carprofen.ID + ":1740988800 Fido: Carprofen due at 08:00",
+		"birthday:" + string(fido.ID) + ":2025 Happy birthday, Fido!",
+		"vaccine-due:" + vaccine.ID + " Rex: vaccine due today",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected notifications\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	for _, n := range notifier.sent {
+		if n.Kind == KindBirthday && n.Body != "Fido turns 5 today." {
+			t.Errorf("unexpected birthday body %q", n.Body)
+		}
+	}
+}
+
+func TestNextBirthday(t *testing.T) {
+	leap := time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		birth, now  time.Time
+		want        time.Time
+		wantNothing bool
+	}{
+		{"later this year", leap, time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), false},
+		{"on a common year", leap, time.Date(2025, time.February, 27, 0, 0, 0, 0, time.UTC), time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC), false},
+		{"today, after the hour", leap, time.Date(2024, time.February, 29, 18, 0, 0, 0, time.UTC), time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), false},
+		{"next year", leap, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC), false},
+		{"not the birth day itself", time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.May, 1, 9, 0, 0, 0, time.UTC), false},
+		{"born in the future", time.Date(2030, time.May, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC), time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := nextBirthday(tt.birth, tt.now)
+			if ok == tt.wantNothing || (ok && !got.Equal(tt.want)) {
+				t.Errorf("expected %s (%v), got %s (%v)", tt.want, !tt.wantNothing, got, ok)
+			}
+		})
+	}
+}
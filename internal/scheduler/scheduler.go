@@ -0,0 +1,221 @@
+// Package scheduler ejecuta en segundo plano, dentro del propio BFF, los trabajos guardados
+// en un store.JobStore. La entrega es "al menos una vez": un trabajo se reserva durante
+// Config.Lease y, si el proceso muere antes de cerrarlo, otro (o el mismo al reiniciar) lo
+// vuelve a ejecutar. Los errores se reintentan con espera exponencial hasta MaxAttempts.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Config ajusta el planificador.
+type Config struct {
+	Enabled bool
+	// PollInterval es cada cuánto se buscan trabajos vencidos.
+	PollInterval time.Duration
+	// PlanInterval es cada cuánto se ejecutan los planificadores (ver AddPlanner).
+	PlanInterval time.Duration
+	// Lease es cuánto tiempo queda reservado un trabajo en curso; también es el plazo
+	// máximo de cada intento.
+	Lease       time.Duration
+	BatchSize   int
+	MaxAttempts int
+	// MinBackoff es la espera antes del primer reintento; se duplica en cada intento
+	// hasta MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultConfig devuelve una configuración razonable para producción.
+func DefaultConfig() Config {
+	return Config{
+		Enabled:      true,
+		PollInterval: 5 * time.Second,
+		PlanInterval: 15 * time.Minute,
+		Lease:        time.Minute,
+		BatchSize:    20,
+		MaxAttempts:  5,
+		MinBackoff:   30 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+// Handler ejecuta un trabajo de un tipo. Si devuelve un error el trabajo se reintenta,
+// salvo que el error sea Permanent.
+type Handler func(ctx context.Context, job types.Job) error
+
+// Planner encola los trabajos que tocan a partir de now, con Enqueue. Como Enqueue ignora
+// las claves repetidas, puede volver a encolar trabajos que ya existen.
+type Planner func(ctx context.Context, now time.Time) error
+
+// ErrPermanent marca los errores que no merece la pena reintentar.
+var ErrPermanent = errors.New("permanent error")
+
+// Permanent envuelve err para que el trabajo falle sin más reintentos.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
+
+// Scheduler reparte los trabajos vencidos entre sus Handler según Job.Kind.
+type Scheduler struct {
+	jobs     store.JobStore
+	cfg      Config
+	handlers map[string]Handler
+	planners []Planner
+	now      func() time.Time
+}
+
+func New(js store.JobStore, cfg Config) *Scheduler {
+	return &Scheduler{
+		jobs:     js,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+		now:      time.Now,
+	}
+}
+
+// Handle registra el Handler de los trabajos de tipo kind. No es seguro llamarlo con el
+// planificador en marcha.
+func (s *Scheduler) Handle(kind string, h Handler) {
+	s.handlers[kind] = h
+}
+
+// AddPlanner registra un planificador. No es seguro llamarlo con el planificador en marcha.
+func (s *Scheduler) AddPlanner(p Planner) {
+	s.planners = append(s.planners, p)
+}
+
+// Enqueue guarda un trabajo de tipo kind para runAt, con payload codificado en JSON.
+// Devuelve false si ya existía un trabajo con la misma clave.
+func (s *Scheduler) Enqueue(ctx context.Context, kind, key string, runAt time.Time, payload any) (bool, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal payload of job %s: %w", key, err)
+	}
+	return s.jobs.EnqueueJob(ctx, types.Job{
+		Kind:        kind,
+		Key:         key,
+		Payload:     data,
+		RunAt:       runAt,
+		MaxAttempts: s.cfg.MaxAttempts,
+	})
+}
+
+// Run ejecuta los planificadores cada PlanInterval y los trabajos vencidos cada
+// PollInterval hasta que se cancela ctx.
+func (s *Scheduler) Run(ctx context.Context) {
+	poll := time.NewTicker(s.cfg.PollInterval)
+	defer poll.Stop()
+	plan := time.NewTicker(s.cfg.PlanInterval)
+	defer plan.Stop()
+
+	log.Printf("Planificador iniciado (cada %s)", s.cfg.PollInterval)
+	s.logPlan(ctx)
+	s.drain(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-plan.C:
+			s.logPlan(ctx)
+		case <-poll.C:
+			s.drain(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) logPlan(ctx context.Context) {
+	if err := s.Plan(ctx); err != nil {
+		log.Printf("Error al planificar los avisos: %v", err)
+	}
+}
+
+// drain ejecuta lotes mientras vengan llenos, para no esperar a la siguiente vuelta cuando
+// se acumulan trabajos.
+func (s *Scheduler) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := s.RunOnce(ctx)
+		if err != nil {
+			log.Printf("Error al ejecutar los trabajos pendientes: %v", err)
+			return
+		}
+		if n < s.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// Plan ejecuta todos los planificadores, aunque alguno falle, y devuelve sus errores juntos.
+func (s *Scheduler) Plan(ctx context.Context) error {
+	var errs []error
+	for _, p := range s.planners {
+		errs = append(errs, p(ctx, s.now()))
+	}
+	return errors.Join(errs...)
+}
+
+// RunOnce reclama un lote de trabajos vencidos, los ejecuta uno a uno y devuelve cuántos
+// reclamó.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	jobs, err := s.jobs.ClaimJobs(ctx, s.now(), s.cfg.Lease, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, job := range jobs {
+		if err := s.run(ctx, job); err != nil {
+			return len(jobs), err
+		}
+	}
+	return len(jobs), nil
+}
+
+// run ejecuta un intento y lo cierra en el store. Solo devuelve los errores del store: los
+// del Handler se guardan en el trabajo.
+func (s *Scheduler) run(ctx context.Context, job types.Job) error {
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		// El proceso murió en el último intento permitido: no se vuelve a intentar.
+		err = Permanent(errors.New("attempts exhausted"))
+	} else if h, ok := s.handlers[job.Kind]; !ok {
+		err = Permanent(fmt.Errorf("no handler for jobs of kind %q", job.Kind))
+	} else {
+		attemptCtx, cancel := context.WithTimeout(ctx, s.cfg.Lease)
+		err = h(attemptCtx, job)
+		cancel()
+	}
+
+	switch {
+	case err == nil:
+		return s.jobs.CompleteJob(ctx, job.ID)
+	case ctx.Err() != nil:
+		// Se está cerrando el proceso: el trabajo queda reservado y se reintentará cuando
+		// caduque la reserva.
+		return ctx.Err()
+	case errors.Is(err, ErrPermanent) || job.Attempts >= job.MaxAttempts:
+		log.Printf("El trabajo %s falló definitivamente tras %d intentos: %v", job.Key, job.Attempts, err)
+		return s.jobs.FailJob(ctx, job.ID, err.Error())
+	default:
+		retryAt := s.now().Add(s.backoff(job.Attempts))
+		log.Printf("El trabajo %s falló (intento %d de %d), se reintentará a las %s: %v",
+			job.Key, job.Attempts, job.MaxAttempts, retryAt.Format(time.RFC3339), err)
+		return s.jobs.RetryJob(ctx, job.ID, retryAt, err.Error())
+	}
+}
+
+// backoff es la espera antes del siguiente intento: MinBackoff, el doble, el doble... hasta
+// MaxBackoff.
+func (s *Scheduler) backoff(attempts int) time.Duration {
+	d := s.cfg.MinBackoff
+	for i := 1; i < attempts && d < s.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.cfg.MaxBackoff)
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+var t0 = time.Date(2025, time.March, 2, 12, 0, 0, 0, time.UTC)
+
+// newTestScheduler devuelve un planificador sobre un MemoryStore con un reloj que el test
+// controla.
+func newTestScheduler() (*Scheduler, *store.MemoryStore, *time.Time) {
+	ms := store.NewMemoryStore(nil)
+	cfg := DefaultConfig()
+	cfg.MaxAttempts = 3
+	s := New(ms, cfg)
+	now := t0
+	s.now = func() time.Time { return now }
+	return s, ms, &now
+}
+
+func runOnce(t *testing.T, s *Scheduler) int {
+	t.Helper()
+	n, err := s.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	return n
+}
+
+func TestRetriesWithBackoff(t *testing.T) {
+	s, _, now := newTestScheduler()
+	var attempts []time.Time
+	s.Handle("flaky", func(ctx context.Context, job types.Job) error {
+		attempts = append(attempts, *now)
+		if len(attempts) < 3 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if _, err := s.Enqueue(context.Background(), "flaky", "flaky:1", t0, nil); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	runOnce(t, s)
+	// El primer reintento espera MinBackoff y el segundo, el doble.
+	*now = t0.Add(29 * time.Second)
+	if n := runOnce(t, s); n != 0 {
+		t.Errorf("expected no job before the backoff, ran %d", n)
+	}
+	*now = t0.Add(30 * time.Second)
+	runOnce(t, s)
+	*now = t0.Add(90 * time.Second)
+	runOnce(t, s)
+	*now = t0.Add(24 * time.Hour)
+	runOnce(t, s)
+
+	want := []time.Time{t0, t0.Add(30 * time.Second), t0.Add(90 * time.Second)}
+	if len(attempts) != len(want) {
+		t.Fatalf("expected %d attempts, got %v", len(want), attempts)
+	}
+	for i := range want {
+		if !attempts[i].Equal(want[i]) {
+			t.Errorf("attempt %d: expected %s, got %s", i+1, want[i], attempts[i])
+		}
+	}
+}
+
+func TestGivesUp(t *testing.T) {
+	s, _, now := newTestScheduler()
+	calls := map[string]int{}
+	s.Handle("failing", func(ctx context.Context, job types.Job) error {
+		calls[job.Key]++
+		if job.Key == "failing:permanent" {
+			return Permanent(errors.New("bad payload"))
+		}
+		return errors.New("boom")
+	})
+	ctx := context.Background()
+	s.Enqueue(ctx, "failing", "failing:transient", t0, nil)
+	s.Enqueue(ctx, "failing", "failing:permanent", t0, nil)
+	s.Enqueue(ctx, "unknown", "unknown:1", t0, nil)
+
+	for i := 0; i < 10; i++ {
+		runOnce(t, s)
+		*now = now.Add(2 * time.Hour)
+	}
+	if calls["failing:transient"] != 3 || calls["failing:permanent"] != 1 {
+		t.Errorf("expected 3 attempts of the transient failure and 1 of the permanent one, got %v", calls)
+	}
+}
+
+// Un trabajo reclamado por un proceso que muere se vuelve a entregar al caducar la reserva.
+func TestRedeliversAfterLease(t *testing.T) {
+	s, ms, now := newTestScheduler()
+	runs := 0
+	s.Handle("job", func(ctx context.Context, job types.Job) error {
+		runs++
+		return nil
+	})
+	ctx := context.Background()
+	s.Enqueue(ctx, "job", "job:1", t0, nil)
+	if jobs, err := ms.ClaimJobs(ctx, t0, s.cfg.Lease, 10); err != nil || len(jobs) != 1 {
+		t.Fatalf("expected to claim the job, got %v (%v)", jobs, err)
+	}
+
+	if n := runOnce(t, s); n != 0 || runs != 0 {
+		t.Errorf("expected the claimed job not to run again before the lease expires")
+	}
+	*now = t0.Add(s.cfg.Lease)
+	runOnce(t, s)
+	*now = t0.Add(time.Hour)
+	runOnce(t, s)
+	if runs != 1 {
+		t.Errorf("expected the job to run once after the lease expired, ran %d times", runs)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := New(nil, Config{MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 60: 10 * time.Second} {
+		if got := s.backoff(attempts); got != want {
+			t.Errorf("backoff(%d): expected %s, got %s", attempts, want, got)
+		}
+	}
+}
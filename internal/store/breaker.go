@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errCircuitOpen se devuelve sin contactar con la base de datos mientras el circuito está abierto.
+var errCircuitOpen = fmt.Errorf("%w: circuit breaker open", ErrUnavailable)
+
+// BreakerConfig configura el circuit breaker de las conexiones a la base de datos.
+type BreakerConfig struct {
+	// FailureThreshold es el número de fallos de conexión consecutivos que abren el circuito.
+	// Cero lo desactiva.
+	FailureThreshold int
+	// OpenTimeout es el tiempo que el circuito permanece abierto antes de dejar pasar
+	// un único intento de prueba (estado semiabierto).
+	OpenTimeout time.Duration
+}
+
+// circuitBreaker evita que, con la base de datos caída, cada solicitud espere a que venza
+// el intento de conexión: tras FailureThreshold fallos seguidos responde errCircuitOpen
+// al instante hasta que un intento de prueba vuelve a conectar.
+type circuitBreaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(cfg BreakerConfig) *circuitBreaker {
+	return &circuitBreaker{cfg: cfg, now: time.Now}
+}
+
+// allow indica si se puede intentar una conexión. Con el circuito abierto solo deja pasar
+// un intento de prueba cuando ha transcurrido OpenTimeout.
+func (b *circuitBreaker) allow() error {
+	if b.cfg.FailureThreshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.cfg.FailureThreshold {
+		return nil
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+		return errCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// record registra el resultado de un intento permitido por allow. Las cancelaciones del
+// llamador no dicen nada sobre la salud de la base de datos y no cuentan como fallo.
+func (b *circuitBreaker) record(err error) {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	switch {
+	case err == nil:
+		b.failures = 0
+	case errors.Is(err, context.Canceled):
+	default:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.openedAt = b.now()
+		}
+	}
+}
+
+// breakerConnector envuelve el connector del driver para que database/sql abra las
+// conexiones nuevas a través del circuit breaker.
+type breakerConnector struct {
+	driver.Connector
+	breaker *circuitBreaker
+}
+
+func (c *breakerConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	conn, err := c.Connector.Connect(ctx)
+	c.breaker.record(err)
+	return conn, err
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeConnector simula un servidor de base de datos que puede estar caído.
+type fakeConnector struct {
+	down     bool
+	failures int // si es mayor que cero, falla esa cantidad de veces y luego funciona
+	attempts int
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.attempts++
+	if c.down || c.attempts <= c.failures {
+		return nil, driver.ErrBadConn
+	}
+	return fakeConn{}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not implemented") }
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: 5 * time.Second})
+	b.now = func() time.Time { return now }
+	boom := errors.New("connection refused")
+
+	for range 2 {
+		if err := b.allow(); err != nil {
+			t.Fatalf("closed circuit must allow attempts, got %v", err)
+		}
+		b.record(boom)
+	}
+	if err := b.allow(); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected the circuit to be open, got %v", err)
+	}
+
+	now = now.Add(5 * time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected a probe after OpenTimeout, got %v", err)
+	}
+	if err := b.allow(); err == nil {
+		t.Fatalf("only one probe may run at a time")
+	}
+	b.record(boom)
+	if err := b.allow(); err == nil {
+		t.Fatalf("a failed probe must reopen the circuit")
+	}
+
+	now = now.Add(5 * time.Second)
+	b.allow()
+	b.record(nil)
+	if err := b.allow(); err != nil {
+		t.Fatalf("a successful probe must close the circuit, got %v", err)
+	}
+
+	t.Run("caller cancellations are not failures", func(t *testing.T) {
+		b := newCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+		b.allow()
+		b.record(context.Canceled)
+		if err := b.allow(); err != nil {
+			t.Errorf("expected the circuit to stay closed, got %v", err)
+		}
+	})
+}
+
+func TestBreakerConnectorFailsFast(t *testing.T) {
+	conn := &fakeConnector{down: true}
+	db := sql.OpenDB(&breakerConnector{Connector: conn, breaker: newCircuitBreaker(BreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute})})
+	defer db.Close()
+
+	for range 5 {
+		err := translateError(db.PingContext(ctx))
+		if !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+	}
+	// database/sql reintenta ErrBadConn, así que cuenta intentos de conexión, no pings:
+	// una vez abierto el circuito no debe llegar ninguno más al servidor.
+	if conn.attempts != 3 {
+		t.Errorf("expected 3 connection attempts before opening the circuit, got %d", conn.attempts)
+	}
+}
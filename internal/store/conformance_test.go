@@ -0,0 +1,32 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/store/storetest"
+)
+
+// TestConformance ejecuta la suite de conformidad contra todos los backends. Los de Postgres
+// se saltan si no hay base de datos de integración (ver TEST_DB_CONN_STRING).
+func TestConformance(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		storetest.Run(t, func(t *testing.T) storetest.Store {
+			return store.NewMemoryStore(storetest.Breeds)
+		})
+	})
+	t.Run("SQLite", func(t *testing.T) {
+		storetest.Run(t, func(t *testing.T) storetest.Store {
+			return store.SetupSQLiteTestDB(t)
+		})
+	})
+	// Postgres y pgx comparten una base de datos: se abre una vez con el test padre.
+	t.Run("Postgres", func(t *testing.T) {
+		s := store.SetupTestDB(t)
+		storetest.Run(t, func(*testing.T) storetest.Store { return s })
+	})
+	t.Run("Pgx", func(t *testing.T) {
+		s := store.SetupPgxTestDB(t)
+		storetest.Run(t, func(*testing.T) storetest.Store { return s })
+	})
+}
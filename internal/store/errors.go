@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Códigos SQLSTATE de Postgres (lib/pq y pgx) que traducimos a errores del store.
+// Ver https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgForeignKeyViolation  = "23503"
+	pgUniqueViolation      = "23505"
+	pgCheckViolation       = "23514"
+	pgInvalidTextRepr      = "22P02"
+	pgInvalidDatetimeFmt   = "22007"
+	pgQueryCanceled        = "57014"
+	pgAdminShutdown        = "57P01"
+	pgCrashShutdown        = "57P02"
+	pgCannotConnectNow     = "57P03"
+	pgTooManyConnections   = "53300"
+	pgConnectionExceptions = "08" // clase completa
+)
+
+// translateError convierte los errores del driver en los errores tipados del store,
+// conservando el error original en la cadena para los logs. Los errores que ya son
+// del store o que no reconoce se devuelven sin cambios.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if code, ok := sqliteCode(err); ok {
+		switch code & 0xff { // código primario, sin la parte extendida
+		case sqlite3.SQLITE_CONSTRAINT:
+			switch code {
+			case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+				return fmt.Errorf("%w: %w", ErrForeignKeyViolation, err)
+			case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+				return fmt.Errorf("%w: %w", ErrUniqueViolation, err)
+			case sqlite3.SQLITE_CONSTRAINT_CHECK:
+				return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+			}
+		case sqlite3.SQLITE_INTERRUPT:
+			return fmt.Errorf("%w: %w", ErrCanceled, err)
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return err
+	}
+
+	if code, ok := sqlState(err); ok {
+		switch {
+		case code == pgForeignKeyViolation:
+			return fmt.Errorf("%w: %w", ErrForeignKeyViolation, err)
+		case code == pgUniqueViolation:
+			return fmt.Errorf("%w: %w", ErrUniqueViolation, err)
+		case code == pgInvalidTextRepr, code == pgInvalidDatetimeFmt, code == pgCheckViolation:
+			return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+		case code == pgQueryCanceled:
+			return fmt.Errorf("%w: %w", ErrCanceled, err)
+		case code == pgAdminShutdown, code == pgCrashShutdown, code == pgCannotConnectNow,
+			code == pgTooManyConnections, strings.HasPrefix(code, pgConnectionExceptions):
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	case isConnectionError(err):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// sqlState devuelve el código SQLSTATE del error, tanto si viene de lib/pq como de pgx.
+func sqlState(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code), true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code, true
+	}
+	return "", false
+}
+
+// sqliteCode devuelve el código de resultado (extendido) de un error de SQLite.
+func sqliteCode(err error) (int, bool) {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code(), true
+	}
+	return 0, false
+}
+
+// isConnectionError detecta fallos de red o conexiones rotas con la base de datos.
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"foreign key", &pq.Error{Code: "23503"}, ErrForeignKeyViolation},
+		{"unique", &pq.Error{Code: "23505"}, ErrUniqueViolation},
+		{"pgx foreign key", &pgconn.PgError{Code: "23503"}, ErrForeignKeyViolation},
+		{"pgx cannot connect now", &pgconn.PgError{Code: "57P03"}, ErrUnavailable},
+		{"invalid uuid", &pq.Error{Code: "22P02"}, ErrInvalidInput},
+		{"query canceled", &pq.Error{Code: "57014"}, ErrCanceled},
+		{"cannot connect now", &pq.Error{Code: "57P03"}, ErrUnavailable},
+		{"connection exception class", &pq.Error{Code: "08006"}, ErrUnavailable},
+		{"too many connections", &pq.Error{Code: "53300"}, ErrUnavailable},
+		{"context canceled", context.Canceled, ErrCanceled},
+		{"deadline exceeded", fmt.Errorf("query: %w", context.DeadlineExceeded), ErrCanceled},
+		{"bad connection", driver.ErrBadConn, ErrUnavailable},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+			if !errors.Is(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("the original error must stay in the chain, got %v", got)
+			}
+		})
+	}
+
+	t.Run("unknown errors pass through", func(t *testing.T) {
+		orig := &pq.Error{Code: "42P01"}
+		if got := translateError(orig); got != orig {
+			t.Errorf("expected the same error, got %v", got)
+		}
+		if translateError(nil) != nil {
+			t.Errorf("nil must stay nil")
+		}
+	})
+}
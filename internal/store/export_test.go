@@ -0,0 +1,9 @@
+package store
+
+// Los helpers de test que necesitan los tests externos (paquete store_test), que no pueden
+// usar directamente los identificadores no exportados.
+var (
+	SetupTestDB       = setupTestDB
+	SetupSQLiteTestDB = setupSQLiteTestDB
+	SetupPgxTestDB    = setupPgxTestDB
+)
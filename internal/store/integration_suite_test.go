@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/fixtures"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// ctx es el contexto usado por los tests; las escrituras quedan auditadas como audit.SystemActor.
+var ctx = context.Background()
+
+// integrationStore es lo que ejercita la suite de integración. La cumplen todos los backends
+// con base de datos real: PostgresStore, PgxStore y SQLiteStore.
+type integrationStore interface {
+	BreedStore
+	PetStore
+	AuditStore
+	Transactor
+}
+
+// runIntegrationSuite ejecuta los mismos tests contra cualquier backend. open se llama una
+// vez por test y debe devolver un store con las razas y mascotas de testFixtures.
+func runIntegrationSuite(t *testing.T, open func(t *testing.T) integrationStore) {
+	t.Run("GetBreeds", func(t *testing.T) { testGetBreeds(t, open(t)) })
+	t.Run("GetBreedByID", func(t *testing.T) { testGetBreedByID(t, open(t)) })
+	t.Run("GetPets", func(t *testing.T) { testGetPets(t, open(t)) })
+	t.Run("Pets", func(t *testing.T) { testPets(t, open(t)) })
+	t.Run("WithinTx", func(t *testing.T) { testWithinTx(t, open(t)) })
+}
+
+// testFixtures son las razas y mascotas con las que cuentan los tests, las mismas que carga
+// db-setup-test (ver Makefile). Se cargan con Seed en las bases de datos que crean los propios
+// tests: SQLite y el Postgres embebido.
+var testFixtures = fixtures.MustLoad(fixtures.Base)
+
+// TestMain permite realizar configuraciones y limpiezas globales para los tests de este paquete.
+func TestMain(m *testing.M) {
+	// Puedes configurar tu base de datos de prueba aquí si es un set-up muy costoso.
+	// Por simplicidad, setupTestDB se llama en cada test si es unitario,
+	// o puedes usar una instancia global si los tests son independientes.
+
+	// Normalmente aquí iniciarías un contenedor de base de datos específico para tests
+	// o harías cualquier configuración de una sola vez.
+
+	code := m.Run() // Ejecuta todos los tests en el paquete
+	stopTestPostgres()
+
+	// Limpieza después de que todos los tests se hayan ejecutado
+	// (ej. detener el contenedor de DB de prueba, si lo iniciaste aquí).
+
+	os.Exit(code)
+}
+
+// testGetBreeds verifica que podemos obtener razas desde el store.
+func testGetBreeds(t *testing.T, store integrationStore) {
+	// Asegurémonos de que la tabla tenga al menos los datos base que insertamos.
+	// Si estás ejecutando tests repetidamente sin limpiar la DB, es posible que los datos se dupliquen,
+	// lo cual es una razón para usar una DB de test separada o limpiar antes de cada test.
+
+	breeds, err := store.GetBreeds(ctx)
+	if err != nil {
+		t.Fatalf("GetBreeds falló: %v", err)
+	}
+
+	if len(breeds) == 0 {
+		t.Errorf("GetBreeds devolvió 0 razas, esperaba al menos una.")
+	}
+
+	// Podemos verificar si una raza específica que esperamos está en la lista.
+	foundGolden := false
+	for _, breed := range breeds {
+		if breed.ID == "golden-retriever" && breed.Name == "Golden Retriever" {
+			foundGolden = true
+			break
+		}
+	}
+	if !foundGolden {
+		t.Errorf("No se encontró 'Golden Retriever' en las razas obtenidas.")
+	}
+
+	// Opcional: verificar la cantidad exacta si los datos son fijos para el test.
+	// if len(breeds) != 5 {
+	//     t.Errorf("Esperaba 5 razas, obtuve %d", len(breeds))
+	// }
+}
+
+func testGetBreedByID(t *testing.T, store integrationStore) {
+
+	t.Run("should return breed for existing ID", func(t *testing.T) {
+
+		idToFind := types.BreedID("golden-retriever") // Asegúrate de que este ID esté en tu db-setup-test
+		breed, err := store.GetBreedByID(ctx, idToFind)
+		if err != nil {
+			t.Fatalf("GetBreedByID falló para ID '%s': %v", idToFind, err)
+		}
+
+		if breed == nil {
+			t.Fatalf("GetBreedByID devolvió nil para ID existente '%s'", idToFind)
+		}
+		if breed.ID != idToFind {
+			t.Errorf("ID de raza incorrecto: esperado '%s', obtenido '%s'", idToFind, breed.ID)
+		}
+		if breed.Name != "Golden Retriever" {
+			t.Errorf("Nombre de raza incorrecto: esperado 'Golden Retriever', obtenido '%s'", breed.Name)
+		}
+		// ... (más aserciones)
+	})
+
+	// Escenario 2: Raza no existente (verificando store.ErrNotFound)
+	t.Run("should return ErrNotFound for non-existent ID", func(t *testing.T) {
+
+		idToFind := types.BreedID("non-existent-breed-123") // ID que sabes que no está en la DB
+		_, err := store.GetBreedByID(ctx, idToFind)
+
+		if err == nil {
+			t.Errorf("GetBreedByID debería haber devuelto un error para ID no existente '%s', pero devolvió nil", idToFind)
+		}
+
+		// ¡Aserción clave! Usar errors.Is para verificar el error sentinel
+		if !errors.Is(err, ErrNotFound) { // Asegúrate de importar "errors" aquí si no está
+			t.Errorf("Tipo de error incorrecto para ID no existente: esperado 'store.ErrNotFound', obtenido '%v'", err)
+		}
+	})
+
+}
+
+func testGetPets(t *testing.T, store integrationStore) {
+
+	pets, err := store.GetPets(ctx)
+	if err != nil {
+		t.Fatalf("GetPets failed: %v", err)
+	}
+
+	if len(pets) == 0 {
+		t.Errorf("GetPets devolvió 0 mascotas, esperaba al menos una.")
+	}
+
+}
+
+func testPets(t *testing.T, store integrationStore) {
+	var id types.PetID
+
+	t.Run("should create a new pet", func(t *testing.T) {
+		breeds, _ := store.GetBreeds(ctx)
+		newPet := types.Pet{ID: "", Name: "Fido", Birth: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Breed: breeds[0]}
+		petWithID, err := store.CreatePet(ctx, newPet.Name, newPet.Birth, newPet.Breed.ID)
+
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			t.Errorf("error in creating new pet:'%v'", err)
+		}
+
+		id = petWithID.ID
+	})
+
+	t.Run("should return the new created pet by id", func(t *testing.T) {
+		_, err := store.GetPetByID(ctx, id)
+
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			t.Errorf("error new pet not found:'%v'", err)
+		}
+	})
+
+	t.Run("should update the pet only with the current version", func(t *testing.T) {
+		updated, err := store.UpdatePet(ctx, id, 1, "Fido II", time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), "poodle")
+		if err != nil {
+			t.Fatalf("error updating pet: %v", err)
+		}
+		if updated.Version != 2 || updated.Breed.ID != "poodle" {
+			t.Errorf("unexpected updated pet: %+v", updated)
+		}
+
+		_, err = store.UpdatePet(ctx, id, 1, "Stale", time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), "poodle")
+		if !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("expected ErrVersionConflict for stale version, got %v", err)
+		}
+
+		if _, err := store.DeletePet(ctx, id, 1); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("expected ErrVersionConflict deleting with stale version, got %v", err)
+		}
+	})
+
+	t.Run("should delete the new created pet by id", func(t *testing.T) {
+		_, err := store.DeletePet(ctx, id, 2)
+
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			t.Errorf("error new pet not found:'%v'", err)
+		}
+
+		if _, err := store.DeletePet(ctx, id, AnyVersion); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound deleting a missing pet, got %v", err)
+		}
+	})
+
+	t.Run("should record every mutation in the audit log", func(t *testing.T) {
+		entries, err := store.GetAuditHistory(ctx, audit.EntityPet, id.String())
+		if err != nil {
+			t.Fatalf("error reading audit history: %v", err)
+		}
+		var actions []string
+		for _, e := range entries {
+			actions = append(actions, e.Action)
+		}
+		want := []string{audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete}
+		if !slices.Equal(actions, want) {
+			t.Fatalf("expected actions %v, got %v", want, actions)
+		}
+		if entries[0].Before != nil || entries[0].After == nil || entries[2].After != nil {
+			t.Errorf("unexpected before/after states: %+v", entries)
+		}
+		if entries[0].Actor != audit.SystemActor {
+			t.Errorf("expected actor %q, got %q", audit.SystemActor, entries[0].Actor)
+		}
+	})
+
+}
+
+func testWithinTx(t *testing.T, store integrationStore) {
+
+	t.Run("should roll back every operation on error", func(t *testing.T) {
+		before, _ := store.GetPets(ctx)
+		err := store.WithinTx(ctx, func(tx Tx) error {
+			if _, err := tx.CreatePet(ctx, "Tx Fido", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "poodle"); err != nil {
+				return err
+			}
+			_, err := tx.CreatePet(ctx, "Tx Ghost", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "non-existent-breed-123")
+			return err
+		})
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+		after, _ := store.GetPets(ctx)
+		if len(after) != len(before) {
+			t.Errorf("expected rollback: %d pets before, %d after", len(before), len(after))
+		}
+	})
+
+	t.Run("should commit every operation together", func(t *testing.T) {
+		var ids []types.PetID
+		err := store.WithinTx(ctx, func(tx Tx) error {
+			for _, name := range []string{"Tx One", "Tx Two"} {
+				p, err := tx.CreatePet(ctx, name, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "poodle")
+				if err != nil {
+					return err
+				}
+				ids = append(ids, p.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithinTx failed: %v", err)
+		}
+		for _, id := range ids {
+			if _, err := store.GetPetByID(ctx, id); err != nil {
+				t.Errorf("committed pet %s not found: %v", id, err)
+			}
+			store.DeletePet(ctx, id, AnyVersion)
+		}
+	})
+}
@@ -0,0 +1,772 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// MemoryStore es una implementación en memoria de los stores, pensada para tests y
+// desarrollo local. Las transacciones trabajan sobre una copia del estado que solo se
+// publica al confirmar, así que un rollback simplemente descarta la copia.
+type MemoryStore struct {
+	// txMu serializa las escrituras: cada escritura es una transacción (explícita o implícita).
+	txMu sync.Mutex
+	// mu protege el puntero a state para las lecturas concurrentes.
+	mu    sync.RWMutex
+	state *memoryState
+	now   func() time.Time
+}
+
+type memoryState struct {
+	breeds      []types.Breed
+	pets        []types.Pet
+	audit       []types.AuditEntry
+	nextAuditID int64
+	visits      []types.VetVisit
+	conditions  []types.Condition
+	medications []types.Medication
+	doses       []types.DoseRecord
+	jobs        []types.Job
+	nextJobID   int64
+	webhooks    []types.Webhook
+	deliveries  []types.WebhookDelivery
+	nextDelivID int64
+}
+
+func (st *memoryState) clone() *memoryState {
+	return &memoryState{
+		breeds:      slices.Clone(st.breeds),
+		pets:        slices.Clone(st.pets),
+		audit:       slices.Clone(st.audit),
+		nextAuditID: st.nextAuditID,
+		visits:      slices.Clone(st.visits),
+		conditions:  slices.Clone(st.conditions),
+		medications: slices.Clone(st.medications),
+		doses:       slices.Clone(st.doses),
+		jobs:        slices.Clone(st.jobs),
+		nextJobID:   st.nextJobID,
+		webhooks:    slices.Clone(st.webhooks),
+		deliveries:  slices.Clone(st.deliveries),
+		nextDelivID: st.nextDelivID,
+	}
+}
+
+// memoryTx es la vista transaccional de MemoryStore: lee y escribe sobre su propia copia.
+type memoryTx struct {
+	state *memoryState
+	now   func() time.Time
+}
+
+// NewMemoryStore crea un store en memoria con las razas y, opcionalmente, las mascotas
+// indicadas. Las mascotas se guardan tal cual, sin auditar; las que no tienen versión
+// empiezan en la 1, igual que una mascota recién creada.
+func NewMemoryStore(breeds []types.Breed, pets ...types.Pet) *MemoryStore {
+	state := &memoryState{breeds: slices.Clone(breeds)}
+	for _, p := range pets {
+		p.Birth = dateOnly(p.Birth)
+		p.Version = max(p.Version, 1)
+		state.pets = append(state.pets, p)
+	}
+	return &MemoryStore{state: state, now: time.Now}
+}
+
+func (s *MemoryStore) snapshot() *memoryState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// WithinTx ejecuta fn sobre una copia del estado y la publica solo si fn no devuelve error.
+func (s *MemoryStore) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	return s.write(ctx, func(t *memoryTx) error { return fn(t) })
+}
+
+// write es WithinTx con acceso a las operaciones de memoryTx que no forman parte de Tx.
+func (s *MemoryStore) write(ctx context.Context, fn func(t *memoryTx) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	tx := &memoryTx{state: s.snapshot().clone(), now: s.now}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.state = tx.state
+	s.mu.Unlock()
+	return nil
+}
+
+// readTx devuelve una vista de solo lectura sobre el estado publicado actual.
+// El estado publicado nunca se modifica en sitio, así que no hace falta copiarlo.
+func (s *MemoryStore) readTx() *memoryTx {
+	return &memoryTx{state: s.snapshot(), now: s.now}
+}
+
+func (s *MemoryStore) GetBreeds(ctx context.Context) ([]types.Breed, error) {
+	return s.readTx().GetBreeds(ctx)
+}
+
+func (s *MemoryStore) GetBreedByID(ctx context.Context, id types.BreedID) (*types.Breed, error) {
+	return s.readTx().GetBreedByID(ctx, id)
+}
+
+func (s *MemoryStore) UpsertBreed(ctx context.Context, b types.Breed) (UpsertResult, error) {
+	var result UpsertResult
+	err := s.WithinTx(ctx, func(tx Tx) error {
+		var err error
+		result, err = tx.UpsertBreed(ctx, b)
+		return err
+	})
+	return result, err
+}
+
+func (s *MemoryStore) GetPets(ctx context.Context) ([]types.Pet, error) {
+	return s.readTx().GetPets(ctx)
+}
+
+func (s *MemoryStore) GetPetByID(ctx context.Context, id types.PetID) (*types.Pet, error) {
+	return s.readTx().GetPetByID(ctx, id)
+}
+
+func (s *MemoryStore) GetAuditHistory(ctx context.Context, entityType, entityID string) ([]types.AuditEntry, error) {
+	return s.readTx().GetAuditHistory(ctx, entityType, entityID)
+}
+
+func (s *MemoryStore) CreatePet(ctx context.Context, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	var pet *types.Pet
+	err := s.WithinTx(ctx, func(tx Tx) error {
+		var err error
+		pet, err = tx.CreatePet(ctx, name, birth, breedID)
+		return err
+	})
+	return pet, err
+}
+
+func (s *MemoryStore) UpdatePet(ctx context.Context, id types.PetID, expectedVersion int64, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	var pet *types.Pet
+	err := s.WithinTx(ctx, func(tx Tx) error {
+		var err error
+		pet, err = tx.UpdatePet(ctx, id, expectedVersion, name, birth, breedID)
+		return err
+	})
+	return pet, err
+}
+
+func (s *MemoryStore) DeletePet(ctx context.Context, id types.PetID, expectedVersion int64) (*types.Pet, error) {
+	var pet *types.Pet
+	err := s.WithinTx(ctx, func(tx Tx) error {
+		var err error
+		pet, err = tx.DeletePet(ctx, id, expectedVersion)
+		return err
+	})
+	return pet, err
+}
+
+func (s *MemoryStore) GetVetVisits(ctx context.Context, petID types.PetID) ([]types.VetVisit, error) {
+	return s.readTx().GetVetVisits(ctx, petID)
+}
+
+func (s *MemoryStore) GetConditions(ctx context.Context, petID types.PetID) ([]types.Condition, error) {
+	return s.readTx().GetConditions(ctx, petID)
+}
+
+func (s *MemoryStore) AddVetVisit(ctx context.Context, visit types.VetVisit) (*types.VetVisit, error) {
+	var v *types.VetVisit
+	err := s.write(ctx, func(t *memoryTx) error {
+		var err error
+		v, err = t.AddVetVisit(ctx, visit)
+		return err
+	})
+	return v, err
+}
+
+func (s *MemoryStore) AddCondition(ctx context.Context, c types.Condition) (*types.Condition, error) {
+	var cond *types.Condition
+	err := s.write(ctx, func(t *memoryTx) error {
+		var err error
+		cond, err = t.AddCondition(ctx, c)
+		return err
+	})
+	return cond, err
+}
+
+func (s *MemoryStore) GetMedications(ctx context.Context, petID types.PetID) ([]types.Medication, error) {
+	return s.readTx().GetMedications(ctx, petID)
+}
+
+func (s *MemoryStore) GetMedication(ctx context.Context, petID types.PetID, id string) (*types.Medication, error) {
+	return s.readTx().GetMedication(ctx, petID, id)
+}
+
+func (s *MemoryStore) GetDoseRecords(ctx context.Context, petID types.PetID, from, to time.Time) ([]types.DoseRecord, error) {
+	return s.readTx().GetDoseRecords(ctx, petID, from, to)
+}
+
+func (s *MemoryStore) AddMedication(ctx context.Context, m types.Medication) (*types.Medication, error) {
+	var med *types.Medication
+	err := s.write(ctx, func(t *memoryTx) error {
+		var err error
+		med, err = t.AddMedication(ctx, m)
+		return err
+	})
+	return med, err
+}
+
+func (s *MemoryStore) RecordDose(ctx context.Context, d types.DoseRecord) (*types.DoseRecord, error) {
+	var dose *types.DoseRecord
+	err := s.write(ctx, func(t *memoryTx) error {
+		var err error
+		dose, err = t.RecordDose(ctx, d)
+		return err
+	})
+	return dose, err
+}
+
+func (s *MemoryStore) EnqueueJob(ctx context.Context, job types.Job) (bool, error) {
+	var created bool
+	err := s.write(ctx, func(t *memoryTx) error {
+		created = t.enqueueJob(job)
+		return nil
+	})
+	return created, err
+}
+
+// enqueueJob guarda el trabajo si no hay otro con la misma clave y devuelve si lo guardó.
+func (t *memoryTx) enqueueJob(job types.Job) bool {
+	if slices.ContainsFunc(t.state.jobs, func(j types.Job) bool { return j.Key == job.Key }) {
+		return false
+	}
+	j := newJob(job, t.now())
+	t.state.nextJobID++
+	j.ID = t.state.nextJobID
+	t.state.jobs = append(t.state.jobs, j)
+	return true
+}
+
+func (s *MemoryStore) ClaimJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]types.Job, error) {
+	claimed := []types.Job{}
+	err := s.write(ctx, func(t *memoryTx) error {
+		var due []int
+		for i, j := range t.state.jobs {
+			expired := j.Status == types.JobRunning && j.LockedUntil != nil && !j.LockedUntil.After(now)
+			if !j.RunAt.After(now) && (j.Status == types.JobPending || expired) {
+				due = append(due, i)
+			}
+		}
+		slices.SortFunc(due, func(a, b int) int {
+			ja, jb := t.state.jobs[a], t.state.jobs[b]
+			return cmp.Or(ja.RunAt.Compare(jb.RunAt), cmp.Compare(ja.ID, jb.ID))
+		})
+		if limit > 0 && len(due) > limit {
+			due = due[:limit]
+		}
+		lockedUntil := now.UTC().Add(lease)
+		for _, i := range due {
+			j := &t.state.jobs[i]
+			j.Status = types.JobRunning
+			j.Attempts++
+			j.LockedUntil = &lockedUntil
+			j.UpdatedAt = now.UTC()
+			claimed = append(claimed, *j)
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+func (s *MemoryStore) CompleteJob(ctx context.Context, id int64) error {
+	return s.updateJob(ctx, id, func(j *types.Job) {
+		j.Status = types.JobDone
+	})
+}
+
+func (s *MemoryStore) RetryJob(ctx context.Context, id int64, runAt time.Time, lastError string) error {
+	return s.updateJob(ctx, id, func(j *types.Job) {
+		j.Status = types.JobPending
+		j.RunAt = runAt.UTC()
+		j.LastError = lastError
+	})
+}
+
+func (s *MemoryStore) FailJob(ctx context.Context, id int64, lastError string) error {
+	return s.updateJob(ctx, id, func(j *types.Job) {
+		j.Status = types.JobFailed
+		j.LastError = lastError
+	})
+}
+
+// updateJob aplica fn al trabajo, le quita la reserva y devuelve ErrNotFound si no existe.
+func (s *MemoryStore) updateJob(ctx context.Context, id int64, fn func(j *types.Job)) error {
+	return s.write(ctx, func(t *memoryTx) error {
+		i := slices.IndexFunc(t.state.jobs, func(j types.Job) bool { return j.ID == id })
+		if i < 0 {
+			return ErrNotFound
+		}
+		j := &t.state.jobs[i]
+		fn(j)
+		j.LockedUntil = nil
+		j.UpdatedAt = t.now()
+		return nil
+	})
+}
+
+func (s *MemoryStore) CreateWebhook(ctx context.Context, webhook types.Webhook) (*types.Webhook, error) {
+	w := newWebhook(webhook, s.now())
+	w.Events = slices.Clone(w.Events)
+	err := s.write(ctx, func(t *memoryTx) error {
+		t.state.webhooks = append(t.state.webhooks, w)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// GetWebhooks devuelve las suscripciones en el orden en que se crearon.
+func (s *MemoryStore) GetWebhooks(ctx context.Context) ([]types.Webhook, error) {
+	webhooks := []types.Webhook{}
+	for _, w := range s.snapshot().webhooks {
+		w.Events = slices.Clone(w.Events)
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, nil
+}
+
+func (s *MemoryStore) GetWebhook(ctx context.Context, id string) (*types.Webhook, error) {
+	for _, w := range s.snapshot().webhooks {
+		if w.ID == id {
+			w.Events = slices.Clone(w.Events)
+			return &w, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) DeleteWebhook(ctx context.Context, id string) error {
+	return s.write(ctx, func(t *memoryTx) error {
+		i := slices.IndexFunc(t.state.webhooks, func(w types.Webhook) bool { return w.ID == id })
+		if i < 0 {
+			return ErrNotFound
+		}
+		t.state.webhooks = slices.Delete(t.state.webhooks, i, i+1)
+		t.state.deliveries = slices.DeleteFunc(t.state.deliveries, func(d types.WebhookDelivery) bool { return d.WebhookID == id })
+		return nil
+	})
+}
+
+func (s *MemoryStore) AddDelivery(ctx context.Context, delivery types.WebhookDelivery) (*types.WebhookDelivery, error) {
+	var d types.WebhookDelivery
+	err := s.write(ctx, func(t *memoryTx) error {
+		if !slices.ContainsFunc(t.state.webhooks, func(w types.Webhook) bool { return w.ID == delivery.WebhookID }) {
+			return ErrNotFound
+		}
+		d = newDelivery(delivery, t.now())
+		t.state.nextDelivID++
+		d.ID = t.state.nextDelivID
+		t.state.deliveries = append(t.state.deliveries, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *MemoryStore) RecordDeliveryAttempt(ctx context.Context, id int64, status types.DeliveryStatus, responseStatus int, lastError string) error {
+	return s.write(ctx, func(t *memoryTx) error {
+		i := slices.IndexFunc(t.state.deliveries, func(d types.WebhookDelivery) bool { return d.ID == id })
+		if i < 0 {
+			return ErrNotFound
+		}
+		d := &t.state.deliveries[i]
+		d.Status = status
+		d.Attempts++
+		d.ResponseStatus = responseStatus
+		d.LastError = lastError
+		d.UpdatedAt = t.now()
+		return nil
+	})
+}
+
+// GetDeliveries devuelve las entregas de la más reciente a la más antigua.
+func (s *MemoryStore) GetDeliveries(ctx context.Context, webhookID string, limit int) ([]types.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	deliveries := []types.WebhookDelivery{}
+	all := s.snapshot().deliveries
+	for i := len(all) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if all[i].WebhookID == webhookID {
+			deliveries = append(deliveries, all[i])
+		}
+	}
+	return deliveries, nil
+}
+
+// PetSnapshot lee las mascotas y el token de la misma versión del estado.
+func (s *MemoryStore) PetSnapshot(ctx context.Context) ([]types.Pet, int64, error) {
+	t := s.readTx()
+	pets, err := t.GetPets(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pets, t.state.nextAuditID, nil
+}
+
+func (s *MemoryStore) PetChanges(ctx context.Context, since int64, limit int) ([]types.PetChange, error) {
+	changes := []types.PetChange{}
+	for _, e := range s.snapshot().audit {
+		if len(changes) == limit {
+			break
+		}
+		if e.ID <= since || e.EntityType != audit.EntityPet {
+			continue
+		}
+		c, err := newPetChange(e.ID, e.EntityID, e.Action, e.After)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *c)
+	}
+	return changes, nil
+}
+
+// WithinTx dentro de una transacción reutiliza la transacción en curso.
+func (t *memoryTx) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	return fn(t)
+}
+
+func (t *memoryTx) GetBreeds(ctx context.Context) ([]types.Breed, error) {
+	breeds := slices.Clone(t.state.breeds)
+	slices.SortFunc(breeds, func(a, b types.Breed) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(string(a.ID), string(b.ID)))
+	})
+	return breeds, nil
+}
+
+func (t *memoryTx) GetBreedByID(ctx context.Context, id types.BreedID) (*types.Breed, error) {
+	for _, b := range t.state.breeds {
+		if b.ID == id {
+			return &b, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// UpsertBreed valida el tamaño como el CHECK de la tabla breeds.
+func (t *memoryTx) UpsertBreed(ctx context.Context, b types.Breed) (UpsertResult, error) {
+	if !b.Size.Valid() {
+		return "", fmt.Errorf("%w: invalid breed size %q", ErrInvalidInput, b.Size)
+	}
+	i := slices.IndexFunc(t.state.breeds, func(x types.Breed) bool { return x.ID == b.ID })
+	if i < 0 {
+		t.state.breeds = append(t.state.breeds, b)
+		return Inserted, t.recordAudit(ctx, audit.EntityBreed, b.ID.String(), audit.ActionCreate, nil, b)
+	}
+	before := t.state.breeds[i]
+	if before == b {
+		return Unchanged, nil
+	}
+	t.state.breeds[i] = b
+	return Updated, t.recordAudit(ctx, audit.EntityBreed, b.ID.String(), audit.ActionUpdate, before, b)
+}
+
+func (t *memoryTx) GetPets(ctx context.Context) ([]types.Pet, error) {
+	return t.petsWithBreeds(), nil
+}
+
+func (t *memoryTx) GetPetByID(ctx context.Context, id types.PetID) (*types.Pet, error) {
+	i := t.petIndex(id)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	pet := t.withBreed(t.state.pets[i])
+	return &pet, nil
+}
+
+func (t *memoryTx) CreatePet(ctx context.Context, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	breed, err := t.GetBreedByID(ctx, breedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+	}
+
+	pet := types.Pet{
+		ID:        types.PetID(newUUID()),
+		Name:      name,
+		Birth:     dateOnly(birth),
+		Breed:     *breed,
+		Owner:     audit.FromContext(ctx).Subject,
+		Version:   1,
+		UpdatedAt: t.now(),
+	}
+	t.state.pets = append(t.state.pets, pet)
+	if err := t.recordAudit(ctx, audit.EntityPet, pet.ID.String(), audit.ActionCreate, nil, pet); err != nil {
+		return nil, err
+	}
+	return &pet, nil
+}
+
+func (t *memoryTx) UpdatePet(ctx context.Context, id types.PetID, expectedVersion int64, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	i := t.petIndex(id)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	before := t.withBreed(t.state.pets[i])
+	if expectedVersion != AnyVersion && before.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+	breed, err := t.GetBreedByID(ctx, breedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+	}
+
+	pet := before
+	pet.Name = name
+	pet.Birth = dateOnly(birth)
+	pet.Breed = *breed
+	pet.Version++
+	pet.UpdatedAt = t.now()
+	t.state.pets[i] = pet
+
+	if err := t.recordAudit(ctx, audit.EntityPet, id.String(), audit.ActionUpdate, before, pet); err != nil {
+		return nil, err
+	}
+	return &pet, nil
+}
+
+func (t *memoryTx) DeletePet(ctx context.Context, id types.PetID, expectedVersion int64) (*types.Pet, error) {
+	i := t.petIndex(id)
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	before := t.withBreed(t.state.pets[i])
+	if expectedVersion != AnyVersion && before.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+	t.state.pets = slices.Delete(t.state.pets, i, i+1)
+	// Igual que ON DELETE CASCADE: el historial médico se borra con la mascota.
+	t.state.visits = slices.DeleteFunc(t.state.visits, func(v types.VetVisit) bool { return v.PetID == id })
+	t.state.conditions = slices.DeleteFunc(t.state.conditions, func(c types.Condition) bool { return c.PetID == id })
+	for _, m := range t.state.medications {
+		if m.PetID == id {
+			t.state.doses = slices.DeleteFunc(t.state.doses, func(d types.DoseRecord) bool { return d.MedicationID == m.ID })
+		}
+	}
+	t.state.medications = slices.DeleteFunc(t.state.medications, func(m types.Medication) bool { return m.PetID == id })
+	if err := t.recordAudit(ctx, audit.EntityPet, id.String(), audit.ActionDelete, before, nil); err != nil {
+		return nil, err
+	}
+	return &before, nil
+}
+
+func (t *memoryTx) GetAuditHistory(ctx context.Context, entityType, entityID string) ([]types.AuditEntry, error) {
+	entries := []types.AuditEntry{}
+	for _, e := range t.state.audit {
+		if e.EntityType == entityType && e.EntityID == entityID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (t *memoryTx) AddVetVisit(ctx context.Context, visit types.VetVisit) (*types.VetVisit, error) {
+	if t.petIndex(visit.PetID) < 0 {
+		return nil, ErrNotFound
+	}
+	v := newVetVisit(visit, t.now())
+	v.Treatments = slices.Clone(v.Treatments)
+	v.Attachments = slices.Clone(v.Attachments)
+	t.state.visits = append(t.state.visits, v)
+	return &v, nil
+}
+
+func (t *memoryTx) GetVetVisits(ctx context.Context, petID types.PetID) ([]types.VetVisit, error) {
+	if t.petIndex(petID) < 0 {
+		return nil, ErrNotFound
+	}
+	visits := []types.VetVisit{}
+	for _, v := range t.state.visits {
+		if v.PetID == petID {
+			visits = append(visits, v)
+		}
+	}
+	slices.SortStableFunc(visits, func(a, b types.VetVisit) int {
+		return cmp.Or(a.Date.Compare(b.Date), a.CreatedAt.Compare(b.CreatedAt))
+	})
+	return visits, nil
+}
+
+func (t *memoryTx) AddCondition(ctx context.Context, cond types.Condition) (*types.Condition, error) {
+	if t.petIndex(cond.PetID) < 0 {
+		return nil, ErrNotFound
+	}
+	c := newCondition(cond, t.now())
+	t.state.conditions = append(t.state.conditions, c)
+	return &c, nil
+}
+
+// GetConditions devuelve las condiciones en el orden en que se añadieron.
+func (t *memoryTx) GetConditions(ctx context.Context, petID types.PetID) ([]types.Condition, error) {
+	if t.petIndex(petID) < 0 {
+		return nil, ErrNotFound
+	}
+	conditions := []types.Condition{}
+	for _, c := range t.state.conditions {
+		if c.PetID == petID {
+			conditions = append(conditions, c)
+		}
+	}
+	return conditions, nil
+}
+
+func (t *memoryTx) AddMedication(ctx context.Context, med types.Medication) (*types.Medication, error) {
+	if t.petIndex(med.PetID) < 0 {
+		return nil, ErrNotFound
+	}
+	m := newMedication(med, t.now())
+	t.state.medications = append(t.state.medications, m)
+	return &m, nil
+}
+
+// GetMedications devuelve las pautas en el orden en que se añadieron.
+func (t *memoryTx) GetMedications(ctx context.Context, petID types.PetID) ([]types.Medication, error) {
+	if t.petIndex(petID) < 0 {
+		return nil, ErrNotFound
+	}
+	meds := []types.Medication{}
+	for _, m := range t.state.medications {
+		if m.PetID == petID {
+			meds = append(meds, m)
+		}
+	}
+	return meds, nil
+}
+
+func (t *memoryTx) GetMedication(ctx context.Context, petID types.PetID, id string) (*types.Medication, error) {
+	for _, m := range t.state.medications {
+		if m.ID == id && m.PetID == petID {
+			return &m, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (t *memoryTx) RecordDose(ctx context.Context, dose types.DoseRecord) (*types.DoseRecord, error) {
+	if !slices.ContainsFunc(t.state.medications, func(m types.Medication) bool { return m.ID == dose.MedicationID }) {
+		return nil, ErrNotFound
+	}
+	d := newDoseRecord(dose, t.now())
+	if slices.ContainsFunc(t.state.doses, func(r types.DoseRecord) bool {
+		return r.MedicationID == d.MedicationID && r.ScheduledAt.Equal(d.ScheduledAt)
+	}) {
+		return nil, fmt.Errorf("dose of medication %s at %s: %w", d.MedicationID, d.ScheduledAt, ErrUniqueViolation)
+	}
+	t.state.doses = append(t.state.doses, d)
+	return &d, nil
+}
+
+func (t *memoryTx) GetDoseRecords(ctx context.Context, petID types.PetID, from, to time.Time) ([]types.DoseRecord, error) {
+	records := []types.DoseRecord{}
+	for _, d := range t.state.doses {
+		if _, err := t.GetMedication(ctx, petID, d.MedicationID); err != nil {
+			continue
+		}
+		if !d.ScheduledAt.Before(from) && d.ScheduledAt.Before(to) {
+			records = append(records, d)
+		}
+	}
+	slices.SortFunc(records, func(a, b types.DoseRecord) int {
+		return cmp.Or(a.ScheduledAt.Compare(b.ScheduledAt), strings.Compare(a.MedicationID, b.MedicationID))
+	})
+	return records, nil
+}
+
+func (t *memoryTx) recordAudit(ctx context.Context, entityType, entityID, action string, before, after any) error {
+	meta := audit.FromContext(ctx)
+	entry := types.AuditEntry{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      meta.Actor,
+		RequestID:  meta.RequestID,
+		CreatedAt:  t.now(),
+	}
+	var err error
+	if before != nil {
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return fmt.Errorf("failed to marshal audit state: %w", err)
+		}
+	}
+	if after != nil {
+		if entry.After, err = json.Marshal(after); err != nil {
+			return fmt.Errorf("failed to marshal audit state: %w", err)
+		}
+	}
+	t.state.nextAuditID++
+	entry.ID = t.state.nextAuditID
+	t.state.audit = append(t.state.audit, entry)
+
+	// El evento va al outbox en la misma transacción, como en insertAudit.
+	if len(t.state.webhooks) == 0 {
+		return nil
+	}
+	job, err := newEventJob(ctx, entityType, entityID, action, after)
+	if err != nil {
+		return err
+	}
+	t.enqueueJob(job)
+	return nil
+}
+
+func (t *memoryTx) petIndex(id types.PetID) int {
+	return slices.IndexFunc(t.state.pets, func(p types.Pet) bool { return p.ID == id })
+}
+
+// withBreed refresca los datos de la raza, igual que el JOIN de PostgresStore.
+func (t *memoryTx) withBreed(p types.Pet) types.Pet {
+	if b, err := t.GetBreedByID(context.Background(), p.Breed.ID); err == nil {
+		p.Breed = *b
+	}
+	return p
+}
+
+func (t *memoryTx) petsWithBreeds() []types.Pet {
+	var pets []types.Pet
+	for _, p := range t.state.pets {
+		pets = append(pets, t.withBreed(p))
+	}
+	slices.SortFunc(pets, func(a, b types.Pet) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(string(a.ID), string(b.ID)))
+	})
+	return pets
+}
+
+// dateOnly trunca a medianoche UTC, igual que una columna DATE leída desde Postgres.
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// newUUID genera un UUID v4 aleatorio, como gen_random_uuid() en Postgres.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
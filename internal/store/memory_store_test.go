@@ -0,0 +1,94 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+func newTestMemoryStore() *MemoryStore {
+	return NewMemoryStore([]types.Breed{
+		{ID: "golden-retriever", Name: "Golden Retriever", Temperament: "Friendly", Origin: "Scotland"},
+		{ID: "poodle", Name: "Poodle", Temperament: "Proud", Origin: "Germany/France"},
+	})
+}
+
+func TestMemoryStoreWithinTx(t *testing.T) {
+	birth := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("commits every operation together", func(t *testing.T) {
+		s := newTestMemoryStore()
+		err := s.WithinTx(ctx, func(tx Tx) error {
+			if _, err := tx.CreatePet(ctx, "Fido", birth, "golden-retriever"); err != nil {
+				return err
+			}
+			_, err := tx.CreatePet(ctx, "Rex", birth, "poodle")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithinTx failed: %v", err)
+		}
+		pets, _ := s.GetPets(ctx)
+		if len(pets) != 2 {
+			t.Errorf("expected 2 pets, got %d", len(pets))
+		}
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		s := newTestMemoryStore()
+		boom := errors.New("boom")
+		err := s.WithinTx(ctx, func(tx Tx) error {
+			if _, err := tx.CreatePet(ctx, "Fido", birth, "golden-retriever"); err != nil {
+				return err
+			}
+			// La transacción ve su propia escritura...
+			if pets, _ := tx.GetPets(ctx); len(pets) != 1 {
+				t.Errorf("tx should see its own write, got %d pets", len(pets))
+			}
+			// ...pero fuera de ella todavía no existe.
+			if pets, _ := s.GetPets(ctx); len(pets) != 0 {
+				t.Errorf("uncommitted write visible outside tx")
+			}
+			return boom
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("expected boom, got %v", err)
+		}
+		if pets, _ := s.GetPets(ctx); len(pets) != 0 {
+			t.Errorf("expected rollback, got %d pets", len(pets))
+		}
+	})
+
+	t.Run("rolls back a failed foreign key", func(t *testing.T) {
+		s := newTestMemoryStore()
+		err := s.WithinTx(ctx, func(tx Tx) error {
+			if _, err := tx.CreatePet(ctx, "Fido", birth, "golden-retriever"); err != nil {
+				return err
+			}
+			_, err := tx.CreatePet(ctx, "Ghost", birth, "does-not-exist")
+			return err
+		})
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+		if pets, _ := s.GetPets(ctx); len(pets) != 0 {
+			t.Errorf("expected rollback, got %d pets", len(pets))
+		}
+	})
+
+	t.Run("nested WithinTx joins the outer transaction", func(t *testing.T) {
+		s := newTestMemoryStore()
+		s.WithinTx(ctx, func(tx Tx) error {
+			tx.WithinTx(ctx, func(inner Tx) error {
+				_, err := inner.CreatePet(ctx, "Fido", birth, "golden-retriever")
+				return err
+			})
+			return errors.New("outer fails")
+		})
+		if pets, _ := s.GetPets(ctx); len(pets) != 0 {
+			t.Errorf("inner write must be rolled back with the outer tx, got %d pets", len(pets))
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+)
+
+// migrationsFS contiene los scripts SQL de migración de cada dialecto, que se aplican en
+// orden por nombre. Todos los dialectos comparten las mismas versiones.
+//
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationsFS embed.FS
+
+// Dialect identifica el dialecto SQL de un conjunto de migraciones.
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// Migration es un script de migración identificado por su nombre de archivo sin extensión.
+type Migration struct {
+	Version string
+	SQL     string
+}
+
+// Migrations devuelve las migraciones embebidas del dialecto ordenadas por versión.
+func Migrations(d Dialect) ([]Migration, error) {
+	dir := "migrations/" + string(d)
+	entries, err := fs.ReadDir(migrationsFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		content, err := fs.ReadFile(migrationsFS, dir+"/"+e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", e.Name(), err)
+		}
+		migrations = append(migrations, Migration{
+			Version: strings.TrimSuffix(e.Name(), ".sql"),
+			SQL:     string(content),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrate aplica las migraciones pendientes, cada una en su propia transacción,
+// y registra las aplicadas en la tabla schema_migrations.
+func (s *PostgresStore) Migrate() error {
+	return migrate(s.db, DialectPostgres)
+}
+
+// migrate usa solo SQL común a todos los dialectos; los scripts son los del dialecto d.
+func migrate(db *sql.DB, d Dialect) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	migrations, err := Migrations(d)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		var applied bool
+		err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version=$1)", m.Version).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", m.Version, err)
+		}
+		if applied {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %s: %w", m.Version, err)
+		}
+		if _, err := tx.Exec(m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %s: %w", m.Version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", m.Version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %s: %w", m.Version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %s: %w", m.Version, err)
+		}
+		log.Printf("Migración aplicada: %s", m.Version)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS breeds (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    temperament TEXT,
+    origin VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS pets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    birth DATE NOT NULL,
+    breed_id VARCHAR(255) NOT NULL REFERENCES breeds(id)
+);
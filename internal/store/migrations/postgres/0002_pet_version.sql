@@ -0,0 +1,3 @@
+-- Control de concurrencia optimista: cada UPDATE incrementa version.
+ALTER TABLE pets ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE pets ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
@@ -0,0 +1,5 @@
+-- Tamaño de la raza: ajusta la edad equivalente humana y las etapas de vida de la mascota.
+ALTER TABLE breeds ADD COLUMN IF NOT EXISTS size VARCHAR(16) NOT NULL DEFAULT 'medium'
+    CHECK (size IN ('small', 'medium', 'large', 'giant'));
+
+UPDATE breeds SET size = 'large' WHERE id IN ('golden-retriever', 'german-shepherd', 'labrador-retriever');
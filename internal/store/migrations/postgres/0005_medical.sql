@@ -0,0 +1,26 @@
+-- Historial médico: visitas al veterinario y enfermedades/alergias de cada mascota.
+CREATE TABLE IF NOT EXISTS vet_visits (
+    id UUID PRIMARY KEY,
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    visit_date DATE NOT NULL,
+    clinic VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    diagnosis TEXT NOT NULL DEFAULT '',
+    treatments JSONB NOT NULL DEFAULT '[]',
+    attachments JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS vet_visits_pet_idx ON vet_visits (pet_id, visit_date);
+
+CREATE TABLE IF NOT EXISTS pet_conditions (
+    id UUID PRIMARY KEY,
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('condition', 'allergy')),
+    name VARCHAR(255) NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    since DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS pet_conditions_pet_idx ON pet_conditions (pet_id, created_at);
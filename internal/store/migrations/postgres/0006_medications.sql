@@ -0,0 +1,24 @@
+-- Pautas de medicación y tomas anotadas. Las tomas previstas no se guardan: se calculan a
+-- partir de frequency (una regla RRULE), time_zone, starts_at y ends_at.
+CREATE TABLE IF NOT EXISTS medications (
+    id UUID PRIMARY KEY,
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    drug VARCHAR(255) NOT NULL,
+    dosage VARCHAR(255) NOT NULL,
+    frequency VARCHAR(255) NOT NULL,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS medications_pet_idx ON medications (pet_id, created_at);
+
+CREATE TABLE IF NOT EXISTS medication_doses (
+    medication_id UUID NOT NULL REFERENCES medications(id) ON DELETE CASCADE,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('given', 'skipped')),
+    note TEXT NOT NULL DEFAULT '',
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (medication_id, scheduled_at)
+);
@@ -0,0 +1,3 @@
+-- Índice de la sincronización: el token y los cambios se leen de las entradas de mascotas
+-- de audit_log por orden de ID.
+CREATE INDEX IF NOT EXISTS audit_log_changes_idx ON audit_log (entity_type, id);
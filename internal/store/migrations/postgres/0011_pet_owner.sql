@@ -0,0 +1,2 @@
+-- Sujeto autenticado que creó la mascota; '' en las creadas sin sujeto y en las anteriores.
+ALTER TABLE pets ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,15 @@
+-- Mismo esquema que migrations/postgres/0001_init.sql. El UUID de las mascotas lo genera
+-- la aplicación, porque SQLite no tiene gen_random_uuid().
+CREATE TABLE IF NOT EXISTS breeds (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    temperament TEXT,
+    origin TEXT
+);
+
+CREATE TABLE IF NOT EXISTS pets (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    birth DATE NOT NULL,
+    breed_id TEXT NOT NULL REFERENCES breeds(id)
+);
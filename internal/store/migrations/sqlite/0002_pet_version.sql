@@ -0,0 +1,4 @@
+-- Control de concurrencia optimista: cada UPDATE incrementa version.
+-- SQLite no admite un DEFAULT no constante en ADD COLUMN; el store siempre escribe updated_at.
+ALTER TABLE pets ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE pets ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
@@ -0,0 +1,27 @@
+-- Historial médico: visitas al veterinario y enfermedades/alergias de cada mascota.
+-- treatments y attachments guardan JSON como texto.
+CREATE TABLE IF NOT EXISTS vet_visits (
+    id TEXT PRIMARY KEY,
+    pet_id TEXT NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    visit_date DATE NOT NULL,
+    clinic TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    diagnosis TEXT NOT NULL DEFAULT '',
+    treatments TEXT NOT NULL DEFAULT '[]',
+    attachments TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS vet_visits_pet_idx ON vet_visits (pet_id, visit_date);
+
+CREATE TABLE IF NOT EXISTS pet_conditions (
+    id TEXT PRIMARY KEY,
+    pet_id TEXT NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('condition', 'allergy')),
+    name TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    since DATE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS pet_conditions_pet_idx ON pet_conditions (pet_id, created_at);
@@ -0,0 +1,25 @@
+-- Pautas de medicación y tomas anotadas. Las tomas previstas no se guardan: se calculan a
+-- partir de frequency (una regla RRULE), time_zone, starts_at y ends_at.
+-- Las fechas se guardan siempre en UTC, así que se pueden comparar como texto.
+CREATE TABLE IF NOT EXISTS medications (
+    id TEXT PRIMARY KEY,
+    pet_id TEXT NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    drug TEXT NOT NULL,
+    dosage TEXT NOT NULL,
+    frequency TEXT NOT NULL,
+    time_zone TEXT NOT NULL DEFAULT 'UTC',
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS medications_pet_idx ON medications (pet_id, created_at);
+
+CREATE TABLE IF NOT EXISTS medication_doses (
+    medication_id TEXT NOT NULL REFERENCES medications(id) ON DELETE CASCADE,
+    scheduled_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('given', 'skipped')),
+    note TEXT NOT NULL DEFAULT '',
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (medication_id, scheduled_at)
+);
@@ -0,0 +1,2 @@
+-- Fecha en que vence la próxima dosis de la vacuna puesta en la visita, para los recordatorios.
+ALTER TABLE vet_visits ADD COLUMN next_due DATE;
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// AppStore es lo que necesitan los binarios (la API y dogctl) de cualquier implementación
+// del store.
+type AppStore interface {
+	BreedStore
+	BreedWriter
+	PetStore
+	AuditStore
+	MedicalStore
+	MedicationStore
+	JobStore
+	WebhookStore
+	SyncStore
+	Transactor
+	Migrate() error
+	Close() error
+}
+
+// Open crea el store del driver indicado: "postgres" (lib/pq), "pgx" o "sqlite". Con
+// Postgres reintenta la conexión mientras la base de datos arranca (ver PostgresConfig.Connect).
+func Open(ctx context.Context, driver string, pg PostgresConfig, sqlitePath string) (AppStore, error) {
+	switch driver {
+	case "sqlite":
+		return OpenSQLite(sqlitePath)
+	case "postgres", "pgx":
+		if pg.ConnString == "" {
+			return nil, fmt.Errorf("missing connection string for the %s driver", driver)
+		}
+		if driver == "pgx" {
+			return OpenPgx(ctx, pg)
+		}
+		return OpenPostgres(ctx, pg)
+	default:
+		return nil, fmt.Errorf("unknown store driver %q", driver)
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// KindEvent es el tipo de trabajo del outbox de eventos. Cada cambio auditado de una
+// mascota o una raza guarda, en la misma transacción que el cambio y su entrada de
+// audit_log, un trabajo con el events.Event como Payload, de modo que el evento no se
+// pierde aunque el proceso muera justo después de confirmar. El trabajo solo se guarda si
+// hay algún webhook: son sus únicos destinatarios (ver webhooks.Dispatcher).
+const KindEvent = "event"
+
+// eventJobMaxAttempts es el máximo de intentos de repartir un evento entre los webhooks.
+const eventJobMaxAttempts = 5
+
+const selectHasWebhooks = "SELECT EXISTS (SELECT 1 FROM webhooks)"
+
+// newEventJob crea el trabajo del outbox para un cambio auditado. after es la entidad
+// después del cambio (nil en los borrados).
+func newEventJob(ctx context.Context, entityType, entityID, action string, after any) (types.Job, error) {
+	e, err := events.New(ctx, events.ChangeType(entityType, action), entityID, after)
+	if err != nil {
+		return types.Job{}, err
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return types.Job{}, fmt.Errorf("failed to marshal %s event: %w", e.Type, err)
+	}
+	return types.Job{
+		Kind:        KindEvent,
+		Key:         KindEvent + ":" + e.ID,
+		Payload:     payload,
+		RunAt:       e.OccurredAt,
+		MaxAttempts: eventJobMaxAttempts,
+	}, nil
+}
+
+// insertEventJob guarda el trabajo del outbox de un cambio si hay algún webhook. Debe
+// llamarse con la misma transacción que aplicó el cambio.
+func insertEventJob(ctx context.Context, q querier, entityType, entityID, action string, after any) error {
+	var hasWebhooks bool
+	if err := q.QueryRowContext(ctx, selectHasWebhooks).Scan(&hasWebhooks); err != nil {
+		return fmt.Errorf("failed to check for webhooks: %w", translateError(err))
+	}
+	if !hasWebhooks {
+		return nil
+	}
+	job, err := newEventJob(ctx, entityType, entityID, action, after)
+	if err != nil {
+		return err
+	}
+	_, err = enqueueJob(ctx, q, job, pgNow())
+	return err
+}
@@ -0,0 +1,904 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// PgxStore implementa los stores sobre pgx/pgxpool. Usa las mismas consultas y el mismo
+// esquema que PostgresStore, pero cada conexión prepara y cachea sus sentencias, y los
+// UUID y fechas viajan con sus tipos nativos de Postgres en formato binario.
+type PgxStore struct {
+	pgxQueries
+	pool *pgxpool.Pool
+}
+
+// pgxQueries implementa las consultas sobre el pool o sobre una transacción en curso,
+// igual que pgQueries en PostgresStore.
+type pgxQueries struct {
+	q pgxQuerier
+}
+
+// pgxTx es la vista transaccional que recibe la función de PgxStore.WithinTx.
+type pgxTx struct {
+	pgxQueries
+}
+
+// pgxQuerier es la parte común de *pgxpool.Pool y pgx.Tx.
+type pgxQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// pgxStatementCacheCapacity alcanza de sobra para todas las consultas del store.
+const pgxStatementCacheCapacity = 128
+
+// OpenPgx crea un PgxStore con la misma configuración de pool, reintentos y circuit
+// breaker que OpenPostgres.
+func OpenPgx(ctx context.Context, cfg PostgresConfig) (*PgxStore, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.ConnString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	poolCfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	poolCfg.ConnConfig.StatementCacheCapacity = pgxStatementCacheCapacity
+	if cfg.Pool.MaxOpenConns > 0 {
+		poolCfg.MaxConns = int32(cfg.Pool.MaxOpenConns)
+	}
+	if cfg.Pool.ConnMaxLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.Pool.ConnMaxLifetime
+	}
+	if cfg.Pool.ConnMaxIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.Pool.ConnMaxIdleTime
+	}
+
+	connCfg := poolCfg.ConnConfig.Copy()
+	if err := waitForDB(ctx, func(ctx context.Context) error {
+		conn, err := pgx.ConnectConfig(ctx, connCfg)
+		if err != nil {
+			return err
+		}
+		return conn.Close(ctx)
+	}, cfg.Connect); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	// pgxpool no usa database/sql, así que el circuit breaker se aplica al abrir el socket.
+	breaker := newCircuitBreaker(cfg.Breaker)
+	dial := poolCfg.ConnConfig.DialFunc
+	poolCfg.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if err := breaker.allow(); err != nil {
+			return nil, err
+		}
+		conn, err := dial(ctx, network, addr)
+		breaker.record(err)
+		return conn, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", translateError(err))
+	}
+
+	log.Println("Conectado exitosamente a PostgreSQL (pgx)!")
+	return &PgxStore{pgxQueries: pgxQueries{q: pool}, pool: pool}, nil
+}
+
+func (s *PgxStore) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+// Migrate aplica las mismas migraciones que PostgresStore.Migrate.
+func (s *PgxStore) Migrate() error {
+	db := stdlib.OpenDBFromPool(s.pool)
+	defer db.Close()
+	return migrate(db, DialectPostgres)
+}
+
+// WithinTx ejecuta fn dentro de una transacción: si fn devuelve error (o entra en pánico)
+// se hace rollback; si no, commit.
+func (s *PgxStore) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	return s.withTx(ctx, func(tx pgx.Tx) error {
+		return fn(&pgxTx{pgxQueries{q: tx}})
+	})
+}
+
+// WithinTx dentro de una transacción reutiliza la transacción en curso.
+func (t *pgxTx) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	return fn(t)
+}
+
+// withTx ejecuta fn en la transacción en curso o, si no hay ninguna, en una nueva.
+func (s *pgxQueries) withTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	switch q := s.q.(type) {
+	case pgx.Tx:
+		return fn(q)
+	case *pgxpool.Pool:
+		tx, err := q.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", translateError(err))
+		}
+		// Rollback después de un Commit correcto no hace nada; cubre errores y pánicos.
+		defer tx.Rollback(context.WithoutCancel(ctx))
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", translateError(err))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported querier %T", q)
+	}
+}
+
+// BREEDS
+func (s *pgxQueries) GetBreeds(ctx context.Context) ([]types.Breed, error) {
+	rows, err := s.q.Query(ctx, selectBreeds+orderBreeds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query breeds: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	var breeds []types.Breed
+	for rows.Next() {
+		var breed types.Breed
+		if err := rows.Scan(&breed.ID, &breed.Name, &breed.Temperament, &breed.Origin, &breed.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan breed: %w", translateError(err))
+		}
+		breeds = append(breeds, breed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return breeds, nil
+}
+
+func (s *pgxQueries) GetBreedByID(ctx context.Context, id types.BreedID) (*types.Breed, error) {
+	var breed types.Breed
+	err := s.q.QueryRow(ctx, selectBreedByID, id).Scan(&breed.ID, &breed.Name, &breed.Temperament, &breed.Origin, &breed.Size)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("query error for breed ID %s: %w", id, translateError(err))
+	}
+	return &breed, nil
+}
+
+func (s *pgxQueries) UpsertBreed(ctx context.Context, b types.Breed) (UpsertResult, error) {
+	result := Unchanged
+	err := s.withTx(ctx, func(tx pgx.Tx) error {
+		before, err := (&pgxQueries{q: tx}).GetBreedByID(ctx, b.ID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			if _, err := tx.Exec(ctx, insertBreed, b.ID, b.Name, b.Temperament, b.Origin, b.Size); err != nil {
+				return fmt.Errorf("failed to insert breed %s: %w", b.ID, translateError(err))
+			}
+			result = Inserted
+			return insertPgxAudit(ctx, tx, audit.EntityBreed, b.ID.String(), audit.ActionCreate, nil, b)
+		case err != nil:
+			return err
+		case *before == b:
+			return nil
+		}
+		if _, err := tx.Exec(ctx, updateBreed, b.ID, b.Name, b.Temperament, b.Origin, b.Size); err != nil {
+			return fmt.Errorf("failed to update breed %s: %w", b.ID, translateError(err))
+		}
+		result = Updated
+		return insertPgxAudit(ctx, tx, audit.EntityBreed, b.ID.String(), audit.ActionUpdate, before, b)
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// PETS
+func (s *pgxQueries) GetPets(ctx context.Context) ([]types.Pet, error) {
+	rows, err := s.q.Query(ctx, selectPets+orderPets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pets: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	var pets []types.Pet
+	for rows.Next() {
+		pet, err := scanPgxPet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pet or breed: %w", translateError(err))
+		}
+		pets = append(pets, *pet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return pets, nil
+}
+
+func (s *pgxQueries) GetPetByID(ctx context.Context, id types.PetID) (*types.Pet, error) {
+	return getPgxPetByID(ctx, s.q, id, false)
+}
+
+// getPgxPetByID lee una mascota; con forUpdate bloquea la fila hasta el fin de la transacción.
+func getPgxPetByID(ctx context.Context, q pgxQuerier, id types.PetID, forUpdate bool) (*types.Pet, error) {
+	uuid, err := pgUUID(id)
+	if err != nil {
+		return nil, err
+	}
+	query := selectPets + `
+		WHERE
+			p.id=$1
+	`
+	if forUpdate {
+		query += " FOR UPDATE OF p"
+	}
+	pet, err := scanPgxPet(q.QueryRow(ctx, query, uuid))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("query error for pet ID %s: %w", id, translateError(err))
+	}
+	return pet, nil
+}
+
+func (s *pgxQueries) CreatePet(ctx context.Context, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	var pet *types.Pet
+	err := s.withTx(ctx, func(tx pgx.Tx) error {
+		if err := lockPgxPetChanges(ctx, tx); err != nil {
+			return err
+		}
+		breed, err := (&pgxQueries{q: tx}).GetBreedByID(ctx, breedID)
+		if err != nil {
+			return fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+		}
+
+		pet = &types.Pet{Name: name, Birth: dateOnly(birth), Breed: *breed, Owner: audit.FromContext(ctx).Subject}
+		var id pgtype.UUID
+		err = tx.QueryRow(ctx, insertPet, name, pgDate(birth), breedID, pet.Owner).Scan(&id, &pet.Version, &pet.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert pet and get ID: %w", translateError(err))
+		}
+		pet.ID = types.PetID(id.String())
+
+		return insertPgxAudit(ctx, tx, audit.EntityPet, pet.ID.String(), audit.ActionCreate, nil, pet)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pet, nil
+}
+
+// UpdatePet hace el mismo compare-and-swap sobre version que PostgresStore.UpdatePet.
+func (s *pgxQueries) UpdatePet(ctx context.Context, id types.PetID, expectedVersion int64, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	var pet *types.Pet
+	err := s.withTx(ctx, func(tx pgx.Tx) error {
+		if err := lockPgxPetChanges(ctx, tx); err != nil {
+			return err
+		}
+		before, err := getPgxPetByID(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != AnyVersion && before.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		breed, err := (&pgxQueries{q: tx}).GetBreedByID(ctx, breedID)
+		if err != nil {
+			return fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+		}
+
+		uuid, err := pgUUID(id)
+		if err != nil {
+			return err
+		}
+		pet = &types.Pet{ID: id, Name: name, Birth: dateOnly(birth), Breed: *breed, Owner: before.Owner}
+		err = tx.QueryRow(ctx, updatePet, uuid, expectedVersion, name, pgDate(birth), breedID).Scan(&pet.Version, &pet.UpdatedAt)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrVersionConflict
+		case err != nil:
+			return fmt.Errorf("failed to update pet %s: %w", id, translateError(err))
+		}
+
+		return insertPgxAudit(ctx, tx, audit.EntityPet, id.String(), audit.ActionUpdate, before, pet)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pet, nil
+}
+
+func (s *pgxQueries) DeletePet(ctx context.Context, id types.PetID, expectedVersion int64) (*types.Pet, error) {
+	var before *types.Pet
+	err := s.withTx(ctx, func(tx pgx.Tx) error {
+		if err := lockPgxPetChanges(ctx, tx); err != nil {
+			return err
+		}
+		var err error
+		before, err = getPgxPetByID(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != AnyVersion && before.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		uuid, err := pgUUID(id)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, deletePet, uuid, expectedVersion)
+		if err != nil {
+			return fmt.Errorf("failed to delete pet %s: %w", id, translateError(err))
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrVersionConflict
+		}
+
+		return insertPgxAudit(ctx, tx, audit.EntityPet, id.String(), audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+// AUDIT
+
+// insertPgxAudit es insertAudit para pgx: registra el cambio en audit_log y su evento en
+// el outbox, en la transacción tx.
+func insertPgxAudit(ctx context.Context, tx pgx.Tx, entityType, entityID, action string, before, after any) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	meta := audit.FromContext(ctx)
+	_, err = tx.Exec(ctx, insertAuditEntry, entityType, entityID, action, meta.Actor, meta.RequestID, beforeJSON, afterJSON)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry for %s %s: %w", entityType, entityID, translateError(err))
+	}
+
+	var hasWebhooks bool
+	if err := tx.QueryRow(ctx, selectHasWebhooks).Scan(&hasWebhooks); err != nil {
+		return fmt.Errorf("failed to check for webhooks: %w", translateError(err))
+	}
+	if !hasWebhooks {
+		return nil
+	}
+	job, err := newEventJob(ctx, entityType, entityID, action, after)
+	if err != nil {
+		return err
+	}
+	j := newJob(job, pgNow())
+	if _, err := tx.Exec(ctx, insertJob, j.Kind, j.Key, string(j.Payload), j.RunAt, j.MaxAttempts, j.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert job %s: %w", j.Key, translateError(err))
+	}
+	return nil
+}
+
+// GetAuditHistory devuelve los cambios de una entidad en orden cronológico.
+func (s *pgxQueries) GetAuditHistory(ctx context.Context, entityType, entityID string) ([]types.AuditEntry, error) {
+	rows, err := s.q.Query(ctx, selectAuditHistory, entityType, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	entries := []types.AuditEntry{}
+	for rows.Next() {
+		var e types.AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.EntityType, &e.EntityID, &e.Action, &e.Actor, &e.RequestID, &before, &after, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", translateError(err))
+		}
+		if before != nil {
+			e.Before = before
+		}
+		if after != nil {
+			e.After = after
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return entries, nil
+}
+
+// MEDICAL
+func (s *pgxQueries) AddVetVisit(ctx context.Context, visit types.VetVisit) (*types.VetVisit, error) {
+	v := newVetVisit(visit, pgNow())
+	petID, err := pgUUID(v.PetID)
+	if err != nil {
+		return nil, err
+	}
+	treatments, err := json.Marshal(v.Treatments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal treatments: %w", err)
+	}
+	attachments, err := json.Marshal(v.Attachments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal attachments: %w", err)
+	}
+	var nextDue pgtype.Date
+	if v.NextDue != nil {
+		nextDue = pgDate(*v.NextDue)
+	}
+
+	err = s.withTx(ctx, func(tx pgx.Tx) error {
+		if err := pgxPetExists(ctx, tx, petID, v.PetID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, insertVetVisit, v.ID, petID, pgDate(v.Date),
+			v.Clinic, v.Reason, v.Diagnosis, string(treatments), string(attachments), nextDue, v.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert vet visit: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (s *pgxQueries) GetVetVisits(ctx context.Context, petID types.PetID) ([]types.VetVisit, error) {
+	uuid, err := pgUUID(petID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pgxPetExists(ctx, s.q, uuid, petID); err != nil {
+		return nil, err
+	}
+	rows, err := s.q.Query(ctx, selectVetVisits, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vet visits: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	visits := []types.VetVisit{}
+	for rows.Next() {
+		v := types.VetVisit{PetID: petID}
+		var id, pet pgtype.UUID
+		var date, nextDue pgtype.Date
+		var treatments, attachments []byte
+		if err := rows.Scan(&id, &pet, &date, &v.Clinic, &v.Reason, &v.Diagnosis, &treatments, &attachments, &nextDue, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan vet visit: %w", translateError(err))
+		}
+		v.ID = id.String()
+		v.Date = date.Time
+		if nextDue.Valid {
+			v.NextDue = &nextDue.Time
+		}
+		if err := unmarshalVisitLists(&v, treatments, attachments); err != nil {
+			return nil, err
+		}
+		visits = append(visits, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return visits, nil
+}
+
+func (s *pgxQueries) AddCondition(ctx context.Context, cond types.Condition) (*types.Condition, error) {
+	c := newCondition(cond, pgNow())
+	petID, err := pgUUID(c.PetID)
+	if err != nil {
+		return nil, err
+	}
+	var since pgtype.Date
+	if c.Since != nil {
+		since = pgDate(*c.Since)
+	}
+
+	err = s.withTx(ctx, func(tx pgx.Tx) error {
+		if err := pgxPetExists(ctx, tx, petID, c.PetID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, insertCondition, c.ID, petID, string(c.Kind), c.Name, c.Notes, since, c.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert condition: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *pgxQueries) GetConditions(ctx context.Context, petID types.PetID) ([]types.Condition, error) {
+	uuid, err := pgUUID(petID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pgxPetExists(ctx, s.q, uuid, petID); err != nil {
+		return nil, err
+	}
+	rows, err := s.q.Query(ctx, selectConditions, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conditions: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	conditions := []types.Condition{}
+	for rows.Next() {
+		c := types.Condition{PetID: petID}
+		var id, pet pgtype.UUID
+		var kind string
+		var since pgtype.Date
+		if err := rows.Scan(&id, &pet, &kind, &c.Name, &c.Notes, &since, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan condition: %w", translateError(err))
+		}
+		c.ID = id.String()
+		c.Kind = types.ConditionKind(kind)
+		if since.Valid {
+			c.Since = &since.Time
+		}
+		conditions = append(conditions, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return conditions, nil
+}
+
+// MEDICATIONS
+func (s *pgxQueries) AddMedication(ctx context.Context, med types.Medication) (*types.Medication, error) {
+	m := newMedication(med, pgNow())
+	petID, err := pgUUID(m.PetID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.withTx(ctx, func(tx pgx.Tx) error {
+		if err := pgxPetExists(ctx, tx, petID, m.PetID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, insertMedication, m.ID, petID, m.Drug, m.Dosage, m.Frequency, m.TimeZone, m.Start, m.End, m.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert medication: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *pgxQueries) GetMedications(ctx context.Context, petID types.PetID) ([]types.Medication, error) {
+	uuid, err := pgUUID(petID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pgxPetExists(ctx, s.q, uuid, petID); err != nil {
+		return nil, err
+	}
+	rows, err := s.q.Query(ctx, selectMedications+" WHERE pet_id=$1"+orderMedications, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query medications: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	meds := []types.Medication{}
+	for rows.Next() {
+		m, err := scanPgxMedication(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan medication: %w", translateError(err))
+		}
+		meds = append(meds, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return meds, nil
+}
+
+func (s *pgxQueries) GetMedication(ctx context.Context, petID types.PetID, id string) (*types.Medication, error) {
+	uuid, err := pgUUID(petID)
+	if err != nil {
+		return nil, err
+	}
+	m, err := scanPgxMedication(s.q.QueryRow(ctx, selectMedications+" WHERE id=$1 AND pet_id=$2", id, uuid))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("query error for medication ID %s: %w", id, translateError(err))
+	}
+	return m, nil
+}
+
+func (s *pgxQueries) RecordDose(ctx context.Context, dose types.DoseRecord) (*types.DoseRecord, error) {
+	d := newDoseRecord(dose, pgNow())
+	err := s.withTx(ctx, func(tx pgx.Tx) error {
+		var one int
+		err := tx.QueryRow(ctx, selectMedicationExists, d.MedicationID).Scan(&one)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrNotFound
+		case err != nil:
+			return fmt.Errorf("query error for medication ID %s: %w", d.MedicationID, translateError(err))
+		}
+		_, err = tx.Exec(ctx, insertDose, d.MedicationID, d.ScheduledAt, string(d.Status), d.Note, d.RecordedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert dose: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *pgxQueries) GetDoseRecords(ctx context.Context, petID types.PetID, from, to time.Time) ([]types.DoseRecord, error) {
+	uuid, err := pgUUID(petID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.q.Query(ctx, selectDoseRecords, uuid, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query doses: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	records := []types.DoseRecord{}
+	for rows.Next() {
+		var d types.DoseRecord
+		var id pgtype.UUID
+		var status string
+		if err := rows.Scan(&id, &d.ScheduledAt, &status, &d.Note, &d.RecordedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dose: %w", translateError(err))
+		}
+		d.MedicationID = id.String()
+		d.ScheduledAt = d.ScheduledAt.UTC()
+		d.Status = types.DoseStatus(status)
+		records = append(records, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return records, nil
+}
+
+// scanPgxMedication lee una fila de selectMedications con los tipos nativos de pgx.
+func scanPgxMedication(row pgx.Row) (*types.Medication, error) {
+	var m types.Medication
+	var id, petID pgtype.UUID
+	if err := row.Scan(&id, &petID, &m.Drug, &m.Dosage, &m.Frequency, &m.TimeZone, &m.Start, &m.End, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	m.ID = id.String()
+	m.PetID = types.PetID(petID.String())
+	m.Start = m.Start.UTC()
+	if m.End != nil {
+		e := m.End.UTC()
+		m.End = &e
+	}
+	return &m, nil
+}
+
+// JOBS
+func (s *pgxQueries) EnqueueJob(ctx context.Context, job types.Job) (bool, error) {
+	j := newJob(job, pgNow())
+	tag, err := s.q.Exec(ctx, insertJob, j.Kind, j.Key, string(j.Payload), j.RunAt, j.MaxAttempts, j.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert job %s: %w", j.Key, translateError(err))
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *pgxQueries) ClaimJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]types.Job, error) {
+	now = now.UTC().Truncate(time.Microsecond)
+	rows, err := s.q.Query(ctx, claimJobs, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	jobs := []types.Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", translateError(err))
+		}
+		jobs = append(jobs, *j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+func (s *pgxQueries) CompleteJob(ctx context.Context, id int64) error {
+	return s.execOne(ctx, fmt.Sprintf("update job %d", id), completeJob, id, pgNow())
+}
+
+func (s *pgxQueries) RetryJob(ctx context.Context, id int64, runAt time.Time, lastError string) error {
+	return s.execOne(ctx, fmt.Sprintf("update job %d", id), retryJob, id, runAt.UTC(), lastError, pgNow())
+}
+
+func (s *pgxQueries) FailJob(ctx context.Context, id int64, lastError string) error {
+	return s.execOne(ctx, fmt.Sprintf("update job %d", id), failJob, id, lastError, pgNow())
+}
+
+// WEBHOOKS
+func (s *pgxQueries) CreateWebhook(ctx context.Context, webhook types.Webhook) (*types.Webhook, error) {
+	w := newWebhook(webhook, pgNow())
+	filters, err := json.Marshal(w.Events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook events: %w", err)
+	}
+	if _, err := s.q.Exec(ctx, insertWebhook, w.ID, w.URL, string(filters), w.Secret, w.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert webhook: %w", translateError(err))
+	}
+	return &w, nil
+}
+
+func (s *pgxQueries) GetWebhooks(ctx context.Context) ([]types.Webhook, error) {
+	rows, err := s.q.Query(ctx, selectWebhooks+orderWebhooks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	webhooks := []types.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", translateError(err))
+		}
+		webhooks = append(webhooks, *w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return webhooks, nil
+}
+
+func (s *pgxQueries) GetWebhook(ctx context.Context, id string) (*types.Webhook, error) {
+	w, err := scanWebhook(s.q.QueryRow(ctx, selectWebhooks+" WHERE id=$1", id))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("query error for webhook ID %s: %w", id, translateError(err))
+	}
+	return w, nil
+}
+
+func (s *pgxQueries) DeleteWebhook(ctx context.Context, id string) error {
+	return s.execOne(ctx, "delete webhook "+id, deleteWebhook, id)
+}
+
+func (s *pgxQueries) AddDelivery(ctx context.Context, delivery types.WebhookDelivery) (*types.WebhookDelivery, error) {
+	d := newDelivery(delivery, pgNow())
+	err := s.withTx(ctx, func(tx pgx.Tx) error {
+		var one int
+		err := tx.QueryRow(ctx, selectWebhookExists, d.WebhookID).Scan(&one)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrNotFound
+		case err != nil:
+			return fmt.Errorf("query error for webhook ID %s: %w", d.WebhookID, translateError(err))
+		}
+		err = tx.QueryRow(ctx, insertDelivery, d.WebhookID, d.EventID, d.EventType, string(d.Payload), d.CreatedAt).Scan(&d.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert webhook delivery: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *pgxQueries) RecordDeliveryAttempt(ctx context.Context, id int64, status types.DeliveryStatus, responseStatus int, lastError string) error {
+	return s.execOne(ctx, fmt.Sprintf("update webhook delivery %d", id), updateDelivery, id, string(status), responseStatus, lastError, pgNow())
+}
+
+func (s *pgxQueries) GetDeliveries(ctx context.Context, webhookID string, limit int) ([]types.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	rows, err := s.q.Query(ctx, selectDeliveries, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", translateError(err))
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return deliveries, nil
+}
+
+// execOne es la versión pgx de execOne.
+func (s *pgxQueries) execOne(ctx context.Context, what string, query string, args ...any) error {
+	tag, err := s.q.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", what, translateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// pgxPetExists devuelve ErrNotFound si la mascota no existe.
+func pgxPetExists(ctx context.Context, q pgxQuerier, uuid pgtype.UUID, id types.PetID) error {
+	var one int
+	err := q.QueryRow(ctx, selectPetExists, uuid).Scan(&one)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrNotFound
+	case err != nil:
+		return fmt.Errorf("query error for pet ID %s: %w", id, translateError(err))
+	}
+	return nil
+}
+
+// scanPgxPet lee una fila de selectPets usando los tipos nativos de pgx para id y birth.
+func scanPgxPet(row pgx.Row) (*types.Pet, error) {
+	var pet types.Pet
+	var id pgtype.UUID
+	var birth pgtype.Date
+	err := row.Scan(&id, &pet.Name, &birth, &pet.Owner, &pet.Version, &pet.UpdatedAt,
+		&pet.Breed.ID, &pet.Breed.Name, &pet.Breed.Temperament, &pet.Breed.Origin, &pet.Breed.Size)
+	if err != nil {
+		return nil, err
+	}
+	pet.ID = types.PetID(id.String())
+	pet.Birth = birth.Time
+	return &pet, nil
+}
+
+// pgUUID convierte un PetID al tipo UUID de pgx, que se envía en binario (16 bytes).
+func pgUUID(id types.PetID) (pgtype.UUID, error) {
+	var u pgtype.UUID
+	if err := u.Scan(id.String()); err != nil {
+		return u, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	return u, nil
+}
+
+// pgDate envía solo la fecha, sin hora ni zona horaria, igual que la columna DATE.
+func pgDate(t time.Time) pgtype.Date {
+	return pgtype.Date{Time: dateOnly(t), Valid: true}
+}
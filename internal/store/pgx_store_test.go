@@ -0,0 +1,128 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// setupPgxTestDB conecta PgxStore a la base de datos de integración, o salta el test si no hay.
+func setupPgxTestDB(tb testing.TB) *PgxStore {
+	tb.Helper()
+	s, err := OpenPgx(ctx, PostgresConfig{ConnString: testConnString(tb)})
+	if err != nil {
+		tb.Fatalf("No se pudo conectar a la base de datos de prueba: %v", err)
+	}
+	tb.Cleanup(func() { s.Close() })
+	if err := s.Migrate(); err != nil {
+		tb.Fatalf("No se pudieron aplicar las migraciones de prueba: %v", err)
+	}
+	return s
+}
+
+func TestPgUUID(t *testing.T) {
+	const id = types.PetID("0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41")
+	u, err := pgUUID(id)
+	if err != nil {
+		t.Fatalf("pgUUID failed: %v", err)
+	}
+	if got := types.PetID(u.String()); got != id {
+		t.Errorf("round trip: expected %s, got %s", id, got)
+	}
+	if _, err := pgUUID("not-a-uuid"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestPgxStore(t *testing.T) {
+	s := setupPgxTestDB(t)
+	runIntegrationSuite(t, func(*testing.T) integrationStore { return s })
+}
+
+// Los benchmarks comparan PostgresStore (lib/pq, la consulta se analiza en cada llamada)
+// con PgxStore (sentencias preparadas y cacheadas por conexión) sobre la misma base de datos
+// y con la misma configuración de pool:
+//
+//	TEST_DB_CONN_STRING=... go test -run '^$' -bench . ./internal/store
+func benchmarkStores(b *testing.B) map[string]interface {
+	BreedStore
+	PetStore
+} {
+	cfg := DefaultPostgresConfig(testConnString(b))
+	pgxStore, err := OpenPgx(ctx, cfg)
+	if err != nil {
+		b.Fatalf("No se pudo conectar a la base de datos de prueba: %v", err)
+	}
+	b.Cleanup(func() { pgxStore.Close() })
+	if err := pgxStore.Migrate(); err != nil {
+		b.Fatalf("No se pudieron aplicar las migraciones de prueba: %v", err)
+	}
+	pqStore, err := OpenPostgres(ctx, cfg)
+	if err != nil {
+		b.Fatalf("No se pudo conectar a la base de datos de prueba: %v", err)
+	}
+	b.Cleanup(func() { pqStore.Close() })
+	return map[string]interface {
+		BreedStore
+		PetStore
+	}{"pq": pqStore, "pgx": pgxStore}
+}
+
+// deleteBenchPets borra al terminar el benchmark las mascotas que creó.
+func deleteBenchPets(b *testing.B, s PetStore, ids *[]types.PetID) {
+	b.Cleanup(func() {
+		for _, id := range *ids {
+			if _, err := s.DeletePet(ctx, id, AnyVersion); err != nil && !errors.Is(err, ErrNotFound) {
+				b.Errorf("DeletePet failed: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetPetByID(b *testing.B) {
+	for name, s := range benchmarkStores(b) {
+		pet, err := s.CreatePet(ctx, "Bench", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "poodle")
+		if err != nil {
+			b.Fatalf("CreatePet failed: %v", err)
+		}
+		deleteBenchPets(b, s, &[]types.PetID{pet.ID})
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := s.GetPetByID(ctx, pet.ID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetPets(b *testing.B) {
+	for name, s := range benchmarkStores(b) {
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := s.GetPets(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCreatePet(b *testing.B) {
+	birth := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, s := range benchmarkStores(b) {
+		var created []types.PetID
+		deleteBenchPets(b, s, &created)
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				pet, err := s.CreatePet(ctx, "Bench", birth, "poodle")
+				if err != nil {
+					b.Fatal(err)
+				}
+				created = append(created, pet.ID)
+			}
+		})
+	}
+}
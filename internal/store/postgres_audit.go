@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+const (
+	insertAuditEntry = `
+		INSERT INTO audit_log (entity_type, entity_id, action, actor, request_id, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	selectAuditHistory = `
+		SELECT id, entity_type, entity_id, action, actor, request_id, before, after, created_at
+		FROM audit_log
+		WHERE entity_type=$1 AND entity_id=$2
+		ORDER BY created_at, id
+	`
+)
+
+// insertAudit registra un cambio en audit_log y su evento en el outbox (ver KindEvent).
+// Debe llamarse con la misma transacción que aplicó el cambio, de modo que todo se
+// confirme o se descarte junto.
+func insertAudit(ctx context.Context, q querier, entityType, entityID, action string, before, after any) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	meta := audit.FromContext(ctx)
+	_, err = q.ExecContext(ctx, insertAuditEntry, entityType, entityID, action, meta.Actor, meta.RequestID, beforeJSON, afterJSON)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry for %s %s: %w", entityType, entityID, translateError(err))
+	}
+	return insertEventJob(ctx, q, entityType, entityID, action, after)
+}
+
+// auditJSON serializa un estado para las columnas JSONB (TEXT en SQLite); nil se guarda
+// como NULL. Se devuelve como string porque lib/pq envía los []byte como bytea.
+func auditJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit state: %w", err)
+	}
+	return string(b), nil
+}
+
+// GetAuditHistory devuelve los cambios de una entidad en orden cronológico.
+func (s *pgQueries) GetAuditHistory(ctx context.Context, entityType, entityID string) ([]types.AuditEntry, error) {
+	return getAuditHistory(ctx, s.q, entityType, entityID)
+}
+
+func getAuditHistory(ctx context.Context, q querier, entityType, entityID string) ([]types.AuditEntry, error) {
+	rows, err := q.QueryContext(ctx, selectAuditHistory, entityType, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	entries := []types.AuditEntry{}
+	for rows.Next() {
+		var e types.AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.EntityType, &e.EntityID, &e.Action, &e.Actor, &e.RequestID, &before, &after, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", translateError(err))
+		}
+		if before != nil {
+			e.Before = before
+		}
+		if after != nil {
+			e.After = after
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return entries, nil
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// testPostgres es el Postgres desechable que arrancan los tests cuando no se indica una base
+// de datos con TEST_DB_CONN_STRING. Se arranca una sola vez por paquete y TestMain lo detiene.
+var testPostgres struct {
+	once    sync.Once
+	pg      *embeddedpostgres.EmbeddedPostgres
+	connStr string
+	err     error
+}
+
+// testConnString devuelve la cadena de conexión de la base de datos de integración. Con
+// TEST_DB_CONN_STRING (o DB_CONN_STRING) se usa esa base de datos, que ya debe tener los datos
+// de db-setup-test; si no, se arranca un Postgres embebido con las migraciones y testFixtures.
+// Salta el test si no hay ninguna disponible, o con -short para no descargar los binarios.
+func testConnString(tb testing.TB) string {
+	tb.Helper()
+	if connStr := os.Getenv("TEST_DB_CONN_STRING"); connStr != "" {
+		return connStr
+	}
+	if connStr := os.Getenv("DB_CONN_STRING"); connStr != "" {
+		return connStr
+	}
+	if testing.Short() {
+		tb.Skip("TEST_DB_CONN_STRING no está configurada y -short no arranca el Postgres embebido")
+	}
+
+	testPostgres.once.Do(func() {
+		testPostgres.pg, testPostgres.connStr, testPostgres.err = startEmbeddedPostgres()
+	})
+	if testPostgres.err != nil {
+		tb.Skipf("TEST_DB_CONN_STRING no está configurada y no se pudo arrancar el Postgres embebido: %v", testPostgres.err)
+	}
+	return testPostgres.connStr
+}
+
+// startEmbeddedPostgres arranca un cluster nuevo en un directorio temporal, en un puerto libre.
+// Los binarios se descargan la primera vez y quedan en la caché del usuario para las siguientes.
+func startEmbeddedPostgres() (*embeddedpostgres.EmbeddedPostgres, string, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, "", err
+	}
+	runtime, err := os.MkdirTemp("", "dog-app-pg-")
+	if err != nil {
+		return nil, "", err
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		cache = os.TempDir()
+	}
+
+	cfg := embeddedpostgres.DefaultConfig().
+		Version(embeddedpostgres.V16).
+		Port(port).
+		Database("dog_app_db_test").
+		RuntimePath(runtime).
+		CachePath(filepath.Join(cache, "dog-app-bff", "embedded-postgres")).
+		StartTimeout(time.Minute).
+		Logger(io.Discard)
+	pg := embeddedpostgres.NewDatabase(cfg)
+	if err := pg.Start(); err != nil {
+		os.RemoveAll(runtime)
+		return nil, "", err
+	}
+
+	connStr := fmt.Sprintf("host=localhost port=%d user=postgres password=postgres dbname=dog_app_db_test sslmode=disable", port)
+	if err := seedPostgres(connStr); err != nil {
+		pg.Stop()
+		os.RemoveAll(runtime)
+		return nil, "", err
+	}
+	return pg, connStr, nil
+}
+
+func seedPostgres(connStr string) error {
+	s, err := NewPostgresStore(connStr)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if err := s.Migrate(); err != nil {
+		return err
+	}
+	_, err = Seed(context.Background(), s, testFixtures)
+	return err
+}
+
+// stopTestPostgres detiene el Postgres embebido, si se llegó a arrancar.
+func stopTestPostgres() {
+	if testPostgres.pg != nil {
+		testPostgres.pg.Stop()
+	}
+}
+
+func freePort() (uint32, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return uint32(l.Addr().(*net.TCPAddr).Port), nil
+}
+
+// isolatedTestDB devuelve una vista de PostgresStore sobre una transacción que se descarta al
+// terminar el test: cada test ve exactamente testFixtures y no deja rastro en la base de datos.
+func isolatedTestDB(t *testing.T) *isolatedStore {
+	t.Helper()
+	store := setupTestDB(t)
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("No se pudo abrir la transacción del test: %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	if _, err := tx.Exec("DELETE FROM audit_log; DELETE FROM pets; DELETE FROM breeds"); err != nil {
+		t.Fatalf("No se pudieron borrar los datos de la base de datos: %v", err)
+	}
+	s := &isolatedStore{pgTx: &pgTx{pgQueries{q: tx}}, tx: tx}
+	// La auditoría de la carga se borra: cada test empieza con el historial vacío.
+	if _, err := Seed(ctx, s, testFixtures); err != nil {
+		t.Fatalf("No se pudieron cargar los datos de prueba: %v", err)
+	}
+	if _, err := tx.Exec("DELETE FROM audit_log"); err != nil {
+		t.Fatalf("No se pudo vaciar la auditoría: %v", err)
+	}
+	return s
+}
+
+// isolatedStore implementa WithinTx con un savepoint, para que un rollback dentro del test
+// descarte solo lo hecho en esa llamada y no la transacción entera del test.
+type isolatedStore struct {
+	*pgTx
+	tx *sql.Tx
+}
+
+func (s *isolatedStore) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	if _, err := s.tx.ExecContext(ctx, "SAVEPOINT within_tx"); err != nil {
+		return translateError(err)
+	}
+	if err := fn(s); err != nil {
+		s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT within_tx")
+		return err
+	}
+	_, err := s.tx.ExecContext(ctx, "RELEASE SAVEPOINT within_tx")
+	return translateError(err)
+}
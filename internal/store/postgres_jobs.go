@@ -0,0 +1,163 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Consultas de la cola de trabajos, compartidas por PostgresStore, PgxStore y SQLiteStore.
+// Las fechas se pasan siempre en UTC: en SQLite se comparan como texto.
+const (
+	insertJob = `
+		INSERT INTO jobs (kind, key, payload, run_at, status, attempts, max_attempts, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'pending', 0, $5, $6, $6)
+		ON CONFLICT (key) DO NOTHING
+	`
+	jobColumns = "id, kind, key, payload, run_at, status, attempts, max_attempts, last_error, locked_until, created_at, updated_at"
+	// claimJobs reserva los trabajos vencidos. SKIP LOCKED deja que varias réplicas del
+	// BFF reclamen a la vez sin esperarse ni llevarse el mismo trabajo.
+	claimJobs = `
+		UPDATE jobs SET status='running', attempts=attempts+1, locked_until=$2, updated_at=$1
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE run_at <= $1 AND (status='pending' OR (status='running' AND locked_until <= $1))
+			ORDER BY run_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+	// claimJobsSQLite es claimJobs sin FOR UPDATE: SQLite solo admite un escritor a la vez.
+	claimJobsSQLite = `
+		UPDATE jobs SET status='running', attempts=attempts+1, locked_until=$2, updated_at=$1
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE run_at <= $1 AND (status='pending' OR (status='running' AND locked_until <= $1))
+			ORDER BY run_at, id
+			LIMIT $3
+		)
+		RETURNING ` + jobColumns
+	completeJob = "UPDATE jobs SET status='done', locked_until=NULL, updated_at=$2 WHERE id=$1"
+	retryJob    = "UPDATE jobs SET status='pending', run_at=$2, last_error=$3, locked_until=NULL, updated_at=$4 WHERE id=$1"
+	failJob     = "UPDATE jobs SET status='failed', last_error=$2, locked_until=NULL, updated_at=$3 WHERE id=$1"
+)
+
+// JOBS
+func (s *pgQueries) EnqueueJob(ctx context.Context, job types.Job) (bool, error) {
+	markWrite(ctx)
+	return enqueueJob(ctx, s.q, job, pgNow())
+}
+
+func (s *pgQueries) ClaimJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]types.Job, error) {
+	markWrite(ctx)
+	return claimJobsFrom(ctx, s.q, claimJobs, now.Truncate(time.Microsecond), lease, limit)
+}
+
+func (s *pgQueries) CompleteJob(ctx context.Context, id int64) error {
+	markWrite(ctx)
+	return execOne(ctx, s.q, fmt.Sprintf("update job %d", id), completeJob, id, pgNow())
+}
+
+func (s *pgQueries) RetryJob(ctx context.Context, id int64, runAt time.Time, lastError string) error {
+	markWrite(ctx)
+	return execOne(ctx, s.q, fmt.Sprintf("update job %d", id), retryJob, id, runAt.UTC(), lastError, pgNow())
+}
+
+func (s *pgQueries) FailJob(ctx context.Context, id int64, lastError string) error {
+	markWrite(ctx)
+	return execOne(ctx, s.q, fmt.Sprintf("update job %d", id), failJob, id, lastError, pgNow())
+}
+
+func enqueueJob(ctx context.Context, q querier, job types.Job, createdAt time.Time) (bool, error) {
+	j := newJob(job, createdAt)
+	res, err := q.ExecContext(ctx, insertJob, j.Kind, j.Key, string(j.Payload), j.RunAt, j.MaxAttempts, j.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert job %s: %w", j.Key, translateError(err))
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to insert job %s: %w", j.Key, translateError(err))
+	}
+	return n == 1, nil
+}
+
+func claimJobsFrom(ctx context.Context, q querier, query string, now time.Time, lease time.Duration, limit int) ([]types.Job, error) {
+	now = now.UTC()
+	rows, err := q.QueryContext(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	jobs := []types.Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", translateError(err))
+		}
+		jobs = append(jobs, *j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+// execOne ejecuta una sentencia que debe modificar una fila y devuelve ErrNotFound si no
+// modificó ninguna. what describe la operación en los mensajes de error.
+func execOne(ctx context.Context, q querier, what string, query string, args ...any) error {
+	res, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", what, translateError(err))
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to %s: %w", what, translateError(err))
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scanJob lee una fila con jobColumns, tanto de database/sql como de pgx.
+func scanJob(row interface{ Scan(dest ...any) error }) (*types.Job, error) {
+	var j types.Job
+	var payload []byte
+	var status string
+	if err := row.Scan(&j.ID, &j.Kind, &j.Key, &payload, &j.RunAt, &status, &j.Attempts, &j.MaxAttempts,
+		&j.LastError, &j.LockedUntil, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+	j.Status = types.JobStatus(status)
+	j.RunAt = j.RunAt.UTC()
+	if j.LockedUntil != nil {
+		l := j.LockedUntil.UTC()
+		j.LockedUntil = &l
+	}
+	return &j, nil
+}
+
+// sortJobs ordena los trabajos reclamados: RETURNING no garantiza ningún orden.
+func sortJobs(jobs []types.Job) {
+	slices.SortFunc(jobs, func(a, b types.Job) int {
+		return cmp.Or(a.RunAt.Compare(b.RunAt), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+// newJob completa el trabajo tal como lo guardan los stores.
+func newJob(j types.Job, createdAt time.Time) types.Job {
+	j.RunAt = j.RunAt.UTC().Truncate(time.Microsecond)
+	if len(j.Payload) == 0 {
+		j.Payload = []byte("{}")
+	}
+	j.MaxAttempts = max(j.MaxAttempts, 1)
+	j.Status = types.JobPending
+	j.CreatedAt = createdAt
+	j.UpdatedAt = createdAt
+	return j
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Consultas del historial médico, compartidas por PostgresStore, PgxStore y SQLiteStore.
+// El ID y created_at los pone el store, para que las tres implementaciones devuelvan lo
+// mismo que guardan sin depender de funciones propias de cada base de datos.
+const (
+	selectPetExists = "SELECT 1 FROM pets WHERE id=$1"
+	insertVetVisit  = `
+		INSERT INTO vet_visits (id, pet_id, visit_date, clinic, reason, diagnosis, treatments, attachments, next_due, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	selectVetVisits = `
+		SELECT id, pet_id, visit_date, clinic, reason, diagnosis, treatments, attachments, next_due, created_at
+		FROM vet_visits
+		WHERE pet_id=$1
+		ORDER BY visit_date, created_at, id
+	`
+	insertCondition = `
+		INSERT INTO pet_conditions (id, pet_id, kind, name, notes, since, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	selectConditions = `
+		SELECT id, pet_id, kind, name, notes, since, created_at
+		FROM pet_conditions
+		WHERE pet_id=$1
+		ORDER BY created_at, id
+	`
+)
+
+// MEDICAL
+func (s *pgQueries) AddVetVisit(ctx context.Context, visit types.VetVisit) (*types.VetVisit, error) {
+	markWrite(ctx)
+	return addVetVisit(ctx, s.q, visit, pgNow())
+}
+
+func (s *pgQueries) AddCondition(ctx context.Context, c types.Condition) (*types.Condition, error) {
+	markWrite(ctx)
+	return addCondition(ctx, s.q, c, pgNow())
+}
+
+func (s *pgQueries) GetVetVisits(ctx context.Context, petID types.PetID) ([]types.VetVisit, error) {
+	return getVetVisits(ctx, s.q, petID)
+}
+
+func (s *pgQueries) GetConditions(ctx context.Context, petID types.PetID) ([]types.Condition, error) {
+	return getConditions(ctx, s.q, petID)
+}
+
+func (s *PostgresStore) GetVetVisits(ctx context.Context, petID types.PetID) ([]types.VetVisit, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.VetVisit, error) {
+		return getVetVisits(ctx, q, petID)
+	})
+}
+
+func (s *PostgresStore) GetConditions(ctx context.Context, petID types.PetID) ([]types.Condition, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.Condition, error) {
+		return getConditions(ctx, q, petID)
+	})
+}
+
+// pgNow es la hora de registro que guarda Postgres: TIMESTAMPTZ tiene precisión de microsegundos.
+func pgNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// petExists devuelve ErrNotFound si la mascota no existe.
+func petExists(ctx context.Context, q querier, id types.PetID) error {
+	var one int
+	switch err := q.QueryRowContext(ctx, selectPetExists, id).Scan(&one); err {
+	case sql.ErrNoRows:
+		return ErrNotFound
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("query error for pet ID %s: %w", id, translateError(err))
+	}
+}
+
+func addVetVisit(ctx context.Context, q querier, visit types.VetVisit, createdAt time.Time) (*types.VetVisit, error) {
+	v := newVetVisit(visit, createdAt)
+	treatments, err := json.Marshal(v.Treatments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal treatments: %w", err)
+	}
+	attachments, err := json.Marshal(v.Attachments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal attachments: %w", err)
+	}
+	var nextDue any
+	if v.NextDue != nil {
+		nextDue = v.NextDue.Format(time.DateOnly)
+	}
+
+	err = withTx(ctx, q, func(tx *sql.Tx) error {
+		if err := petExists(ctx, tx, v.PetID); err != nil {
+			return err
+		}
+		// Los JSON van como string: lib/pq envía los []byte como bytea.
+		_, err := tx.ExecContext(ctx, insertVetVisit, v.ID, v.PetID, v.Date.Format(time.DateOnly),
+			v.Clinic, v.Reason, v.Diagnosis, string(treatments), string(attachments), nextDue, v.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert vet visit: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func getVetVisits(ctx context.Context, q querier, petID types.PetID) ([]types.VetVisit, error) {
+	if err := petExists(ctx, q, petID); err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, selectVetVisits, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vet visits: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	visits := []types.VetVisit{}
+	for rows.Next() {
+		var v types.VetVisit
+		var treatments, attachments []byte
+		var nextDue sql.NullTime
+		if err := rows.Scan(&v.ID, &v.PetID, &v.Date, &v.Clinic, &v.Reason, &v.Diagnosis, &treatments, &attachments, &nextDue, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan vet visit: %w", translateError(err))
+		}
+		if nextDue.Valid {
+			d := dateOnly(nextDue.Time)
+			v.NextDue = &d
+		}
+		if err := unmarshalVisitLists(&v, treatments, attachments); err != nil {
+			return nil, err
+		}
+		visits = append(visits, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return visits, nil
+}
+
+func addCondition(ctx context.Context, q querier, cond types.Condition, createdAt time.Time) (*types.Condition, error) {
+	c := newCondition(cond, createdAt)
+	var since any
+	if c.Since != nil {
+		since = c.Since.Format(time.DateOnly)
+	}
+
+	err := withTx(ctx, q, func(tx *sql.Tx) error {
+		if err := petExists(ctx, tx, c.PetID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, insertCondition, c.ID, c.PetID, c.Kind, c.Name, c.Notes, since, c.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert condition: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func getConditions(ctx context.Context, q querier, petID types.PetID) ([]types.Condition, error) {
+	if err := petExists(ctx, q, petID); err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, selectConditions, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conditions: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	conditions := []types.Condition{}
+	for rows.Next() {
+		var c types.Condition
+		var since sql.NullTime
+		if err := rows.Scan(&c.ID, &c.PetID, &c.Kind, &c.Name, &c.Notes, &since, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan condition: %w", translateError(err))
+		}
+		if since.Valid {
+			d := dateOnly(since.Time)
+			c.Since = &d
+		}
+		conditions = append(conditions, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return conditions, nil
+}
+
+// newVetVisit completa la visita tal como la guardan los stores: con ID, hora de registro,
+// la fecha sin hora y listas vacías en lugar de nil.
+func newVetVisit(v types.VetVisit, createdAt time.Time) types.VetVisit {
+	v.ID = newUUID()
+	v.Date = dateOnly(v.Date)
+	if v.NextDue != nil {
+		d := dateOnly(*v.NextDue)
+		v.NextDue = &d
+	}
+	v.CreatedAt = createdAt
+	if v.Treatments == nil {
+		v.Treatments = []string{}
+	}
+	if v.Attachments == nil {
+		v.Attachments = []types.Attachment{}
+	}
+	return v
+}
+
+// newCondition completa la condición tal como la guardan los stores.
+func newCondition(c types.Condition, createdAt time.Time) types.Condition {
+	c.ID = newUUID()
+	c.CreatedAt = createdAt
+	if c.Since != nil {
+		d := dateOnly(*c.Since)
+		c.Since = &d
+	}
+	return c
+}
+
+func unmarshalVisitLists(v *types.VetVisit, treatments, attachments []byte) error {
+	if err := json.Unmarshal(treatments, &v.Treatments); err != nil {
+		return fmt.Errorf("failed to unmarshal treatments of vet visit %s: %w", v.ID, err)
+	}
+	if err := json.Unmarshal(attachments, &v.Attachments); err != nil {
+		return fmt.Errorf("failed to unmarshal attachments of vet visit %s: %w", v.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Consultas de las pautas de medicación, compartidas por PostgresStore, PgxStore y
+// SQLiteStore. Las fechas se pasan siempre en UTC: en SQLite se comparan como texto.
+const (
+	insertMedication = `
+		INSERT INTO medications (id, pet_id, drug, dosage, frequency, time_zone, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	selectMedications = `
+		SELECT id, pet_id, drug, dosage, frequency, time_zone, starts_at, ends_at, created_at
+		FROM medications
+	`
+	orderMedications = " ORDER BY created_at, id"
+	insertDose       = `
+		INSERT INTO medication_doses (medication_id, scheduled_at, status, note, recorded_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	selectDoseRecords = `
+		SELECT d.medication_id, d.scheduled_at, d.status, d.note, d.recorded_at
+		FROM medication_doses d
+		JOIN medications m ON m.id = d.medication_id
+		WHERE m.pet_id=$1 AND d.scheduled_at >= $2 AND d.scheduled_at < $3
+		ORDER BY d.scheduled_at, d.medication_id
+	`
+	selectMedicationExists = "SELECT 1 FROM medications WHERE id=$1"
+)
+
+// MEDICATIONS
+func (s *pgQueries) AddMedication(ctx context.Context, m types.Medication) (*types.Medication, error) {
+	markWrite(ctx)
+	return addMedication(ctx, s.q, m, pgNow())
+}
+
+func (s *pgQueries) GetMedications(ctx context.Context, petID types.PetID) ([]types.Medication, error) {
+	return getMedications(ctx, s.q, petID)
+}
+
+func (s *pgQueries) GetMedication(ctx context.Context, petID types.PetID, id string) (*types.Medication, error) {
+	return getMedication(ctx, s.q, petID, id)
+}
+
+func (s *pgQueries) RecordDose(ctx context.Context, d types.DoseRecord) (*types.DoseRecord, error) {
+	markWrite(ctx)
+	return recordDose(ctx, s.q, d, pgNow())
+}
+
+func (s *pgQueries) GetDoseRecords(ctx context.Context, petID types.PetID, from, to time.Time) ([]types.DoseRecord, error) {
+	return getDoseRecords(ctx, s.q, petID, from, to)
+}
+
+func (s *PostgresStore) GetMedications(ctx context.Context, petID types.PetID) ([]types.Medication, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.Medication, error) {
+		return getMedications(ctx, q, petID)
+	})
+}
+
+func (s *PostgresStore) GetMedication(ctx context.Context, petID types.PetID, id string) (*types.Medication, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) (*types.Medication, error) {
+		return getMedication(ctx, q, petID, id)
+	})
+}
+
+func (s *PostgresStore) GetDoseRecords(ctx context.Context, petID types.PetID, from, to time.Time) ([]types.DoseRecord, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.DoseRecord, error) {
+		return getDoseRecords(ctx, q, petID, from, to)
+	})
+}
+
+func addMedication(ctx context.Context, q querier, med types.Medication, createdAt time.Time) (*types.Medication, error) {
+	m := newMedication(med, createdAt)
+	var end any
+	if m.End != nil {
+		end = *m.End
+	}
+
+	err := withTx(ctx, q, func(tx *sql.Tx) error {
+		if err := petExists(ctx, tx, m.PetID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, insertMedication, m.ID, m.PetID, m.Drug, m.Dosage, m.Frequency, m.TimeZone, m.Start, end, m.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert medication: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func getMedications(ctx context.Context, q querier, petID types.PetID) ([]types.Medication, error) {
+	if err := petExists(ctx, q, petID); err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, selectMedications+" WHERE pet_id=$1"+orderMedications, petID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query medications: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	meds := []types.Medication{}
+	for rows.Next() {
+		m, err := scanMedication(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan medication: %w", translateError(err))
+		}
+		meds = append(meds, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return meds, nil
+}
+
+func getMedication(ctx context.Context, q querier, petID types.PetID, id string) (*types.Medication, error) {
+	m, err := scanMedication(q.QueryRowContext(ctx, selectMedications+" WHERE id=$1 AND pet_id=$2", id, petID))
+	switch err {
+	case sql.ErrNoRows:
+		return nil, ErrNotFound
+	case nil:
+		return m, nil
+	default:
+		return nil, fmt.Errorf("query error for medication ID %s: %w", id, translateError(err))
+	}
+}
+
+// scanMedication lee una fila de selectMedications, tanto de *sql.Row como de *sql.Rows.
+func scanMedication(row interface{ Scan(dest ...any) error }) (*types.Medication, error) {
+	var m types.Medication
+	var end sql.NullTime
+	if err := row.Scan(&m.ID, &m.PetID, &m.Drug, &m.Dosage, &m.Frequency, &m.TimeZone, &m.Start, &end, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	m.Start = m.Start.UTC()
+	if end.Valid {
+		e := end.Time.UTC()
+		m.End = &e
+	}
+	return &m, nil
+}
+
+func recordDose(ctx context.Context, q querier, dose types.DoseRecord, recordedAt time.Time) (*types.DoseRecord, error) {
+	d := newDoseRecord(dose, recordedAt)
+	err := withTx(ctx, q, func(tx *sql.Tx) error {
+		var one int
+		switch err := tx.QueryRowContext(ctx, selectMedicationExists, d.MedicationID).Scan(&one); err {
+		case sql.ErrNoRows:
+			return ErrNotFound
+		case nil:
+		default:
+			return fmt.Errorf("query error for medication ID %s: %w", d.MedicationID, translateError(err))
+		}
+		_, err := tx.ExecContext(ctx, insertDose, d.MedicationID, d.ScheduledAt, d.Status, d.Note, d.RecordedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert dose: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func getDoseRecords(ctx context.Context, q querier, petID types.PetID, from, to time.Time) ([]types.DoseRecord, error) {
+	rows, err := q.QueryContext(ctx, selectDoseRecords, petID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query doses: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	records := []types.DoseRecord{}
+	for rows.Next() {
+		var d types.DoseRecord
+		if err := rows.Scan(&d.MedicationID, &d.ScheduledAt, &d.Status, &d.Note, &d.RecordedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dose: %w", translateError(err))
+		}
+		d.ScheduledAt = d.ScheduledAt.UTC()
+		records = append(records, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return records, nil
+}
+
+// newMedication completa la pauta tal como la guardan los stores.
+func newMedication(m types.Medication, createdAt time.Time) types.Medication {
+	m.ID = newUUID()
+	m.Start = m.Start.UTC().Truncate(time.Second)
+	if m.End != nil {
+		e := m.End.UTC().Truncate(time.Second)
+		m.End = &e
+	}
+	if m.TimeZone == "" {
+		m.TimeZone = "UTC"
+	}
+	m.CreatedAt = createdAt
+	return m
+}
+
+// newDoseRecord completa la toma tal como la guardan los stores.
+func newDoseRecord(d types.DoseRecord, recordedAt time.Time) types.DoseRecord {
+	d.ScheduledAt = d.ScheduledAt.UTC()
+	d.RecordedAt = recordedAt
+	return d
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgresConfig agrupa la conexión, el pool y la política de resiliencia de PostgresStore.
+type PostgresConfig struct {
+	ConnString string
+	// Replicas son las cadenas de conexión de las réplicas de lectura, con el mismo pool y
+	// circuit breaker que el primario. Solo las usa PostgresStore.
+	Replicas []string
+	// ReplicaCooldown es el tiempo que una réplica que no respondió deja de recibir lecturas.
+	ReplicaCooldown time.Duration
+	Pool            PoolConfig
+	Connect         RetryConfig
+	Breaker         BreakerConfig
+}
+
+// PoolConfig configura el pool de conexiones de database/sql. Los valores cero
+// mantienen el comportamiento por defecto de database/sql.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// RetryConfig controla los reintentos de la conexión inicial: se reintenta con backoff
+// exponencial y jitter hasta que la base de datos responde o se agota Timeout.
+// Con Timeout cero se hace un único intento. Si MaxBackoff es menor que InitialBackoff,
+// la espera no crece.
+type RetryConfig struct {
+	Timeout        time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultPostgresConfig devuelve una configuración razonable para producción.
+func DefaultPostgresConfig(connStr string) PostgresConfig {
+	return PostgresConfig{
+		ConnString: connStr,
+		Pool: PoolConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Connect: RetryConfig{
+			Timeout:        30 * time.Second,
+			InitialBackoff: 250 * time.Millisecond,
+			MaxBackoff:     5 * time.Second,
+		},
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      5 * time.Second,
+		},
+		ReplicaCooldown: 5 * time.Second,
+	}
+}
+
+// OpenPostgres espera a que la base de datos acepte conexiones y devuelve el store con el
+// pool configurado. Las conexiones nuevas pasan por el circuit breaker, de modo que con la
+// base de datos caída las consultas fallan en el acto con ErrUnavailable.
+func OpenPostgres(ctx context.Context, cfg PostgresConfig) (*PostgresStore, error) {
+	pqConnector, err := pq.NewConnector(cfg.ConnString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := waitForDB(ctx, connectorPing(pqConnector), cfg.Connect); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	replicas, err := openReplicas(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db := openPool(pqConnector, cfg)
+	log.Println("Conectado exitosamente a PostgreSQL!")
+	return &PostgresStore{pgQueries: pgQueries{q: db}, db: db, replicas: replicas, connString: cfg.ConnString}, nil
+}
+
+// openPool abre el pool de c con los ajustes de cfg.Pool; las conexiones nuevas pasan por
+// un circuit breaker propio del pool. Solo se aplican los ajustes distintos de cero: para
+// database/sql, SetMaxIdleConns(0) no es "por defecto" sino no conservar ninguna conexión.
+func openPool(c driver.Connector, cfg PostgresConfig) *sql.DB {
+	db := sql.OpenDB(&breakerConnector{Connector: c, breaker: newCircuitBreaker(cfg.Breaker)})
+	if cfg.Pool.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(cfg.Pool.MaxOpenConns)
+	}
+	if cfg.Pool.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(cfg.Pool.MaxIdleConns)
+	}
+	if cfg.Pool.ConnMaxLifetime != 0 {
+		db.SetConnMaxLifetime(cfg.Pool.ConnMaxLifetime)
+	}
+	if cfg.Pool.ConnMaxIdleTime != 0 {
+		db.SetConnMaxIdleTime(cfg.Pool.ConnMaxIdleTime)
+	}
+	return db
+}
+
+// waitForDB llama a ping hasta que la base de datos responde. ping no debe pasar por el
+// circuit breaker: durante el arranque es normal que la base de datos todavía no esté lista.
+func waitForDB(ctx context.Context, ping func(ctx context.Context) error, cfg RetryConfig) error {
+	deadline := time.Now().Add(cfg.Timeout)
+	for attempt := 0; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		delay := backoff(attempt, cfg.InitialBackoff, cfg.MaxBackoff)
+		if cfg.Timeout <= 0 || time.Now().Add(delay).After(deadline) {
+			return translateError(err)
+		}
+		log.Printf("La base de datos no está disponible (intento %d): %v. Reintentando en %s", attempt+1, err, delay.Round(time.Millisecond))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return translateError(ctx.Err())
+		}
+	}
+}
+
+// connectorPing comprueba la base de datos abriendo y cerrando una conexión con c.
+func connectorPing(c driver.Connector) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		conn, err := c.Connect(ctx)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// backoff calcula la espera antes del reintento attempt (empezando en 0) con "full jitter":
+// un valor aleatorio entre 0 y min(max, initial*2^attempt), para que varias instancias
+// arrancando a la vez no reintenten sincronizadas.
+func backoff(attempt int, initial, max time.Duration) time.Duration {
+	if initial <= 0 {
+		return 0
+	}
+	if max < initial {
+		max = initial
+	}
+	ceiling := initial
+	for i := 0; i < attempt && ceiling < max; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, max)
+	return rand.N(ceiling) + 1
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitForDB(t *testing.T) {
+	retry := RetryConfig{Timeout: time.Second, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	t.Run("retries until the database is ready", func(t *testing.T) {
+		conn := &fakeConnector{failures: 3}
+		if err := waitForDB(ctx, connectorPing(conn), retry); err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if conn.attempts != 4 {
+			t.Errorf("expected 4 attempts, got %d", conn.attempts)
+		}
+	})
+
+	t.Run("gives up after the timeout", func(t *testing.T) {
+		conn := &fakeConnector{down: true}
+		err := waitForDB(ctx, connectorPing(conn), RetryConfig{Timeout: 20 * time.Millisecond, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+		if !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+		if conn.attempts < 2 {
+			t.Errorf("expected several attempts, got %d", conn.attempts)
+		}
+	})
+
+	t.Run("a zero timeout means a single attempt", func(t *testing.T) {
+		conn := &fakeConnector{down: true}
+		if err := waitForDB(ctx, connectorPing(conn), RetryConfig{}); err == nil {
+			t.Fatalf("expected an error")
+		}
+		if conn.attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", conn.attempts)
+		}
+	})
+}
+
+func TestOpenPool(t *testing.T) {
+	for name, tt := range map[string]struct {
+		pool              PoolConfig
+		wantIdle, wantMax int
+	}{
+		// Sin ajustes, el pool conserva las conexiones como database/sql por defecto.
+		"zero config": {PoolConfig{}, 1, 0},
+		"configured":  {PoolConfig{MaxOpenConns: 3, MaxIdleConns: 2}, 1, 3},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := openPool(&fakeConnector{}, PostgresConfig{Pool: tt.pool})
+			defer db.Close()
+			if err := db.PingContext(ctx); err != nil {
+				t.Fatal(err)
+			}
+			stats := db.Stats()
+			if stats.Idle != tt.wantIdle || stats.MaxOpenConnections != tt.wantMax {
+				t.Errorf("expected %d idle and at most %d open connections, got %+v", tt.wantIdle, tt.wantMax, stats)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	initial, max := 100*time.Millisecond, time.Second
+	for attempt := range 10 {
+		ceiling := min(initial<<attempt, max)
+		for range 50 {
+			if d := backoff(attempt, initial, max); d <= 0 || d > ceiling {
+				t.Fatalf("attempt %d: delay %s outside (0, %s]", attempt, d, ceiling)
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+)
+
+const (
+	// notifyQuery usa pg_notify en lugar de NOTIFY porque NOTIFY no admite parámetros.
+	notifyQuery = "SELECT pg_notify($1, $2)"
+	// listenMinBackoff y listenMaxBackoff acotan la espera entre reconexiones de Listen.
+	listenMinBackoff = time.Second
+	listenMaxBackoff = time.Minute
+	// listenPingInterval es cada cuánto se comprueba la conexión de Listen si no llegan
+	// mensajes, para detectar antes una conexión muerta.
+	listenPingInterval = 90 * time.Second
+)
+
+// PUBSUB
+
+// Notify siempre va al primario: las réplicas no admiten NOTIFY.
+func (s *PostgresStore) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := s.db.ExecContext(ctx, notifyQuery, channel, payload); err != nil {
+		return fmt.Errorf("failed to notify %s: %w", channel, translateError(err))
+	}
+	return nil
+}
+
+// Listen usa una conexión propia de lib/pq, fuera del pool, que pq.Listener reconecta sola.
+func (s *PostgresStore) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	l := pq.NewListener(s.connString, listenMinBackoff, listenMaxBackoff, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			log.Printf("Se perdió la conexión de LISTEN %s: %v", channel, err)
+		case pq.ListenerEventReconnected:
+			log.Printf("Conexión de LISTEN %s restablecida", channel)
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("Error al reconectar LISTEN %s: %v", channel, err)
+		}
+	})
+	defer l.Close()
+	if err := l.Listen(channel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", channel, translateError(err))
+	}
+
+	ping := time.NewTicker(listenPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-l.Notify:
+			// pq.Listener envía nil tras reconectar.
+			if n != nil {
+				fn(n.Extra)
+			}
+		case <-ping.C:
+			go l.Ping()
+		}
+	}
+}
+
+func (s *PgxStore) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := s.pool.Exec(ctx, notifyQuery, channel, payload); err != nil {
+		return fmt.Errorf("failed to notify %s: %w", channel, translateError(err))
+	}
+	return nil
+}
+
+// Listen saca una conexión del pool para escuchar y, si se pierde, la vuelve a abrir con
+// espera exponencial.
+func (s *PgxStore) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	for attempt := 0; ; attempt++ {
+		listening, err := s.listen(ctx, channel, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if listening {
+			attempt = 0
+		}
+		delay := backoff(attempt, listenMinBackoff, listenMaxBackoff)
+		log.Printf("Se perdió la conexión de LISTEN %s: %v. Reintentando en %s", channel, err, delay.Round(time.Millisecond))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// listen escucha channel hasta que falla la conexión. listening indica si llegó a
+// ejecutarse LISTEN.
+func (s *PgxStore) listen(ctx context.Context, channel string, fn func(payload string)) (listening bool, err error) {
+	pooled, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return false, translateError(err)
+	}
+	// La conexión queda suscrita al canal, así que no se devuelve al pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return false, translateError(err)
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, translateError(err)
+		}
+		fn(n.Payload)
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPubSub(t *testing.T) {
+	stores := map[string]PubSub{
+		"lib/pq": setupTestDB(t),
+		"pgx":    setupPgxTestDB(t),
+	}
+	for name, ps := range stores {
+		t.Run(name, func(t *testing.T) {
+			channel := fmt.Sprintf("pubsub_test_%d", time.Now().UnixNano())
+			ctx, cancel := context.WithCancel(context.Background())
+			received := make(chan string, 1)
+			done := make(chan error)
+			go func() {
+				done <- ps.Listen(ctx, channel, func(payload string) {
+					select {
+					case received <- payload:
+					default:
+					}
+				})
+			}()
+
+			// LISTEN se ejecuta en segundo plano: se notifica hasta que llega el mensaje.
+			deadline := time.After(10 * time.Second)
+		loop:
+			for {
+				if err := ps.Notify(context.Background(), channel, "hello"); err != nil {
+					t.Fatalf("Notify failed: %v", err)
+				}
+				select {
+				case payload := <-received:
+					if payload != "hello" {
+						t.Errorf("expected hello, got %q", payload)
+					}
+					break loop
+				case <-time.After(100 * time.Millisecond):
+				case <-deadline:
+					t.Fatal("no notification received")
+				}
+			}
+
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("Listen failed: %v", err)
+			}
+		})
+	}
+}
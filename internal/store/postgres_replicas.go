@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// replicaSet reparte las lecturas de PostgresStore entre las réplicas en round-robin. Una
+// réplica que responde ErrUnavailable queda fuera durante cooldown y la lectura se reintenta
+// en la siguiente; si no queda ninguna sana, se lee del primario.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	cooldown time.Duration
+	now      func() time.Time
+}
+
+type replica struct {
+	db *sql.DB // nil en los tests, que solo usan q
+	q  querier
+	// downUntil es el instante (en nanosegundos Unix) hasta el que la réplica no recibe lecturas.
+	downUntil atomic.Int64
+}
+
+// openReplicas abre un pool por réplica, con su propio circuit breaker. No espera a que
+// respondan: una réplica caída al arrancar solo hace que las lecturas vayan al primario.
+func openReplicas(cfg PostgresConfig) (*replicaSet, error) {
+	if len(cfg.Replicas) == 0 {
+		return nil, nil
+	}
+	rs := &replicaSet{cooldown: cfg.ReplicaCooldown, now: time.Now}
+	for i, connStr := range cfg.Replicas {
+		c, err := pq.NewConnector(connStr)
+		if err != nil {
+			rs.close()
+			return nil, fmt.Errorf("failed to configure replica %d: %w", i, err)
+		}
+		db := openPool(c, cfg)
+		rs.replicas = append(rs.replicas, &replica{db: db, q: db})
+	}
+	log.Printf("Leyendo de %d réplicas de PostgreSQL", len(rs.replicas))
+	return rs, nil
+}
+
+func (rs *replicaSet) close() {
+	for _, r := range rs.replicas {
+		if r.db != nil {
+			r.db.Close()
+		}
+	}
+}
+
+// readFrom ejecuta una lectura en una réplica sana o, si no hay réplicas, ninguna está sana
+// o la solicitud ya escribió (read-your-writes), en el primario. Solo ErrUnavailable pasa a
+// la siguiente réplica: el resto de errores (ErrNotFound, cancelaciones...) son la respuesta.
+func readFrom[T any](ctx context.Context, rs *replicaSet, primary querier, fn func(q querier) (T, error)) (T, error) {
+	if rs == nil || len(rs.replicas) == 0 || sessionWrote(ctx) {
+		return fn(primary)
+	}
+
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1) - 1
+	for i := range n {
+		r := rs.replicas[(start+i)%n]
+		now := rs.now()
+		if now.UnixNano() < r.downUntil.Load() {
+			continue
+		}
+		v, err := fn(r.q)
+		if err == nil || !errors.Is(err, ErrUnavailable) {
+			return v, err
+		}
+		log.Printf("La réplica %d no está disponible, se descarta durante %s: %v", (start+i)%n, rs.cooldown, err)
+		r.downUntil.Store(now.Add(rs.cooldown).UnixNano())
+	}
+	return fn(primary)
+}
+
+// Lecturas de PostgresStore: van a las réplicas si las hay. Dentro de WithinTx se usa pgTx,
+// que lee siempre de la transacción en el primario.
+
+func (s *PostgresStore) GetBreeds(ctx context.Context) ([]types.Breed, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.Breed, error) {
+		return getBreeds(ctx, q)
+	})
+}
+
+func (s *PostgresStore) GetBreedByID(ctx context.Context, id types.BreedID) (*types.Breed, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) (*types.Breed, error) {
+		return getBreedByID(ctx, q, id)
+	})
+}
+
+func (s *PostgresStore) GetPets(ctx context.Context) ([]types.Pet, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.Pet, error) {
+		return getPets(ctx, q)
+	})
+}
+
+func (s *PostgresStore) GetPetByID(ctx context.Context, id types.PetID) (*types.Pet, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) (*types.Pet, error) {
+		return getPetByID(ctx, q, id, false)
+	})
+}
+
+func (s *PostgresStore) GetAuditHistory(ctx context.Context, entityType, entityID string) ([]types.AuditEntry, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.AuditEntry, error) {
+		return getAuditHistory(ctx, q, entityType, entityID)
+	})
+}
+
+// session registra si una solicitud ya escribió, para que sus lecturas posteriores vayan al
+// primario y vean su propio cambio aunque las réplicas vayan con retraso.
+type session struct {
+	wrote atomic.Bool
+}
+
+type sessionKey struct{}
+
+// WithSession abre una sesión de read-your-writes en ctx: tras la primera escritura hecha con
+// ese contexto (o uno derivado), las lecturas dejan de ir a las réplicas. Sin sesión, cada
+// lectura puede ir a cualquier réplica.
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// markWrite anota en la sesión de ctx, si la hay, que se ha escrito.
+func markWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+func sessionWrote(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// namedQuerier identifica a qué base de datos fue una lectura; nunca se usa para consultar.
+type namedQuerier struct {
+	querier
+	name string
+}
+
+// fakeReplicas devuelve un replicaSet con las réplicas indicadas y una lectura que responde
+// el nombre de la base de datos usada, o el error configurado para ella.
+func fakeReplicas(names ...string) (*replicaSet, *time.Time, map[string]error, func(ctx context.Context) (string, error)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rs := &replicaSet{cooldown: 5 * time.Second, now: func() time.Time { return now }}
+	for _, name := range names {
+		rs.replicas = append(rs.replicas, &replica{q: namedQuerier{name: name}})
+	}
+	errs := map[string]error{}
+	primary := namedQuerier{name: "primary"}
+	read := func(ctx context.Context) (string, error) {
+		return readFrom(ctx, rs, primary, func(q querier) (string, error) {
+			name := q.(namedQuerier).name
+			return name, errs[name]
+		})
+	}
+	return rs, &now, errs, read
+}
+
+func TestReadFromReplicas(t *testing.T) {
+	unavailable := fmt.Errorf("failed to query pets: %w", ErrUnavailable)
+
+	t.Run("round robin", func(t *testing.T) {
+		_, _, _, read := fakeReplicas("r1", "r2")
+		for _, want := range []string{"r1", "r2", "r1", "r2"} {
+			if got, _ := read(ctx); got != want {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		}
+	})
+
+	t.Run("no replicas reads from the primary", func(t *testing.T) {
+		_, _, _, read := fakeReplicas()
+		if got, _ := read(ctx); got != "primary" {
+			t.Errorf("expected primary, got %s", got)
+		}
+	})
+
+	t.Run("unavailable replica fails over and cools down", func(t *testing.T) {
+		_, now, errs, read := fakeReplicas("r1", "r2")
+		errs["r1"] = unavailable
+
+		for range 4 {
+			if got, err := read(ctx); got != "r2" || err != nil {
+				t.Errorf("expected r2, got %s (%v)", got, err)
+			}
+		}
+
+		// Pasado el cooldown, la réplica vuelve a recibir lecturas.
+		delete(errs, "r1")
+		*now = now.Add(5 * time.Second)
+		seen := map[string]bool{}
+		for range 2 {
+			got, _ := read(ctx)
+			seen[got] = true
+		}
+		if !seen["r1"] || !seen["r2"] {
+			t.Errorf("expected both replicas after the cooldown, got %v", seen)
+		}
+	})
+
+	t.Run("every replica down reads from the primary", func(t *testing.T) {
+		_, _, errs, read := fakeReplicas("r1", "r2")
+		errs["r1"], errs["r2"] = unavailable, unavailable
+		if got, err := read(ctx); got != "primary" || err != nil {
+			t.Errorf("expected primary, got %s (%v)", got, err)
+		}
+	})
+
+	t.Run("other errors are returned without failover", func(t *testing.T) {
+		_, _, errs, read := fakeReplicas("r1", "r2")
+		errs["r1"] = ErrNotFound
+		if got, err := read(ctx); got != "r1" || err != ErrNotFound {
+			t.Errorf("expected ErrNotFound from r1, got %s (%v)", got, err)
+		}
+	})
+
+	t.Run("read your writes", func(t *testing.T) {
+		_, _, _, read := fakeReplicas("r1", "r2")
+
+		// Sin sesión, las escrituras no cambian adónde van las lecturas.
+		markWrite(ctx)
+		if got, _ := read(ctx); got == "primary" {
+			t.Errorf("expected a replica without a session")
+		}
+
+		session := WithSession(ctx)
+		if got, _ := read(session); got == "primary" {
+			t.Errorf("expected a replica before the first write")
+		}
+		markWrite(session)
+		for range 3 {
+			if got, _ := read(session); got != "primary" {
+				t.Errorf("expected primary after a write in the session, got %s", got)
+			}
+		}
+		if got, _ := read(WithSession(ctx)); got == "primary" {
+			t.Errorf("another session should still read from replicas")
+		}
+	})
+}
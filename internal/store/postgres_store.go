@@ -1,68 +1,177 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
-	_ "github.com/lib/pq"
-
+	"github.com/agugliotta/dog-app-bff/internal/audit"
 	"github.com/agugliotta/dog-app-bff/internal/types"
 )
 
+// PostgresStore escribe siempre en el primario (db). Las lecturas van a las réplicas, si
+// se configuraron (ver readFrom).
 type PostgresStore struct {
-	db *sql.DB
+	pgQueries
+	db       *sql.DB
+	replicas *replicaSet
+	// connString abre la conexión dedicada de Listen.
+	connString string
 }
 
-func NewPostgresStore(connStr string) (*PostgresStore, error) {
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
+// pgQueries implementa las consultas sobre un querier, que puede ser la conexión (*sql.DB)
+// o una transacción en curso (*sql.Tx). Así el mismo código sirve para PostgresStore y pgTx.
+type pgQueries struct {
+	q querier
+}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
+// pgTx es la vista transaccional que recibe la función de PostgresStore.WithinTx.
+type pgTx struct {
+	pgQueries
+}
 
-	log.Println("Conectado exitosamente a PostgreSQL!")
-	return &PostgresStore{db: db}, nil
+// querier es la parte común de *sql.DB y *sql.Tx que usan las consultas, para poder
+// ejecutarlas tanto fuera como dentro de una transacción.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// NewPostgresStore conecta con un único intento y sin ajustes de pool ni circuit breaker.
+// Para producción conviene OpenPostgres con DefaultPostgresConfig.
+func NewPostgresStore(connStr string) (*PostgresStore, error) {
+	return OpenPostgres(context.Background(), PostgresConfig{ConnString: connStr})
 }
 
 func (s *PostgresStore) Close() error {
+	if s.replicas != nil {
+		s.replicas.close()
+	}
 	if s.db != nil {
 		return s.db.Close()
 	}
 	return nil
 }
 
+// WithinTx ejecuta fn dentro de una transacción: si fn devuelve error (o entra en pánico)
+// se hace rollback; si no, commit.
+func (s *PostgresStore) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	markWrite(ctx)
+	return runTx(ctx, s.db, func(tx *sql.Tx) error {
+		return fn(&pgTx{pgQueries{q: tx}})
+	})
+}
+
+// WithinTx dentro de una transacción reutiliza la transacción en curso.
+func (t *pgTx) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	return fn(t)
+}
+
+func runTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", translateError(err))
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", translateError(err))
+	}
+	return nil
+}
+
+// withTx ejecuta fn en la transacción en curso o, si no hay ninguna, en una nueva.
+// Las escrituras lo usan para que el cambio y su entrada de auditoría sean atómicos.
+func (s *pgQueries) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	markWrite(ctx)
+	return withTx(ctx, s.q, fn)
+}
+
+func withTx(ctx context.Context, q querier, fn func(tx *sql.Tx) error) error {
+	switch q := q.(type) {
+	case *sql.Tx:
+		return fn(q)
+	case *sql.DB:
+		return runTx(ctx, q, fn)
+	default:
+		return fmt.Errorf("unsupported querier %T", q)
+	}
+}
+
+// Consultas compartidas por PostgresStore (lib/pq) y PgxStore (pgx). Las que no usan
+// funciones propias de Postgres (selectBreeds, selectBreedByID, deletePet, insertBreed, updateBreed)
+// también las usa SQLiteStore.
+const (
+	selectBreeds    = "SELECT id, name, temperament, origin, size FROM breeds"
+	selectBreedByID = selectBreeds + " WHERE id=$1"
+	insertPet       = `
+            INSERT INTO pets (name, birth, breed_id, owner)
+            VALUES ($1, $2, $3, $4)
+            RETURNING id, version, updated_at
+        `
+	updatePet = `
+			UPDATE pets
+			SET name=$3, birth=$4, breed_id=$5, version=version+1, updated_at=now()
+			WHERE id=$1 AND ($2 = 0 OR version=$2)
+			RETURNING version, updated_at
+		`
+	deletePet   = "DELETE FROM pets WHERE id=$1 AND ($2 = 0 OR version=$2)"
+	insertBreed = "INSERT INTO breeds (id, name, temperament, origin, size) VALUES ($1, $2, $3, $4, $5)"
+	updateBreed = "UPDATE breeds SET name=$2, temperament=$3, origin=$4, size=$5 WHERE id=$1"
+
+	// Orden de GetBreeds y GetPets; el ID desempata para que el orden sea estable.
+	orderBreeds = " ORDER BY name, id"
+	orderPets   = " ORDER BY p.name, p.id"
+)
+
 // BREEDS
-func (s *PostgresStore) GetBreeds() ([]types.Breed, error) {
-	rows, err := s.db.Query("SELECT id, name, temperament, origin FROM breeds")
+func (s *pgQueries) GetBreeds(ctx context.Context) ([]types.Breed, error) {
+	return getBreeds(ctx, s.q)
+}
+
+// getBreeds, getBreedByID, getPets y getPetByID solo usan SQL estándar y database/sql,
+// así que también los reutiliza SQLiteStore.
+func getBreeds(ctx context.Context, q querier) ([]types.Breed, error) {
+	rows, err := q.QueryContext(ctx, selectBreeds+orderBreeds)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query breeds: %w", err)
+		return nil, fmt.Errorf("failed to query breeds: %w", translateError(err))
 	}
 	defer rows.Close()
 
 	var breeds []types.Breed
 	for rows.Next() {
 		var breed types.Breed
-		if err := rows.Scan(&breed.ID, &breed.Name, &breed.Temperament, &breed.Origin); err != nil {
-			return nil, fmt.Errorf("failed to scan breed: %w", err)
+		if err := rows.Scan(&breed.ID, &breed.Name, &breed.Temperament, &breed.Origin, &breed.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan breed: %w", translateError(err))
 		}
 		breeds = append(breeds, breed)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over rows: %w", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
 	}
 
 	return breeds, nil
 }
 
-func (s *PostgresStore) GetBreedByID(id string) (*types.Breed, error) {
+func (s *pgQueries) GetBreedByID(ctx context.Context, id types.BreedID) (*types.Breed, error) {
+	return getBreedByID(ctx, s.q, id)
+}
+
+func getBreedByID(ctx context.Context, q querier, id types.BreedID) (*types.Breed, error) {
 	var breed types.Breed
-	err := s.db.QueryRow("SELECT id, name, temperament, origin FROM breeds WHERE id=$1", id).Scan(&breed.ID, &breed.Name, &breed.Temperament, &breed.Origin)
+	err := q.QueryRowContext(ctx, selectBreedByID, id).Scan(&breed.ID, &breed.Name, &breed.Temperament, &breed.Origin, &breed.Size)
 
 	switch err { // El switch ya manejará los diferentes tipos de error de 'err'
 	case sql.ErrNoRows:
@@ -71,24 +180,63 @@ func (s *PostgresStore) GetBreedByID(id string) (*types.Breed, error) {
 	case nil: // Si err es nil, significa que todo fue exitoso
 		return &breed, nil
 	default: // Cualquier otro tipo de error de la base de datos
-		return nil, fmt.Errorf("query error for breed ID %s: %w", id, err)
+		return nil, fmt.Errorf("query error for breed ID %s: %w", id, translateError(err))
+	}
+}
+
+func (s *pgQueries) UpsertBreed(ctx context.Context, b types.Breed) (UpsertResult, error) {
+	markWrite(ctx)
+	return upsertBreed(ctx, s.q, b)
+}
+
+// upsertBreed también lo usa SQLiteStore.
+func upsertBreed(ctx context.Context, q querier, b types.Breed) (UpsertResult, error) {
+	result := Unchanged
+	err := withTx(ctx, q, func(tx *sql.Tx) error {
+		before, err := getBreedByID(ctx, tx, b.ID)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			if _, err := tx.ExecContext(ctx, insertBreed, b.ID, b.Name, b.Temperament, b.Origin, b.Size); err != nil {
+				return fmt.Errorf("failed to insert breed %s: %w", b.ID, translateError(err))
+			}
+			result = Inserted
+			return insertAudit(ctx, tx, audit.EntityBreed, b.ID.String(), audit.ActionCreate, nil, b)
+		case err != nil:
+			return err
+		case *before == b:
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, updateBreed, b.ID, b.Name, b.Temperament, b.Origin, b.Size); err != nil {
+			return fmt.Errorf("failed to update breed %s: %w", b.ID, translateError(err))
+		}
+		result = Updated
+		return insertAudit(ctx, tx, audit.EntityBreed, b.ID.String(), audit.ActionUpdate, before, b)
+	})
+	if err != nil {
+		return "", err
 	}
+	return result, nil
 }
 
 // PETS
-func (s *PostgresStore) GetPets() ([]types.Pet, error) {
-	query := `
+const selectPets = `
 		SELECT
-            p.id, p.name, p.birth,
-            b.id AS breed_id, b.name AS breed_name, b.temperament AS breed_temperament, b.origin AS breed_origin
+            p.id, p.name, p.birth, p.owner, p.version, p.updated_at,
+            b.id AS breed_id, b.name AS breed_name, b.temperament AS breed_temperament, b.origin AS breed_origin, b.size AS breed_size
         FROM
             pets p
         JOIN
             breeds b ON p.breed_id = b.id
 	`
-	rows, err := s.db.Query(query)
+
+func (s *pgQueries) GetPets(ctx context.Context) ([]types.Pet, error) {
+	return getPets(ctx, s.q)
+}
+
+func getPets(ctx context.Context, q querier) ([]types.Pet, error) {
+	rows, err := q.QueryContext(ctx, selectPets+orderPets)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query pets: %w", err)
+		return nil, fmt.Errorf("failed to query pets: %w", translateError(err))
 	}
 	defer rows.Close()
 
@@ -96,35 +244,36 @@ func (s *PostgresStore) GetPets() ([]types.Pet, error) {
 	for rows.Next() {
 		var pet types.Pet
 		var breed types.Breed
-		if err := rows.Scan(&pet.ID, &pet.Name, &pet.Birth, &breed.ID, &breed.Name, &breed.Temperament, &breed.Origin); err != nil {
-			return nil, fmt.Errorf("failed to scan pet or breed: %w", err)
+		if err := rows.Scan(&pet.ID, &pet.Name, &pet.Birth, &pet.Owner, &pet.Version, &pet.UpdatedAt, &breed.ID, &breed.Name, &breed.Temperament, &breed.Origin, &breed.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan pet or breed: %w", translateError(err))
 		}
 		pet.Breed = breed
 		pets = append(pets, pet)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating over rows: %w", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
 	}
 
 	return pets, nil
 }
 
-func (s *PostgresStore) GetPetByID(id string) (*types.Pet, error) {
+func (s *pgQueries) GetPetByID(ctx context.Context, id types.PetID) (*types.Pet, error) {
+	return getPetByID(ctx, s.q, id, false)
+}
+
+// getPetByID lee una mascota; con forUpdate bloquea la fila hasta el fin de la transacción.
+func getPetByID(ctx context.Context, q querier, id types.PetID, forUpdate bool) (*types.Pet, error) {
 	var pet types.Pet
 	var breed types.Breed
-	query := `
-		SELECT
-            p.id, p.name, p.birth,
-            b.id AS breed_id, b.name AS breed_name, b.temperament AS breed_temperament, b.origin AS breed_origin
-        FROM
-            pets p
-        JOIN
-            breeds b ON p.breed_id = b.id
+	query := selectPets + `
 		WHERE
 			p.id=$1
 	`
-	err := s.db.QueryRow(query, id).Scan(&pet.ID, &pet.Name, &pet.Birth, &breed.ID, &breed.Name, &breed.Temperament, &breed.Origin)
+	if forUpdate {
+		query += " FOR UPDATE OF p"
+	}
+	err := q.QueryRowContext(ctx, query, id).Scan(&pet.ID, &pet.Name, &pet.Birth, &pet.Owner, &pet.Version, &pet.UpdatedAt, &breed.ID, &breed.Name, &breed.Temperament, &breed.Origin, &breed.Size)
 	switch err {
 	case sql.ErrNoRows:
 		return nil, ErrNotFound
@@ -132,47 +281,121 @@ func (s *PostgresStore) GetPetByID(id string) (*types.Pet, error) {
 		pet.Breed = breed
 		return &pet, nil
 	default:
-		return nil, fmt.Errorf("query error for breed ID %s: %w", id, err)
+		return nil, fmt.Errorf("query error for pet ID %s: %w", id, translateError(err))
 	}
 }
 
-func (s *PostgresStore) CreatePet(name string, birth time.Time, breedID string) (*types.Pet, error) {
-	// Paso 1: Validar si la raza existe. Reutilizamos el método GetBreedByID.
-	breed, err := s.GetBreedByID(breedID)
+func (s *pgQueries) CreatePet(ctx context.Context, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	var newPet *types.Pet
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockPetChangesTx(ctx, tx); err != nil {
+			return err
+		}
+		// Paso 1: Validar si la raza existe. Reutilizamos la consulta de GetBreedByID.
+		breed, err := getBreedByID(ctx, tx, breedID)
+		if err != nil {
+			// Si falla, propagamos ese error directamente.
+			// Podría ser ErrNotFound, u otro error interno.
+			return fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+		}
+
+		newPet = &types.Pet{
+			Name:  name,
+			Birth: dateOnly(birth),
+			Breed: *breed,
+			Owner: audit.FromContext(ctx).Subject,
+		}
+
+		err = tx.QueryRowContext(ctx, insertPet, name, birth, breedID, newPet.Owner).Scan(&newPet.ID, &newPet.Version, &newPet.UpdatedAt)
+		if err != nil {
+			// No uses log.Fatalf. Devuelve el error para que el llamador lo maneje.
+			return fmt.Errorf("failed to insert pet and get ID: %w", translateError(err))
+		}
+
+		return insertAudit(ctx, tx, audit.EntityPet, newPet.ID.String(), audit.ActionCreate, nil, newPet)
+	})
 	if err != nil {
-		// Si GetBreedByID falla, propagamos ese error directamente.
-		// Podría ser ErrNotFound, u otro error interno.
-		return nil, fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+		return nil, err
 	}
+	return newPet, nil
+}
 
-	query := `
-        INSERT INTO pets (name, birth, breed_id)
-        VALUES ($1, $2, $3)
-        RETURNING id
-    `
+// UpdatePet hace un compare-and-swap sobre la columna version: el UPDATE solo afecta a la
+// fila si nadie la modificó desde que el cliente la leyó.
+func (s *pgQueries) UpdatePet(ctx context.Context, id types.PetID, expectedVersion int64, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	var pet *types.Pet
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockPetChangesTx(ctx, tx); err != nil {
+			return err
+		}
+		before, err := getPetByID(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != AnyVersion && before.Version != expectedVersion {
+			return ErrVersionConflict
+		}
 
-	var newPetID string
+		breed, err := getBreedByID(ctx, tx, breedID)
+		if err != nil {
+			return fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+		}
 
-	err = s.db.QueryRow(query, name, birth, breedID).Scan(&newPetID)
+		pet = &types.Pet{
+			ID:    id,
+			Name:  name,
+			Birth: dateOnly(birth),
+			Breed: *breed,
+			Owner: before.Owner,
+		}
+		err = tx.QueryRowContext(ctx, updatePet, id, expectedVersion, name, birth, breedID).Scan(&pet.Version, &pet.UpdatedAt)
+		switch err {
+		case sql.ErrNoRows:
+			return ErrVersionConflict
+		case nil:
+		default:
+			return fmt.Errorf("failed to update pet %s: %w", id, translateError(err))
+		}
+
+		return insertAudit(ctx, tx, audit.EntityPet, id.String(), audit.ActionUpdate, before, pet)
+	})
 	if err != nil {
-		// No uses log.Fatalf. Devuelve el error para que el llamador lo maneje.
-		return nil, fmt.Errorf("failed to insert pet and get ID: %w", err)
+		return nil, err
 	}
-	newPet := &types.Pet{
-		ID:    newPetID,
-		Name:  name,
-		Birth: birth,
-		Breed: *breed,
-	}
-
-	return newPet, nil
+	return pet, nil
 }
 
-func (s *PostgresStore) DeletePet(id string) error {
-	_, err := s.db.Exec("DELETE FROM pets WHERE id=$1", id)
+func (s *pgQueries) DeletePet(ctx context.Context, id types.PetID, expectedVersion int64) (*types.Pet, error) {
+	var before *types.Pet
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockPetChangesTx(ctx, tx); err != nil {
+			return err
+		}
+		var err error
+		before, err = getPetByID(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != AnyVersion && before.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		res, err := tx.ExecContext(ctx, deletePet, id, expectedVersion)
+		if err != nil {
+			return fmt.Errorf("failed to delete pet %s: %w", id, translateError(err))
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to delete pet %s: %w", id, translateError(err))
+		}
+		if n == 0 {
+			return ErrVersionConflict
+		}
 
+		return insertAudit(ctx, tx, audit.EntityPet, id.String(), audit.ActionDelete, before, nil)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return before, nil
 }
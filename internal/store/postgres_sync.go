@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// Consultas de la sincronización, compartidas por PostgresStore, PgxStore y SQLiteStore. Los
+// cambios se leen de audit_log, que las escrituras de mascotas rellenan en su transacción.
+// Tanto el token como los cambios miran solo las entradas de mascotas: las de otras
+// entidades (p. ej. las razas) no toman lockPetChanges, así que un ID suyo confirmado no
+// garantiza que los anteriores de mascotas ya estén confirmados.
+const (
+	selectLatestChange = "SELECT COALESCE(MAX(id), 0) FROM audit_log WHERE entity_type='" + audit.EntityPet + "'"
+	selectPetChanges   = `
+		SELECT id, entity_id, action, after
+		FROM audit_log
+		WHERE entity_type='` + audit.EntityPet + `' AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	// lockPetChanges serializa las escrituras de mascotas hasta el commit. Así los IDs de
+	// audit_log se confirman en orden y quien lee el ID n ya ve todos los anteriores: sin el
+	// bloqueo, una transacción lenta podría confirmar el ID n-1 después de que un cliente
+	// se sincronizara hasta n, y ese cambio no le llegaría nunca. Se toma antes que ningún
+	// bloqueo de fila para que no pueda haber interbloqueos.
+	lockPetChanges = "SELECT pg_advisory_xact_lock(7242001)"
+)
+
+// SYNC
+
+// PetSnapshot y PetChanges no se redirigen a las réplicas: un token leído de una réplica
+// adelantada junto con mascotas de otra atrasada perdería cambios.
+func (s *pgQueries) PetSnapshot(ctx context.Context) ([]types.Pet, int64, error) {
+	return petSnapshot(ctx, s.q)
+}
+
+func (s *pgQueries) PetChanges(ctx context.Context, since int64, limit int) ([]types.PetChange, error) {
+	return petChanges(ctx, s.q, since, limit)
+}
+
+func petSnapshot(ctx context.Context, q querier) ([]types.Pet, int64, error) {
+	// El token se lee antes que las mascotas: los cambios que se confirmen entre medias
+	// pueden llegar dos veces, pero ninguno se pierde.
+	var token int64
+	if err := q.QueryRowContext(ctx, selectLatestChange).Scan(&token); err != nil {
+		return nil, 0, fmt.Errorf("failed to query the latest change: %w", translateError(err))
+	}
+	pets, err := getPets(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pets, token, nil
+}
+
+func petChanges(ctx context.Context, q querier, since int64, limit int) ([]types.PetChange, error) {
+	rows, err := q.QueryContext(ctx, selectPetChanges, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pet changes: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	changes := []types.PetChange{}
+	for rows.Next() {
+		c, err := scanPetChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return changes, nil
+}
+
+// scanPetChange lee una fila de selectPetChanges, tanto de database/sql como de pgx.
+func scanPetChange(row interface{ Scan(dest ...any) error }) (*types.PetChange, error) {
+	var c types.PetChange
+	var id, action string
+	var after []byte
+	if err := row.Scan(&c.Seq, &id, &action, &after); err != nil {
+		return nil, fmt.Errorf("failed to scan pet change: %w", translateError(err))
+	}
+	return newPetChange(c.Seq, id, action, after)
+}
+
+// newPetChange construye el cambio a partir de una entrada de auditoría de una mascota.
+func newPetChange(seq int64, id, action string, after []byte) (*types.PetChange, error) {
+	c := types.PetChange{Seq: seq, PetID: types.PetID(id), Deleted: action == audit.ActionDelete}
+	if !c.Deleted {
+		c.Pet = &types.Pet{}
+		if err := json.Unmarshal(after, c.Pet); err != nil {
+			return nil, fmt.Errorf("invalid state of pet %s in change %d: %w", id, seq, err)
+		}
+	}
+	return &c, nil
+}
+
+func lockPetChangesTx(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, lockPetChanges); err != nil {
+		return fmt.Errorf("failed to lock pet changes: %w", translateError(err))
+	}
+	return nil
+}
+
+func (s *pgxQueries) PetSnapshot(ctx context.Context) ([]types.Pet, int64, error) {
+	var token int64
+	if err := s.q.QueryRow(ctx, selectLatestChange).Scan(&token); err != nil {
+		return nil, 0, fmt.Errorf("failed to query the latest change: %w", translateError(err))
+	}
+	pets, err := s.GetPets(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pets, token, nil
+}
+
+func (s *pgxQueries) PetChanges(ctx context.Context, since int64, limit int) ([]types.PetChange, error) {
+	rows, err := s.q.Query(ctx, selectPetChanges, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pet changes: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	changes := []types.PetChange{}
+	for rows.Next() {
+		c, err := scanPetChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return changes, nil
+}
+
+func lockPgxPetChanges(ctx context.Context, tx pgx.Tx) error {
+	if _, err := tx.Exec(ctx, lockPetChanges); err != nil {
+		return fmt.Errorf("failed to lock pet changes: %w", translateError(err))
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Consultas de los webhooks, compartidas por PostgresStore, PgxStore y SQLiteStore.
+const (
+	insertWebhook  = "INSERT INTO webhooks (id, url, events, secret, created_at) VALUES ($1, $2, $3, $4, $5)"
+	selectWebhooks = "SELECT id, url, events, secret, created_at FROM webhooks"
+	orderWebhooks  = " ORDER BY created_at, id"
+	deleteWebhook  = "DELETE FROM webhooks WHERE id=$1"
+	insertDelivery = `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'pending', $5, $5)
+		RETURNING id
+	`
+	updateDelivery = `
+		UPDATE webhook_deliveries
+		SET status=$2, attempts=attempts+1, response_status=$3, last_error=$4, updated_at=$5
+		WHERE id=$1
+	`
+	selectDeliveries = `
+		SELECT id, webhook_id, event_id, event_type, payload, status, attempts, response_status, last_error, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE webhook_id=$1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+	selectWebhookExists = "SELECT 1 FROM webhooks WHERE id=$1"
+)
+
+// WEBHOOKS
+func (s *pgQueries) CreateWebhook(ctx context.Context, w types.Webhook) (*types.Webhook, error) {
+	markWrite(ctx)
+	return createWebhook(ctx, s.q, w, pgNow())
+}
+
+func (s *pgQueries) GetWebhooks(ctx context.Context) ([]types.Webhook, error) {
+	return getWebhooks(ctx, s.q)
+}
+
+func (s *pgQueries) GetWebhook(ctx context.Context, id string) (*types.Webhook, error) {
+	return getWebhook(ctx, s.q, id)
+}
+
+func (s *pgQueries) DeleteWebhook(ctx context.Context, id string) error {
+	markWrite(ctx)
+	return execOne(ctx, s.q, "delete webhook "+id, deleteWebhook, id)
+}
+
+func (s *pgQueries) AddDelivery(ctx context.Context, d types.WebhookDelivery) (*types.WebhookDelivery, error) {
+	markWrite(ctx)
+	return addDelivery(ctx, s.q, d, pgNow())
+}
+
+func (s *pgQueries) RecordDeliveryAttempt(ctx context.Context, id int64, status types.DeliveryStatus, responseStatus int, lastError string) error {
+	markWrite(ctx)
+	return execOne(ctx, s.q, fmt.Sprintf("update webhook delivery %d", id), updateDelivery, id, string(status), responseStatus, lastError, pgNow())
+}
+
+func (s *pgQueries) GetDeliveries(ctx context.Context, webhookID string, limit int) ([]types.WebhookDelivery, error) {
+	return getDeliveries(ctx, s.q, webhookID, limit)
+}
+
+func (s *PostgresStore) GetWebhooks(ctx context.Context) ([]types.Webhook, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.Webhook, error) {
+		return getWebhooks(ctx, q)
+	})
+}
+
+func (s *PostgresStore) GetWebhook(ctx context.Context, id string) (*types.Webhook, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) (*types.Webhook, error) {
+		return getWebhook(ctx, q, id)
+	})
+}
+
+func (s *PostgresStore) GetDeliveries(ctx context.Context, webhookID string, limit int) ([]types.WebhookDelivery, error) {
+	return readFrom(ctx, s.replicas, s.q, func(q querier) ([]types.WebhookDelivery, error) {
+		return getDeliveries(ctx, q, webhookID, limit)
+	})
+}
+
+func createWebhook(ctx context.Context, q querier, webhook types.Webhook, createdAt time.Time) (*types.Webhook, error) {
+	w := newWebhook(webhook, createdAt)
+	filters, err := json.Marshal(w.Events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook events: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, insertWebhook, w.ID, w.URL, string(filters), w.Secret, w.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert webhook: %w", translateError(err))
+	}
+	return &w, nil
+}
+
+func getWebhooks(ctx context.Context, q querier) ([]types.Webhook, error) {
+	rows, err := q.QueryContext(ctx, selectWebhooks+orderWebhooks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	webhooks := []types.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", translateError(err))
+		}
+		webhooks = append(webhooks, *w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return webhooks, nil
+}
+
+func getWebhook(ctx context.Context, q querier, id string) (*types.Webhook, error) {
+	w, err := scanWebhook(q.QueryRowContext(ctx, selectWebhooks+" WHERE id=$1", id))
+	switch err {
+	case sql.ErrNoRows:
+		return nil, ErrNotFound
+	case nil:
+		return w, nil
+	default:
+		return nil, fmt.Errorf("query error for webhook ID %s: %w", id, translateError(err))
+	}
+}
+
+// scanWebhook lee una fila de selectWebhooks, tanto de database/sql como de pgx.
+func scanWebhook(row interface{ Scan(dest ...any) error }) (*types.Webhook, error) {
+	var w types.Webhook
+	var filters []byte
+	if err := row.Scan(&w.ID, &w.URL, &filters, &w.Secret, &w.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filters, &w.Events); err != nil {
+		return nil, fmt.Errorf("invalid events of webhook %s: %w", w.ID, err)
+	}
+	return &w, nil
+}
+
+func addDelivery(ctx context.Context, q querier, delivery types.WebhookDelivery, createdAt time.Time) (*types.WebhookDelivery, error) {
+	d := newDelivery(delivery, createdAt)
+	err := withTx(ctx, q, func(tx *sql.Tx) error {
+		var one int
+		switch err := tx.QueryRowContext(ctx, selectWebhookExists, d.WebhookID).Scan(&one); err {
+		case sql.ErrNoRows:
+			return ErrNotFound
+		case nil:
+		default:
+			return fmt.Errorf("query error for webhook ID %s: %w", d.WebhookID, translateError(err))
+		}
+		err := tx.QueryRowContext(ctx, insertDelivery, d.WebhookID, d.EventID, d.EventType, string(d.Payload), d.CreatedAt).Scan(&d.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert webhook delivery: %w", translateError(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func getDeliveries(ctx context.Context, q querier, webhookID string, limit int) ([]types.WebhookDelivery, error) {
+	if _, err := getWebhook(ctx, q, webhookID); err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, selectDeliveries, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", translateError(err))
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", translateError(err))
+	}
+	return deliveries, nil
+}
+
+// scanDelivery lee una fila de selectDeliveries, tanto de database/sql como de pgx.
+func scanDelivery(row interface{ Scan(dest ...any) error }) (*types.WebhookDelivery, error) {
+	var d types.WebhookDelivery
+	var payload []byte
+	var status string
+	if err := row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &payload, &status, &d.Attempts,
+		&d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	d.Status = types.DeliveryStatus(status)
+	return &d, nil
+}
+
+// newWebhook completa la suscripción tal como la guardan los stores.
+func newWebhook(w types.Webhook, createdAt time.Time) types.Webhook {
+	w.ID = newUUID()
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+	w.CreatedAt = createdAt
+	return w
+}
+
+// newDelivery completa la entrega tal como la guardan los stores.
+func newDelivery(d types.WebhookDelivery, createdAt time.Time) types.WebhookDelivery {
+	d.Status = types.DeliveryPending
+	d.Attempts = 0
+	d.ResponseStatus = 0
+	d.LastError = ""
+	d.CreatedAt = createdAt
+	d.UpdatedAt = createdAt
+	return d
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/fixtures"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// SeedCounts cuenta cuántas razas o mascotas se insertaron, actualizaron o quedaron igual.
+type SeedCounts struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+func (c *SeedCounts) add(r UpsertResult) {
+	switch r {
+	case Inserted:
+		c.Inserted++
+	case Updated:
+		c.Updated++
+	default:
+		c.Unchanged++
+	}
+}
+
+// SeedReport es el resultado de Seed.
+type SeedReport struct {
+	Breeds SeedCounts `json:"breeds"`
+	Pets   SeedCounts `json:"pets"`
+}
+
+// Seed carga las razas y mascotas de set en una transacción, usando solo BreedWriter y
+// PetStore, así que sirve para cualquier store. Se puede repetir: las razas se identifican
+// por su ID y las mascotas por su nombre y raza, y lo que no cambió no se toca ni se audita.
+// Una mascota que ya existe solo se actualiza si cambió su fecha de nacimiento; las que
+// están en el store y no en set se dejan como están.
+func Seed(ctx context.Context, tr Transactor, set *fixtures.Set) (*SeedReport, error) {
+	report := &SeedReport{}
+	err := tr.WithinTx(ctx, func(tx Tx) error {
+		for _, b := range set.Breeds {
+			res, err := tx.UpsertBreed(ctx, b)
+			if err != nil {
+				return fmt.Errorf("failed to seed breed %s: %w", b.ID, err)
+			}
+			report.Breeds.add(res)
+		}
+
+		pets, err := tx.GetPets(ctx)
+		if err != nil {
+			return err
+		}
+		type petKey struct {
+			name  string
+			breed types.BreedID
+		}
+		existing := make(map[petKey]types.Pet, len(pets))
+		for _, p := range pets {
+			key := petKey{p.Name, p.Breed.ID}
+			// Si hay varias con el mismo nombre y raza, se usa la primera de GetPets.
+			if _, ok := existing[key]; !ok {
+				existing[key] = p
+			}
+		}
+
+		for _, p := range set.Pets {
+			current, ok := existing[petKey{p.Name, p.Breed}]
+			switch {
+			case !ok:
+				if _, err := tx.CreatePet(ctx, p.Name, p.Birth, p.Breed); err != nil {
+					return fmt.Errorf("failed to seed pet %s: %w", p.Name, err)
+				}
+				report.Pets.add(Inserted)
+			case current.Birth.Format(time.DateOnly) == p.Birth.Format(time.DateOnly):
+				report.Pets.add(Unchanged)
+			default:
+				if _, err := tx.UpdatePet(ctx, current.ID, current.Version, p.Name, p.Birth, p.Breed); err != nil {
+					return fmt.Errorf("failed to seed pet %s: %w", p.Name, err)
+				}
+				report.Pets.add(Updated)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,332 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// SQLiteStore implementa los stores sobre un archivo SQLite (driver en Go puro, sin cgo),
+// pensado para demos en un solo equipo y desarrollo sin conexión. Comparte con
+// PostgresStore las consultas de lectura y las versiones de las migraciones.
+type SQLiteStore struct {
+	sqliteQueries
+	db *sql.DB
+}
+
+// sqliteQueries implementa las consultas sobre la conexión o sobre una transacción en curso.
+type sqliteQueries struct {
+	q   querier
+	now func() time.Time
+}
+
+// sqliteTx es la vista transaccional que recibe la función de SQLiteStore.WithinTx.
+type sqliteTx struct {
+	sqliteQueries
+}
+
+// OpenSQLite abre (o crea) la base de datos en path. Con ":memory:" la base de datos vive
+// solo mientras el store esté abierto.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	// Las transacciones toman el bloqueo de escritura al empezar: leer y luego escribir
+	// dentro de la misma transacción no puede fallar con SQLITE_BUSY a mitad de camino.
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+	if path != ":memory:" {
+		params.Add("_pragma", "journal_mode(WAL)")
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	if path == ":memory:" {
+		// Cada conexión a ":memory:" es una base de datos distinta.
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open sqlite database: %w", translateError(err))
+	}
+
+	log.Printf("Usando la base de datos SQLite %s", path)
+	return &SQLiteStore{sqliteQueries: sqliteQueries{q: db, now: time.Now}, db: db}, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Migrate aplica las migraciones de SQLite, que tienen las mismas versiones que las de Postgres.
+func (s *SQLiteStore) Migrate() error {
+	return migrate(s.db, DialectSQLite)
+}
+
+// WithinTx ejecuta fn dentro de una transacción: si fn devuelve error (o entra en pánico)
+// se hace rollback; si no, commit.
+func (s *SQLiteStore) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	return runTx(ctx, s.db, func(tx *sql.Tx) error {
+		return fn(&sqliteTx{sqliteQueries{q: tx, now: s.now}})
+	})
+}
+
+// WithinTx dentro de una transacción reutiliza la transacción en curso.
+func (t *sqliteTx) WithinTx(ctx context.Context, fn func(tx Tx) error) error {
+	return fn(t)
+}
+
+const (
+	insertSQLitePet = `
+		INSERT INTO pets (id, name, birth, breed_id, owner, version, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 1, $6)
+	`
+	updateSQLitePet = `
+		UPDATE pets
+		SET name=$3, birth=$4, breed_id=$5, version=version+1, updated_at=$6
+		WHERE id=$1 AND ($2 = 0 OR version=$2)
+		RETURNING version
+	`
+)
+
+// BREEDS
+func (s *sqliteQueries) GetBreeds(ctx context.Context) ([]types.Breed, error) {
+	return getBreeds(ctx, s.q)
+}
+
+func (s *sqliteQueries) GetBreedByID(ctx context.Context, id types.BreedID) (*types.Breed, error) {
+	return getBreedByID(ctx, s.q, id)
+}
+
+func (s *sqliteQueries) UpsertBreed(ctx context.Context, b types.Breed) (UpsertResult, error) {
+	return upsertBreed(ctx, s.q, b)
+}
+
+// PETS
+func (s *sqliteQueries) GetPets(ctx context.Context) ([]types.Pet, error) {
+	return getPets(ctx, s.q)
+}
+
+// GetPetByID no necesita FOR UPDATE: las transacciones de SQLite ya tienen el bloqueo de escritura.
+func (s *sqliteQueries) GetPetByID(ctx context.Context, id types.PetID) (*types.Pet, error) {
+	return getPetByID(ctx, s.q, id, false)
+}
+
+func (s *sqliteQueries) CreatePet(ctx context.Context, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	var pet *types.Pet
+	err := withTx(ctx, s.q, func(tx *sql.Tx) error {
+		breed, err := getBreedByID(ctx, tx, breedID)
+		if err != nil {
+			return fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+		}
+
+		pet = &types.Pet{
+			ID:        types.PetID(newUUID()),
+			Name:      name,
+			Birth:     dateOnly(birth),
+			Breed:     *breed,
+			Owner:     audit.FromContext(ctx).Subject,
+			Version:   1,
+			UpdatedAt: s.now().UTC(),
+		}
+		_, err = tx.ExecContext(ctx, insertSQLitePet, pet.ID, name, sqliteDate(birth), breedID, pet.Owner, pet.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert pet: %w", translateError(err))
+		}
+
+		return insertAudit(ctx, tx, audit.EntityPet, pet.ID.String(), audit.ActionCreate, nil, pet)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pet, nil
+}
+
+func (s *sqliteQueries) UpdatePet(ctx context.Context, id types.PetID, expectedVersion int64, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error) {
+	var pet *types.Pet
+	err := withTx(ctx, s.q, func(tx *sql.Tx) error {
+		before, err := getPetByID(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != AnyVersion && before.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		breed, err := getBreedByID(ctx, tx, breedID)
+		if err != nil {
+			return fmt.Errorf("failed to get breed with ID %s: %w", breedID, err)
+		}
+
+		pet = &types.Pet{
+			ID:        id,
+			Name:      name,
+			Birth:     dateOnly(birth),
+			Breed:     *breed,
+			Owner:     before.Owner,
+			UpdatedAt: s.now().UTC(),
+		}
+		err = tx.QueryRowContext(ctx, updateSQLitePet, id, expectedVersion, name, sqliteDate(birth), breedID, pet.UpdatedAt).Scan(&pet.Version)
+		switch err {
+		case sql.ErrNoRows:
+			return ErrVersionConflict
+		case nil:
+		default:
+			return fmt.Errorf("failed to update pet %s: %w", id, translateError(err))
+		}
+
+		return insertAudit(ctx, tx, audit.EntityPet, id.String(), audit.ActionUpdate, before, pet)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pet, nil
+}
+
+func (s *sqliteQueries) DeletePet(ctx context.Context, id types.PetID, expectedVersion int64) (*types.Pet, error) {
+	var before *types.Pet
+	err := withTx(ctx, s.q, func(tx *sql.Tx) error {
+		var err error
+		before, err = getPetByID(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != AnyVersion && before.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		res, err := tx.ExecContext(ctx, deletePet, id, expectedVersion)
+		if err != nil {
+			return fmt.Errorf("failed to delete pet %s: %w", id, translateError(err))
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to delete pet %s: %w", id, translateError(err))
+		} else if n == 0 {
+			return ErrVersionConflict
+		}
+
+		return insertAudit(ctx, tx, audit.EntityPet, id.String(), audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+// AUDIT
+func (s *sqliteQueries) GetAuditHistory(ctx context.Context, entityType, entityID string) ([]types.AuditEntry, error) {
+	return getAuditHistory(ctx, s.q, entityType, entityID)
+}
+
+// MEDICAL
+func (s *sqliteQueries) AddVetVisit(ctx context.Context, visit types.VetVisit) (*types.VetVisit, error) {
+	return addVetVisit(ctx, s.q, visit, s.now().UTC())
+}
+
+func (s *sqliteQueries) AddCondition(ctx context.Context, c types.Condition) (*types.Condition, error) {
+	return addCondition(ctx, s.q, c, s.now().UTC())
+}
+
+func (s *sqliteQueries) GetVetVisits(ctx context.Context, petID types.PetID) ([]types.VetVisit, error) {
+	return getVetVisits(ctx, s.q, petID)
+}
+
+func (s *sqliteQueries) GetConditions(ctx context.Context, petID types.PetID) ([]types.Condition, error) {
+	return getConditions(ctx, s.q, petID)
+}
+
+// MEDICATIONS
+func (s *sqliteQueries) AddMedication(ctx context.Context, m types.Medication) (*types.Medication, error) {
+	return addMedication(ctx, s.q, m, s.now().UTC())
+}
+
+func (s *sqliteQueries) GetMedications(ctx context.Context, petID types.PetID) ([]types.Medication, error) {
+	return getMedications(ctx, s.q, petID)
+}
+
+func (s *sqliteQueries) GetMedication(ctx context.Context, petID types.PetID, id string) (*types.Medication, error) {
+	return getMedication(ctx, s.q, petID, id)
+}
+
+func (s *sqliteQueries) RecordDose(ctx context.Context, d types.DoseRecord) (*types.DoseRecord, error) {
+	return recordDose(ctx, s.q, d, s.now().UTC())
+}
+
+func (s *sqliteQueries) GetDoseRecords(ctx context.Context, petID types.PetID, from, to time.Time) ([]types.DoseRecord, error) {
+	return getDoseRecords(ctx, s.q, petID, from, to)
+}
+
+// JOBS
+func (s *sqliteQueries) EnqueueJob(ctx context.Context, job types.Job) (bool, error) {
+	return enqueueJob(ctx, s.q, job, s.now().UTC())
+}
+
+func (s *sqliteQueries) ClaimJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]types.Job, error) {
+	return claimJobsFrom(ctx, s.q, claimJobsSQLite, now, lease, limit)
+}
+
+func (s *sqliteQueries) CompleteJob(ctx context.Context, id int64) error {
+	return execOne(ctx, s.q, fmt.Sprintf("update job %d", id), completeJob, id, s.now().UTC())
+}
+
+func (s *sqliteQueries) RetryJob(ctx context.Context, id int64, runAt time.Time, lastError string) error {
+	return execOne(ctx, s.q, fmt.Sprintf("update job %d", id), retryJob, id, runAt.UTC(), lastError, s.now().UTC())
+}
+
+func (s *sqliteQueries) FailJob(ctx context.Context, id int64, lastError string) error {
+	return execOne(ctx, s.q, fmt.Sprintf("update job %d", id), failJob, id, lastError, s.now().UTC())
+}
+
+// WEBHOOKS
+func (s *sqliteQueries) CreateWebhook(ctx context.Context, w types.Webhook) (*types.Webhook, error) {
+	return createWebhook(ctx, s.q, w, s.now().UTC())
+}
+
+func (s *sqliteQueries) GetWebhooks(ctx context.Context) ([]types.Webhook, error) {
+	return getWebhooks(ctx, s.q)
+}
+
+func (s *sqliteQueries) GetWebhook(ctx context.Context, id string) (*types.Webhook, error) {
+	return getWebhook(ctx, s.q, id)
+}
+
+func (s *sqliteQueries) DeleteWebhook(ctx context.Context, id string) error {
+	return execOne(ctx, s.q, "delete webhook "+id, deleteWebhook, id)
+}
+
+func (s *sqliteQueries) AddDelivery(ctx context.Context, d types.WebhookDelivery) (*types.WebhookDelivery, error) {
+	return addDelivery(ctx, s.q, d, s.now().UTC())
+}
+
+func (s *sqliteQueries) RecordDeliveryAttempt(ctx context.Context, id int64, status types.DeliveryStatus, responseStatus int, lastError string) error {
+	return execOne(ctx, s.q, fmt.Sprintf("update webhook delivery %d", id), updateDelivery, id, string(status), responseStatus, lastError, s.now().UTC())
+}
+
+func (s *sqliteQueries) GetDeliveries(ctx context.Context, webhookID string, limit int) ([]types.WebhookDelivery, error) {
+	return getDeliveries(ctx, s.q, webhookID, limit)
+}
+
+// SYNC
+// SQLite admite un único escritor, así que los IDs de audit_log ya se confirman en orden.
+func (s *sqliteQueries) PetSnapshot(ctx context.Context) ([]types.Pet, int64, error) {
+	return petSnapshot(ctx, s.q)
+}
+
+func (s *sqliteQueries) PetChanges(ctx context.Context, since int64, limit int) ([]types.PetChange, error) {
+	return petChanges(ctx, s.q, since, limit)
+}
+
+// sqliteDate guarda solo la fecha ("2006-01-02"), igual que una columna DATE de Postgres.
+func sqliteDate(t time.Time) string {
+	return dateOnly(t).Format(time.DateOnly)
+}
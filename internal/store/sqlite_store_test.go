@@ -0,0 +1,81 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// setupSQLiteTestDB crea una base de datos SQLite nueva en un directorio temporal, con las
+// migraciones aplicadas y los datos de prueba cargados.
+func setupSQLiteTestDB(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("No se pudo abrir la base de datos SQLite: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("No se pudieron aplicar las migraciones de prueba: %v", err)
+	}
+	if _, err := Seed(ctx, store, testFixtures); err != nil {
+		t.Fatalf("No se pudieron cargar los datos de prueba: %v", err)
+	}
+	return store
+}
+
+func TestSQLiteStore(t *testing.T) {
+	runIntegrationSuite(t, func(t *testing.T) integrationStore { return setupSQLiteTestDB(t) })
+}
+
+func TestSQLiteStoreDetails(t *testing.T) {
+	store := setupSQLiteTestDB(t)
+
+	t.Run("birth is stored as a date", func(t *testing.T) {
+		local := time.Date(2021, 3, 4, 23, 30, 0, 0, time.FixedZone("UTC-3", -3*3600))
+		pet, err := store.CreatePet(ctx, "Luna", local, "poodle")
+		if err != nil {
+			t.Fatalf("CreatePet failed: %v", err)
+		}
+		got, err := store.GetPetByID(ctx, pet.ID)
+		if err != nil {
+			t.Fatalf("GetPetByID failed: %v", err)
+		}
+		if want := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC); !got.Birth.Equal(want) {
+			t.Errorf("expected birth %v, got %v", want, got.Birth)
+		}
+	})
+
+	t.Run("foreign keys are enforced", func(t *testing.T) {
+		_, err := store.db.Exec("INSERT INTO pets (id, name, birth, breed_id) VALUES ('x', 'Ghost', '2020-01-01', 'does-not-exist')")
+		if !errors.Is(translateError(err), ErrForeignKeyViolation) {
+			t.Errorf("expected ErrForeignKeyViolation, got %v", err)
+		}
+	})
+
+	t.Run("migrations are idempotent", func(t *testing.T) {
+		if err := store.Migrate(); err != nil {
+			t.Errorf("second Migrate failed: %v", err)
+		}
+	})
+}
+
+func TestMigrationsShareVersions(t *testing.T) {
+	versions := func(d Dialect) []string {
+		migrations, err := Migrations(d)
+		if err != nil {
+			t.Fatalf("Migrations(%s) failed: %v", d, err)
+		}
+		var out []string
+		for _, m := range migrations {
+			out = append(out, m.Version)
+		}
+		return out
+	}
+	pg, lite := versions(DialectPostgres), versions(DialectSQLite)
+	if len(pg) == 0 || !slices.Equal(pg, lite) {
+		t.Errorf("every dialect must have the same migrations: postgres %v, sqlite %v", pg, lite)
+	}
+}
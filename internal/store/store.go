@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -10,16 +11,177 @@ import (
 var (
 	ErrNotFound            = errors.New("not found")
 	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrVersionConflict indica que el registro cambió desde que el cliente lo leyó.
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrUniqueViolation indica que ya existe un registro con la misma clave.
+	ErrUniqueViolation = errors.New("unique violation")
+	// ErrInvalidInput indica que la base de datos rechazó un valor por su formato (p. ej. un UUID mal formado).
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrCanceled indica que la operación se canceló (por el cliente o por un timeout).
+	ErrCanceled = errors.New("operation canceled")
+	// ErrUnavailable indica que la base de datos no está disponible; la operación puede reintentarse.
+	ErrUnavailable = errors.New("store unavailable")
 )
 
+// AnyVersion desactiva la comprobación de versión en UpdatePet y DeletePet.
+const AnyVersion int64 = 0
+
+// Todas las implementaciones deben comportarse igual; storetest.Run lo comprueba.
+// Los IDs inexistentes devuelven un error que cumple errors.Is(err, ErrNotFound).
+
+// BreedStore da acceso a las razas. GetBreeds las ordena por nombre (y luego por ID).
 type BreedStore interface {
-	GetBreedByID(id string) (*types.Breed, error)
-	GetBreeds() ([]types.Breed, error)
+	GetBreedByID(ctx context.Context, id types.BreedID) (*types.Breed, error)
+	GetBreeds(ctx context.Context) ([]types.Breed, error)
+}
+
+// Resultados de UpsertBreed.
+type UpsertResult string
+
+const (
+	Inserted  UpsertResult = "inserted"
+	Updated   UpsertResult = "updated"
+	Unchanged UpsertResult = "unchanged"
+)
+
+// BreedWriter carga razas. La API no las modifica: las cargan dogctl y los fixtures. Las
+// altas y los cambios se auditan igual que los de las mascotas.
+type BreedWriter interface {
+	// UpsertBreed crea la raza o, si ya existe una con su ID, reemplaza sus campos. Una raza
+	// idéntica a la guardada no se modifica ni se audita, así que cargar dos veces los mismos
+	// datos no cambia nada. Un tamaño desconocido devuelve ErrInvalidInput.
+	UpsertBreed(ctx context.Context, b types.Breed) (UpsertResult, error)
 }
 
+// PetStore da acceso a las mascotas. GetPets las ordena por nombre (y luego por ID).
+// CreatePet guarda como dueño (Pet.Owner) el audit.Meta.Subject del contexto.
+// Birth se guarda como fecha sin hora: se conserva el día de calendario en la zona horaria
+// recibida y se devuelve como medianoche UTC. Crear o actualizar una mascota con una raza
+// inexistente devuelve ErrNotFound sin modificar nada.
 type PetStore interface {
-	GetPets() ([]types.Pet, error)
-	GetPetByID(id string) (*types.Pet, error)
-	CreatePet(name string, birth time.Time, breedID string) (*types.Pet, error)
-	DeletePet(id string) error
+	GetPets(ctx context.Context) ([]types.Pet, error)
+	GetPetByID(ctx context.Context, id types.PetID) (*types.Pet, error)
+	CreatePet(ctx context.Context, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error)
+	// UpdatePet y DeletePet solo modifican la mascota si su versión actual es expectedVersion
+	// (o si es AnyVersion); si no, devuelven ErrVersionConflict.
+	UpdatePet(ctx context.Context, id types.PetID, expectedVersion int64, name string, birth time.Time, breedID types.BreedID) (*types.Pet, error)
+	// DeletePet devuelve la mascota tal como estaba antes de borrarla.
+	DeletePet(ctx context.Context, id types.PetID, expectedVersion int64) (*types.Pet, error)
+}
+
+// AuditStore permite consultar el historial de cambios. Las entradas las escriben las propias
+// operaciones de escritura, en la misma transacción que el cambio, usando audit.FromContext(ctx).
+type AuditStore interface {
+	GetAuditHistory(ctx context.Context, entityType, entityID string) ([]types.AuditEntry, error)
+}
+
+// MedicalStore guarda el historial médico de las mascotas. Las visitas y condiciones se
+// borran junto con su mascota. Todas las operaciones devuelven ErrNotFound si la mascota no
+// existe; las listas de una mascota sin historial están vacías.
+type MedicalStore interface {
+	// AddVetVisit guarda la visita de visit.PetID y devuelve la visita con ID y CreatedAt.
+	// Date se guarda como fecha sin hora, igual que Pet.Birth.
+	AddVetVisit(ctx context.Context, visit types.VetVisit) (*types.VetVisit, error)
+	// AddCondition guarda la enfermedad o alergia de c.PetID y la devuelve con ID y CreatedAt.
+	AddCondition(ctx context.Context, c types.Condition) (*types.Condition, error)
+	// GetVetVisits devuelve las visitas por fecha (y luego por fecha de registro).
+	GetVetVisits(ctx context.Context, petID types.PetID) ([]types.VetVisit, error)
+	// GetConditions devuelve las condiciones en el orden en que se registraron.
+	GetConditions(ctx context.Context, petID types.PetID) ([]types.Condition, error)
+}
+
+// MedicationStore guarda las pautas de medicación de las mascotas y las tomas ya anotadas.
+// Las tomas previstas no se guardan: se calculan con types.ScheduleDoses. Las pautas y sus
+// tomas se borran junto con su mascota.
+type MedicationStore interface {
+	// AddMedication guarda la pauta de m.PetID (ErrNotFound si no existe) y la devuelve con
+	// ID y CreatedAt. Start y End se guardan en UTC, truncados al segundo.
+	AddMedication(ctx context.Context, m types.Medication) (*types.Medication, error)
+	// GetMedications devuelve las pautas de la mascota en el orden en que se registraron.
+	GetMedications(ctx context.Context, petID types.PetID) ([]types.Medication, error)
+	// GetMedication devuelve ErrNotFound si la pauta no existe o es de otra mascota.
+	GetMedication(ctx context.Context, petID types.PetID, id string) (*types.Medication, error)
+	// RecordDose anota una toma y la devuelve con RecordedAt. Devuelve ErrNotFound si la
+	// pauta no existe y ErrUniqueViolation si esa toma ya estaba anotada.
+	RecordDose(ctx context.Context, d types.DoseRecord) (*types.DoseRecord, error)
+	// GetDoseRecords devuelve las tomas anotadas de todas las pautas de la mascota con
+	// from <= ScheduledAt < to, en orden cronológico.
+	GetDoseRecords(ctx context.Context, petID types.PetID, from, to time.Time) ([]types.DoseRecord, error)
+}
+
+// JobStore es la cola persistente del planificador (internal/scheduler). La entrega es
+// "al menos una vez": un trabajo reclamado cuya reserva caduca sin que se cierre se vuelve
+// a entregar. CompleteJob, RetryJob y FailJob devuelven ErrNotFound si el trabajo no existe.
+type JobStore interface {
+	// EnqueueJob guarda el trabajo como pendiente y devuelve true. Si ya existe uno con la
+	// misma Key no hace nada y devuelve false. MaxAttempts es al menos 1.
+	EnqueueJob(ctx context.Context, job types.Job) (bool, error)
+	// ClaimJobs reserva hasta limit trabajos con RunAt <= now que estén pendientes o cuya
+	// reserva haya caducado, los pasa a JobRunning hasta now+lease, incrementa Attempts y
+	// los devuelve ordenados por RunAt (y luego por ID).
+	ClaimJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]types.Job, error)
+	// CompleteJob marca el trabajo como JobDone.
+	CompleteJob(ctx context.Context, id int64) error
+	// RetryJob vuelve a dejar el trabajo pendiente para runAt y guarda el error del intento.
+	RetryJob(ctx context.Context, id int64, runAt time.Time, lastError string) error
+	// FailJob marca el trabajo como JobFailed: no se vuelve a entregar.
+	FailJob(ctx context.Context, id int64, lastError string) error
+}
+
+// WebhookStore guarda las suscripciones a webhooks y el registro de sus entregas. Las
+// entregas se borran junto con su webhook.
+type WebhookStore interface {
+	// CreateWebhook guarda la suscripción y la devuelve con ID y CreatedAt.
+	CreateWebhook(ctx context.Context, w types.Webhook) (*types.Webhook, error)
+	// GetWebhooks devuelve las suscripciones en el orden en que se crearon.
+	GetWebhooks(ctx context.Context) ([]types.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*types.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	// AddDelivery registra una entrega pendiente de d.WebhookID (ErrNotFound si no existe)
+	// y la devuelve con ID y CreatedAt.
+	AddDelivery(ctx context.Context, d types.WebhookDelivery) (*types.WebhookDelivery, error)
+	// RecordDeliveryAttempt anota el resultado de un intento de la entrega id.
+	RecordDeliveryAttempt(ctx context.Context, id int64, status types.DeliveryStatus, responseStatus int, lastError string) error
+	// GetDeliveries devuelve las últimas limit entregas del webhook, de la más reciente a
+	// la más antigua. Devuelve ErrNotFound si el webhook no existe.
+	GetDeliveries(ctx context.Context, webhookID string, limit int) ([]types.WebhookDelivery, error)
+}
+
+// SyncStore da a los clientes sin conexión los cambios de las mascotas a partir de un
+// token: un número que crece con cada cambio confirmado, sin que ninguno anterior pueda
+// confirmarse después.
+type SyncStore interface {
+	// PetSnapshot devuelve todas las mascotas, como GetPets, y el token a partir del cual
+	// pedir los siguientes cambios. Los cambios posteriores al token pueden estar ya
+	// incluidos en las mascotas, pero ninguno anterior falta.
+	PetSnapshot(ctx context.Context) ([]types.Pet, int64, error)
+	// PetChanges devuelve hasta limit cambios de mascotas con Seq > since, en orden de Seq.
+	PetChanges(ctx context.Context, since int64, limit int) ([]types.PetChange, error)
+}
+
+// PubSub reparte mensajes entre todas las instancias que comparten la base de datos. Lo
+// implementan los stores de Postgres con LISTEN/NOTIFY. Los mensajes no se guardan: lo que
+// se publica mientras una instancia está desconectada se pierde para ella.
+type PubSub interface {
+	// Notify envía payload (como mucho unos 8000 bytes) a quienes escuchan channel.
+	Notify(ctx context.Context, channel, payload string) error
+	// Listen llama a fn con cada mensaje de channel, reconectando si se pierde la conexión,
+	// hasta que se cancela ctx.
+	Listen(ctx context.Context, channel string, fn func(payload string)) error
+}
+
+// Tx agrupa las operaciones disponibles dentro de una transacción (unit of work).
+type Tx interface {
+	BreedStore
+	BreedWriter
+	PetStore
+	AuditStore
+	Transactor
+}
+
+// Transactor ejecuta varias operaciones de forma atómica. Si fn devuelve un error, todos
+// los cambios hechos a través de tx se descartan; si no, se confirman juntos.
+// Llamar a WithinTx sobre un tx reutiliza la transacción en curso.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(tx Tx) error) error
 }
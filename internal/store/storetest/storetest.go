@@ -0,0 +1,1296 @@
+// Package storetest contiene la suite de conformidad de los stores: cualquier implementación
+// de store.BreedStore y store.PetStore debe pasarla, de modo que todos los backends (y los
+// stores usados en los tests de los handlers) se comporten exactamente igual.
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/fixtures"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Store es lo mínimo que ejercita la suite. Si la implementación también cumple
+// store.BreedWriter, store.AuditStore, store.MedicalStore, store.MedicationStore,
+// store.JobStore, store.WebhookStore, store.SyncStore o store.Transactor, se comprueban
+// además la carga de razas, la auditoría, el historial médico, la medicación, la cola de
+// trabajos, los webhooks, la sincronización y las transacciones.
+type Store interface {
+	store.BreedStore
+	store.PetStore
+}
+
+// Breeds son las razas que la suite espera encontrar en el store: las de fixtures.Base, que
+// también carga db-setup-test (ver Makefile), ordenadas por nombre. El store puede contener
+// además otras razas y mascotas.
+var Breeds = slices.SortedFunc(slices.Values(fixtures.MustLoad(fixtures.Base).Breeds), func(a, b types.Breed) int {
+	return strings.Compare(a.Name, b.Name)
+})
+
+// missingPetID es un UUID válido que ningún store debería tener.
+const missingPetID = types.PetID("00000000-0000-4000-8000-000000000000")
+
+// Run ejecuta la suite. newStore se llama una vez por test y debe devolver un store que
+// contenga al menos Breeds. Los tests solo miran las mascotas que crean ellos mismos, así
+// que newStore puede devolver siempre la misma base de datos compartida.
+func Run(t *testing.T, newStore func(t *testing.T) Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s Store)
+	}{
+		{"BreedsOrdered", testBreedsOrdered},
+		{"BreedNotFound", testBreedNotFound},
+		{"CreateAndGet", testCreateAndGet},
+		{"PetsOrdered", testPetsOrdered},
+		{"PetNotFound", testPetNotFound},
+		{"UnknownBreed", testUnknownBreed},
+		{"UpsertBreed", testUpsertBreed},
+		{"Seed", testSeed},
+		{"Versions", testVersions},
+		{"Owner", testOwner},
+		{"BirthTimeZones", testBirthTimeZones},
+		{"ConcurrentCreates", testConcurrentCreates},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+		{"Audit", testAudit},
+		{"Medical", testMedical},
+		{"Medications", testMedications},
+		{"Jobs", testJobs},
+		{"Webhooks", testWebhooks},
+		{"EventOutbox", testEventOutbox},
+		{"Sync", testSync},
+		{"SyncConcurrent", testSyncConcurrent},
+		{"Transactions", testTransactions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { tt.fn(t, newStore(t)) })
+	}
+}
+
+// uniqueName devuelve un nombre que no existe en el store, para poder reconocer las
+// mascotas creadas por el test aunque la base de datos sea compartida.
+func uniqueName(suffix string) string {
+	return fmt.Sprintf("conformance-%d-%s", time.Now().UnixNano(), suffix)
+}
+
+// createPet crea una mascota y la borra al terminar el test.
+func createPet(t *testing.T, s Store, name string, birth time.Time, breedID types.BreedID) *types.Pet {
+	t.Helper()
+	pet, err := s.CreatePet(context.Background(), name, birth, breedID)
+	if err != nil {
+		t.Fatalf("CreatePet(%q) failed: %v", name, err)
+	}
+	t.Cleanup(func() { s.DeletePet(context.Background(), pet.ID, store.AnyVersion) })
+	return pet
+}
+
+// petsNamed devuelve, en el orden de GetPets, las mascotas cuyo nombre empieza por prefix.
+func petsNamed(t *testing.T, s Store, prefix string) []types.Pet {
+	t.Helper()
+	pets, err := s.GetPets(context.Background())
+	if err != nil {
+		t.Fatalf("GetPets failed: %v", err)
+	}
+	var found []types.Pet
+	for _, p := range pets {
+		if strings.HasPrefix(p.Name, prefix) {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// samePet compara dos mascotas ignorando UpdatedAt, cuya precisión depende del backend.
+func samePet(t *testing.T, got, want types.Pet) {
+	t.Helper()
+	if got.ID != want.ID || got.Name != want.Name || got.Breed != want.Breed || got.Owner != want.Owner || got.Version != want.Version {
+		t.Errorf("pets differ:\n got  %+v\n want %+v", got, want)
+	}
+	if !got.Birth.Equal(want.Birth) {
+		t.Errorf("birth differs: got %v, want %v", got.Birth, want.Birth)
+	}
+}
+
+func testBreedsOrdered(t *testing.T, s Store) {
+	breeds, err := s.GetBreeds(context.Background())
+	if err != nil {
+		t.Fatalf("GetBreeds failed: %v", err)
+	}
+	// Solo se comprueba el orden relativo de Breeds: las colaciones de cada base de datos
+	// pueden ordenar distinto otros nombres, pero coinciden en estos.
+	var got []types.Breed
+	for _, b := range breeds {
+		if slices.Contains(Breeds, b) {
+			got = append(got, b)
+		}
+	}
+	if !slices.Equal(got, Breeds) {
+		t.Errorf("expected breeds ordered by name %v, got %v", Breeds, got)
+	}
+}
+
+func testBreedNotFound(t *testing.T, s Store) {
+	want := Breeds[2]
+	got, err := s.GetBreedByID(context.Background(), want.ID)
+	if err != nil {
+		t.Fatalf("GetBreedByID(%s) failed: %v", want.ID, err)
+	}
+	if *got != want {
+		t.Errorf("expected %+v, got %+v", want, *got)
+	}
+
+	if _, err := s.GetBreedByID(context.Background(), "no-such-breed"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func testCreateAndGet(t *testing.T, s Store) {
+	name := uniqueName("fido")
+	created := createPet(t, s, name, day(2020, time.January, 2), "poodle")
+
+	if _, err := types.ParsePetID(created.ID.String()); err != nil {
+		t.Errorf("created ID %q is not a canonical UUID: %v", created.ID, err)
+	}
+	if created.Version != 1 {
+		t.Errorf("expected version 1, got %d", created.Version)
+	}
+	if created.UpdatedAt.IsZero() {
+		t.Errorf("expected UpdatedAt to be set")
+	}
+	samePet(t, *created, types.Pet{ID: created.ID, Name: name, Birth: day(2020, time.January, 2), Breed: Breeds[4], Version: 1})
+
+	got, err := s.GetPetByID(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("GetPetByID failed: %v", err)
+	}
+	samePet(t, *got, *created)
+
+	if listed := petsNamed(t, s, name); len(listed) != 1 {
+		t.Errorf("expected GetPets to list the new pet once, got %d", len(listed))
+	} else {
+		samePet(t, listed[0], *created)
+	}
+}
+
+func testPetsOrdered(t *testing.T, s Store) {
+	prefix := uniqueName("")
+	birth := day(2020, time.January, 1)
+	// Se crean desordenadas; dos con el mismo nombre para comprobar el desempate por ID.
+	c := createPet(t, s, prefix+"c", birth, "poodle")
+	a := createPet(t, s, prefix+"a", birth, "poodle")
+	b1 := createPet(t, s, prefix+"b", birth, "poodle")
+	b2 := createPet(t, s, prefix+"b", birth, "bulldog")
+	if b2.ID < b1.ID {
+		b1, b2 = b2, b1
+	}
+
+	var got []types.PetID
+	for _, p := range petsNamed(t, s, prefix) {
+		got = append(got, p.ID)
+	}
+	want := []types.PetID{a.ID, b1.ID, b2.ID, c.ID}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected pets ordered by name and ID %v, got %v", want, got)
+	}
+}
+
+func testPetNotFound(t *testing.T, s Store) {
+	if _, err := s.GetPetByID(context.Background(), missingPetID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetPetByID: expected ErrNotFound, got %v", err)
+	}
+	_, err := s.UpdatePet(context.Background(), missingPetID, store.AnyVersion, "Ghost", day(2020, time.January, 1), "poodle")
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("UpdatePet: expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.DeletePet(context.Background(), missingPetID, store.AnyVersion); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeletePet: expected ErrNotFound, got %v", err)
+	}
+}
+
+// testUnknownBreed comprueba que una raza inexistente es ErrNotFound (no una violación de
+// clave foránea) y que no se escribe nada.
+func testUnknownBreed(t *testing.T, s Store) {
+	name := uniqueName("orphan")
+	_, err := s.CreatePet(context.Background(), name, day(2020, time.January, 1), "no-such-breed")
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("CreatePet: expected ErrNotFound, got %v", err)
+	}
+	if pets := petsNamed(t, s, name); len(pets) != 0 {
+		t.Errorf("CreatePet with an unknown breed stored %d pets", len(pets))
+	}
+
+	pet := createPet(t, s, uniqueName("fido"), day(2020, time.January, 1), "poodle")
+	_, err = s.UpdatePet(context.Background(), pet.ID, pet.Version, "Rex", day(2021, time.March, 4), "no-such-breed")
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("UpdatePet: expected ErrNotFound, got %v", err)
+	}
+	got, err := s.GetPetByID(context.Background(), pet.ID)
+	if err != nil {
+		t.Fatalf("GetPetByID failed: %v", err)
+	}
+	samePet(t, *got, *pet)
+}
+
+func testVersions(t *testing.T, s Store) {
+	pet := createPet(t, s, uniqueName("fido"), day(2020, time.January, 1), "poodle")
+
+	updated, err := s.UpdatePet(context.Background(), pet.ID, 1, pet.Name, day(2021, time.March, 4), "bulldog")
+	if err != nil {
+		t.Fatalf("UpdatePet failed: %v", err)
+	}
+	samePet(t, *updated, types.Pet{ID: pet.ID, Name: pet.Name, Birth: day(2021, time.March, 4), Breed: Breeds[0], Version: 2})
+	if updated.UpdatedAt.Before(pet.UpdatedAt) {
+		t.Errorf("UpdatedAt went backwards: %v -> %v", pet.UpdatedAt, updated.UpdatedAt)
+	}
+
+	if _, err := s.UpdatePet(context.Background(), pet.ID, 1, "Stale", pet.Birth, "poodle"); !errors.Is(err, store.ErrVersionConflict) {
+		t.Errorf("stale UpdatePet: expected ErrVersionConflict, got %v", err)
+	}
+	if updated, err = s.UpdatePet(context.Background(), pet.ID, store.AnyVersion, pet.Name, pet.Birth, "poodle"); err != nil {
+		t.Fatalf("UpdatePet with AnyVersion failed: %v", err)
+	} else if updated.Version != 3 {
+		t.Errorf("expected version 3, got %d", updated.Version)
+	}
+
+	if _, err := s.DeletePet(context.Background(), pet.ID, 2); !errors.Is(err, store.ErrVersionConflict) {
+		t.Errorf("stale DeletePet: expected ErrVersionConflict, got %v", err)
+	}
+	deleted, err := s.DeletePet(context.Background(), pet.ID, 3)
+	if err != nil {
+		t.Fatalf("DeletePet failed: %v", err)
+	}
+	samePet(t, *deleted, *updated)
+	if _, err := s.GetPetByID(context.Background(), pet.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+// testOwner comprueba que una mascota guarda como dueño el sujeto que la creó y que lo
+// conserva aunque la modifique o la borre otro.
+func testOwner(t *testing.T, s Store) {
+	as := func(subject string) context.Context {
+		return audit.WithMeta(context.Background(), audit.Meta{Actor: "conformance", Subject: subject})
+	}
+	pet, err := s.CreatePet(as("alice"), uniqueName("owned"), day(2020, time.January, 1), "poodle")
+	if err != nil {
+		t.Fatalf("CreatePet failed: %v", err)
+	}
+	if pet.Owner != "alice" {
+		t.Errorf("expected alice as the owner, got %q", pet.Owner)
+	}
+	if got, err := s.GetPetByID(context.Background(), pet.ID); err != nil || got.Owner != "alice" {
+		t.Errorf("expected GetPetByID to return the owner, got %+v (%v)", got, err)
+	}
+	if listed := petsNamed(t, s, pet.Name); len(listed) != 1 || listed[0].Owner != "alice" {
+		t.Errorf("expected GetPets to return the owner, got %+v", listed)
+	}
+
+	updated, err := s.UpdatePet(as("bob"), pet.ID, pet.Version, "Rex", pet.Birth, "bulldog")
+	if err != nil {
+		t.Fatalf("UpdatePet failed: %v", err)
+	}
+	if updated.Owner != "alice" {
+		t.Errorf("expected the update to keep the owner, got %q", updated.Owner)
+	}
+	deleted, err := s.DeletePet(as("bob"), pet.ID, store.AnyVersion)
+	if err != nil {
+		t.Fatalf("DeletePet failed: %v", err)
+	}
+	samePet(t, *deleted, *updated)
+
+	anonymous := createPet(t, s, uniqueName("stray"), day(2020, time.January, 1), "poodle")
+	if anonymous.Owner != "" {
+		t.Errorf("expected no owner without a subject, got %q", anonymous.Owner)
+	}
+}
+
+// testBirthTimeZones comprueba que Birth conserva el día de calendario en la zona horaria
+// recibida, aunque en UTC sea otro día, y que se devuelve como medianoche UTC.
+func testBirthTimeZones(t *testing.T, s Store) {
+	tests := []struct {
+		name  string
+		birth time.Time
+		want  time.Time
+	}{
+		{"utc midnight", day(2021, time.March, 4), day(2021, time.March, 4)},
+		{"late west of UTC", time.Date(2021, time.March, 4, 23, 30, 0, 0, time.FixedZone("UTC-3", -3*3600)), day(2021, time.March, 4)},
+		{"early east of UTC", time.Date(2021, time.March, 5, 0, 30, 0, 0, time.FixedZone("UTC+14", 14*3600)), day(2021, time.March, 5)},
+		{"leap day", time.Date(2020, time.February, 29, 12, 0, 0, 0, time.FixedZone("UTC+5:30", 5*3600+1800)), day(2020, time.February, 29)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := createPet(t, s, uniqueName("tz"), tt.birth, "poodle")
+			if !created.Birth.Equal(tt.want) {
+				t.Errorf("CreatePet: expected birth %v, got %v", tt.want, created.Birth)
+			}
+			got, err := s.GetPetByID(context.Background(), created.ID)
+			if err != nil {
+				t.Fatalf("GetPetByID failed: %v", err)
+			}
+			if !got.Birth.Equal(tt.want) {
+				t.Errorf("GetPetByID: expected birth %v, got %v", tt.want, got.Birth)
+			}
+
+			updated, err := s.UpdatePet(context.Background(), created.ID, store.AnyVersion, created.Name, tt.birth, "poodle")
+			if err != nil {
+				t.Fatalf("UpdatePet failed: %v", err)
+			}
+			if !updated.Birth.Equal(tt.want) {
+				t.Errorf("UpdatePet: expected birth %v, got %v", tt.want, updated.Birth)
+			}
+		})
+	}
+}
+
+const concurrency = 8
+
+func testConcurrentCreates(t *testing.T, s Store) {
+	prefix := uniqueName("")
+	var wg sync.WaitGroup
+	for i := range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pet, err := s.CreatePet(context.Background(), fmt.Sprintf("%s%02d", prefix, i), day(2020, time.January, 1), "poodle")
+			if err != nil {
+				t.Errorf("concurrent CreatePet failed: %v", err)
+				return
+			}
+			t.Cleanup(func() { s.DeletePet(context.Background(), pet.ID, store.AnyVersion) })
+		}()
+	}
+	wg.Wait()
+
+	ids := map[types.PetID]bool{}
+	for _, p := range petsNamed(t, s, prefix) {
+		ids[p.ID] = true
+	}
+	if len(ids) != concurrency {
+		t.Errorf("expected %d distinct pets, got %d", concurrency, len(ids))
+	}
+}
+
+// testConcurrentUpdates comprueba que, de varias actualizaciones simultáneas sobre la misma
+// versión, exactamente una gana y las demás reciben ErrVersionConflict.
+func testConcurrentUpdates(t *testing.T, s Store) {
+	pet := createPet(t, s, uniqueName("fido"), day(2020, time.January, 1), "poodle")
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		won       int
+		conflicts int
+	)
+	for i := range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.UpdatePet(context.Background(), pet.ID, pet.Version, fmt.Sprintf("%s-%d", pet.Name, i), pet.Birth, "poodle")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				won++
+			case errors.Is(err, store.ErrVersionConflict):
+				conflicts++
+			default:
+				t.Errorf("concurrent UpdatePet failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if won != 1 || conflicts != concurrency-1 {
+		t.Errorf("expected 1 winner and %d conflicts, got %d and %d", concurrency-1, won, conflicts)
+	}
+	got, err := s.GetPetByID(context.Background(), pet.ID)
+	if err != nil {
+		t.Fatalf("GetPetByID failed: %v", err)
+	}
+	if got.Version != 2 {
+		t.Errorf("expected version 2, got %d", got.Version)
+	}
+}
+
+// testAudit comprueba que cada escritura confirmada deja exactamente una entrada de
+// auditoría y que las escrituras rechazadas no dejan ninguna.
+func testUpsertBreed(t *testing.T, s Store) {
+	bw, ok := s.(store.BreedWriter)
+	if !ok {
+		t.Skip("the store does not implement store.BreedWriter")
+	}
+	ctx := context.Background()
+
+	breed := types.Breed{ID: types.BreedID(uniqueName("breed")), Name: "Conformance", Temperament: "Calm", Origin: "Nowhere", Size: types.SizeSmall}
+	upsert := func(b types.Breed, want store.UpsertResult) {
+		t.Helper()
+		got, err := bw.UpsertBreed(ctx, b)
+		if err != nil {
+			t.Fatalf("UpsertBreed failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+		stored, err := s.GetBreedByID(ctx, b.ID)
+		if err != nil {
+			t.Fatalf("GetBreedByID failed: %v", err)
+		}
+		if *stored != b {
+			t.Errorf("expected %+v, got %+v", b, *stored)
+		}
+	}
+	upsert(breed, store.Inserted)
+	upsert(breed, store.Unchanged)
+
+	pet := createPet(t, s, uniqueName("upsert"), day(2020, time.January, 1), breed.ID)
+	breed.Temperament, breed.Size = "Playful", types.SizeGiant
+	upsert(breed, store.Updated)
+	got, err := s.GetPetByID(ctx, pet.ID)
+	if err != nil {
+		t.Fatalf("GetPetByID failed: %v", err)
+	}
+	if got.Breed != breed {
+		t.Errorf("expected the pet to have the updated breed, got %+v", got.Breed)
+	}
+
+	invalid := breed
+	invalid.Size = "huge"
+	if _, err := bw.UpsertBreed(ctx, invalid); !errors.Is(err, store.ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for an unknown size, got %v", err)
+	}
+
+	if as, ok := s.(store.AuditStore); ok {
+		entries, err := as.GetAuditHistory(ctx, audit.EntityBreed, breed.ID.String())
+		if err != nil {
+			t.Fatalf("GetAuditHistory failed: %v", err)
+		}
+		var actions []string
+		for _, e := range entries {
+			actions = append(actions, e.Action)
+		}
+		if want := []string{audit.ActionCreate, audit.ActionUpdate}; !slices.Equal(actions, want) {
+			t.Errorf("expected actions %v, got %v", want, actions)
+		}
+	}
+}
+
+func testSeed(t *testing.T, s Store) {
+	tr, ok := s.(store.Transactor)
+	if !ok {
+		t.Skip("the store does not implement store.Transactor")
+	}
+	ctx := context.Background()
+	prefix := uniqueName("seed")
+	set := &fixtures.Set{
+		Breeds: []types.Breed{Breeds[0], {ID: types.BreedID(prefix), Name: "Seeded", Size: types.SizeSmall}},
+		Pets: []fixtures.Pet{
+			{Name: prefix + "-a", Birth: day(2020, time.March, 4), Breed: Breeds[0].ID},
+			{Name: prefix + "-b", Birth: day(2021, time.April, 5), Breed: types.BreedID(prefix)},
+		},
+	}
+	t.Cleanup(func() {
+		for _, p := range petsNamed(t, s, prefix) {
+			s.DeletePet(ctx, p.ID, store.AnyVersion)
+		}
+	})
+	seed := func(want store.SeedReport) {
+		t.Helper()
+		got, err := store.Seed(ctx, tr, set)
+		if err != nil {
+			t.Fatalf("Seed failed: %v", err)
+		}
+		if *got != want {
+			t.Errorf("expected %+v, got %+v", want, *got)
+		}
+	}
+
+	seed(store.SeedReport{Breeds: store.SeedCounts{Inserted: 1, Unchanged: 1}, Pets: store.SeedCounts{Inserted: 2}})
+	seed(store.SeedReport{Breeds: store.SeedCounts{Unchanged: 2}, Pets: store.SeedCounts{Unchanged: 2}})
+
+	set.Pets[1].Birth = day(2021, time.May, 6)
+	seed(store.SeedReport{Breeds: store.SeedCounts{Unchanged: 2}, Pets: store.SeedCounts{Updated: 1, Unchanged: 1}})
+	pets := petsNamed(t, s, prefix)
+	if len(pets) != 2 || pets[1].Breed.ID != types.BreedID(prefix) || !pets[1].Birth.Equal(set.Pets[1].Birth) || pets[1].Version != 2 {
+		t.Errorf("unexpected seeded pets %+v", pets)
+	}
+
+	// Una mascota de una raza que no existe deshace la carga entera.
+	set.Pets = append(set.Pets, fixtures.Pet{Name: prefix + "-c", Birth: day(2022, time.June, 7), Breed: "missing-breed"})
+	set.Pets[0].Birth = day(2019, time.January, 1)
+	if _, err := store.Seed(ctx, tr, set); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if pets := petsNamed(t, s, prefix); len(pets) != 2 || !pets[0].Birth.Equal(day(2020, time.March, 4)) {
+		t.Errorf("expected the failed seed to be rolled back, got %+v", pets)
+	}
+}
+
+func testAudit(t *testing.T, s Store) {
+	as, ok := s.(store.AuditStore)
+	if !ok {
+		t.Skip("the store does not implement store.AuditStore")
+	}
+	ctx := audit.WithMeta(context.Background(), audit.Meta{Actor: "conformance", RequestID: "req-1"})
+
+	pet, err := s.CreatePet(ctx, uniqueName("fido"), day(2020, time.January, 1), "poodle")
+	if err != nil {
+		t.Fatalf("CreatePet failed: %v", err)
+	}
+	if _, err := s.UpdatePet(ctx, pet.ID, 1, pet.Name, pet.Birth, "bulldog"); err != nil {
+		t.Fatalf("UpdatePet failed: %v", err)
+	}
+	if _, err := s.UpdatePet(ctx, pet.ID, 1, pet.Name, pet.Birth, "poodle"); !errors.Is(err, store.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	if _, err := s.DeletePet(ctx, pet.ID, 2); err != nil {
+		t.Fatalf("DeletePet failed: %v", err)
+	}
+
+	entries, err := as.GetAuditHistory(context.Background(), audit.EntityPet, pet.ID.String())
+	if err != nil {
+		t.Fatalf("GetAuditHistory failed: %v", err)
+	}
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+		if e.Actor != "conformance" || e.RequestID != "req-1" {
+			t.Errorf("entry %d: expected actor and request ID from the context, got %q and %q", e.ID, e.Actor, e.RequestID)
+		}
+	}
+	want := []string{audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete}
+	if !slices.Equal(actions, want) {
+		t.Errorf("expected actions %v, got %v", want, actions)
+	}
+}
+
+// testMedical comprueba el orden y la normalización de fechas del historial médico, y que
+// se borra junto con la mascota.
+func testMedical(t *testing.T, s Store) {
+	ms, ok := s.(store.MedicalStore)
+	if !ok {
+		t.Skip("the store does not implement store.MedicalStore")
+	}
+	ctx := context.Background()
+
+	if _, err := ms.AddVetVisit(ctx, types.VetVisit{PetID: missingPetID, Date: day(2024, time.March, 1), Clinic: "Vet", Reason: "Checkup"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("AddVetVisit: expected ErrNotFound for a missing pet, got %v", err)
+	}
+	if _, err := ms.AddCondition(ctx, types.Condition{PetID: missingPetID, Kind: types.KindAllergy, Name: "Pollen"}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("AddCondition: expected ErrNotFound for a missing pet, got %v", err)
+	}
+	if _, err := ms.GetVetVisits(ctx, missingPetID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetVetVisits: expected ErrNotFound for a missing pet, got %v", err)
+	}
+	if _, err := ms.GetConditions(ctx, missingPetID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetConditions: expected ErrNotFound for a missing pet, got %v", err)
+	}
+
+	pet := createPet(t, s, uniqueName("medical"), day(2020, time.January, 1), "poodle")
+	visits, err := ms.GetVetVisits(ctx, pet.ID)
+	if err != nil || visits == nil || len(visits) != 0 {
+		t.Fatalf("expected no visits for a new pet, got %v (%v)", visits, err)
+	}
+	conditions, err := ms.GetConditions(ctx, pet.ID)
+	if err != nil || conditions == nil || len(conditions) != 0 {
+		t.Fatalf("expected no conditions for a new pet, got %v (%v)", conditions, err)
+	}
+
+	// La hora y la zona horaria de la fecha se descartan, igual que en Birth.
+	late := time.Date(2024, time.June, 10, 23, 30, 0, 0, time.FixedZone("UTC-3", -3*3600))
+	added, err := ms.AddVetVisit(ctx, types.VetVisit{
+		PetID: pet.ID, Date: late, Clinic: "Vet", Reason: "Limping", Diagnosis: "Sprain",
+		Treatments:  []string{"Rest", "Anti-inflammatory"},
+		Attachments: []types.Attachment{{Name: "x-ray.png", ContentType: "image/png", Size: 1024, URL: "https://files.example.com/x-ray.png"}},
+	})
+	if err != nil {
+		t.Fatalf("AddVetVisit failed: %v", err)
+	}
+	if added.ID == "" || added.CreatedAt.IsZero() || !added.Date.Equal(day(2024, time.June, 10)) {
+		t.Errorf("unexpected visit %+v", added)
+	}
+	nextDue := time.Date(2025, time.January, 5, 18, 0, 0, 0, time.FixedZone("UTC-3", -3*3600))
+	if _, err := ms.AddVetVisit(ctx, types.VetVisit{PetID: pet.ID, Date: day(2024, time.January, 5), Clinic: "Vet", Reason: "Vaccines", NextDue: &nextDue}); err != nil {
+		t.Fatalf("AddVetVisit failed: %v", err)
+	}
+
+	visits, err = ms.GetVetVisits(ctx, pet.ID)
+	if err != nil {
+		t.Fatalf("GetVetVisits failed: %v", err)
+	}
+	if len(visits) != 2 || visits[0].Reason != "Vaccines" || visits[1].Reason != "Limping" {
+		t.Fatalf("expected visits ordered by date, got %+v", visits)
+	}
+	if visits[0].NextDue == nil || !visits[0].NextDue.Equal(day(2025, time.January, 5)) || visits[1].NextDue != nil {
+		t.Errorf("expected the next due date to round-trip as a date, got %v and %v", visits[0].NextDue, visits[1].NextDue)
+	}
+	got := visits[1]
+	if got.ID != added.ID || got.PetID != pet.ID || !got.Date.Equal(day(2024, time.June, 10)) || got.Diagnosis != "Sprain" {
+		t.Errorf("visit differs: got %+v, want %+v", got, *added)
+	}
+	if !slices.Equal(got.Treatments, added.Treatments) || !slices.Equal(got.Attachments, added.Attachments) {
+		t.Errorf("expected treatments and attachments to round-trip, got %+v", got)
+	}
+	if visits[0].Treatments == nil || visits[0].Attachments == nil {
+		t.Errorf("expected empty lists instead of nil, got %+v", visits[0])
+	}
+
+	since := day(2022, time.May, 3)
+	if _, err := ms.AddCondition(ctx, types.Condition{PetID: pet.ID, Kind: types.KindAllergy, Name: "Chicken", Since: &since}); err != nil {
+		t.Fatalf("AddCondition failed: %v", err)
+	}
+	if _, err := ms.AddCondition(ctx, types.Condition{PetID: pet.ID, Kind: types.KindCondition, Name: "Arthritis", Notes: "Mild"}); err != nil {
+		t.Fatalf("AddCondition failed: %v", err)
+	}
+	conditions, err = ms.GetConditions(ctx, pet.ID)
+	if err != nil {
+		t.Fatalf("GetConditions failed: %v", err)
+	}
+	if len(conditions) != 2 || conditions[0].Name != "Chicken" || conditions[1].Name != "Arthritis" {
+		t.Fatalf("expected conditions in insertion order, got %+v", conditions)
+	}
+	if c := conditions[0]; c.Kind != types.KindAllergy || c.Since == nil || !c.Since.Equal(since) {
+		t.Errorf("unexpected allergy %+v", c)
+	}
+	if c := conditions[1]; c.Since != nil || c.Notes != "Mild" {
+		t.Errorf("unexpected condition %+v", c)
+	}
+
+	if _, err := s.DeletePet(ctx, pet.ID, store.AnyVersion); err != nil {
+		t.Fatalf("DeletePet failed: %v", err)
+	}
+	if _, err := ms.GetVetVisits(ctx, pet.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after deleting the pet, got %v", err)
+	}
+}
+
+// testMedications comprueba que las pautas y las tomas anotadas se guardan en UTC, que una
+// toma no se puede anotar dos veces y que todo se borra junto con la mascota.
+func testMedications(t *testing.T, s Store) {
+	ms, ok := s.(store.MedicationStore)
+	if !ok {
+		t.Skip("the store does not implement store.MedicationStore")
+	}
+	ctx := context.Background()
+
+	if _, err := ms.AddMedication(ctx, types.Medication{PetID: missingPetID, Drug: "Drug", Dosage: "1 pill", Frequency: "FREQ=DAILY", Start: day(2024, time.January, 1)}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("AddMedication: expected ErrNotFound for a missing pet, got %v", err)
+	}
+	if _, err := ms.GetMedications(ctx, missingPetID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetMedications: expected ErrNotFound for a missing pet, got %v", err)
+	}
+
+	pet := createPet(t, s, uniqueName("medications"), day(2020, time.January, 1), "poodle")
+	other := createPet(t, s, uniqueName("medications-other"), day(2020, time.January, 1), "poodle")
+	meds, err := ms.GetMedications(ctx, pet.ID)
+	if err != nil || meds == nil || len(meds) != 0 {
+		t.Fatalf("expected no medications for a new pet, got %v (%v)", meds, err)
+	}
+
+	zone := time.FixedZone("UTC-3", -3*3600)
+	end := time.Date(2024, time.January, 10, 20, 0, 0, 0, zone)
+	added, err := ms.AddMedication(ctx, types.Medication{
+		PetID: pet.ID, Drug: "Carprofen", Dosage: "25 mg", Frequency: "FREQ=DAILY;BYHOUR=8,20", TimeZone: "America/Argentina/Buenos_Aires",
+		Start: time.Date(2024, time.January, 1, 8, 0, 0, 500, zone), End: &end,
+	})
+	if err != nil {
+		t.Fatalf("AddMedication failed: %v", err)
+	}
+	if _, err := ms.AddMedication(ctx, types.Medication{PetID: pet.ID, Drug: "Drops", Dosage: "2 drops", Frequency: "FREQ=HOURLY;INTERVAL=8", Start: day(2024, time.January, 1)}); err != nil {
+		t.Fatalf("AddMedication failed: %v", err)
+	}
+
+	meds, err = ms.GetMedications(ctx, pet.ID)
+	if err != nil {
+		t.Fatalf("GetMedications failed: %v", err)
+	}
+	if len(meds) != 2 || meds[0].ID != added.ID || meds[1].Drug != "Drops" || meds[1].TimeZone != "UTC" {
+		t.Fatalf("expected medications in insertion order, got %+v", meds)
+	}
+	got, err := ms.GetMedication(ctx, pet.ID, added.ID)
+	if err != nil {
+		t.Fatalf("GetMedication failed: %v", err)
+	}
+	wantStart := time.Date(2024, time.January, 1, 11, 0, 0, 0, time.UTC)
+	if !got.Start.Equal(wantStart) || got.End == nil || !got.End.Equal(end) || got.Frequency != added.Frequency || got.TimeZone != added.TimeZone {
+		t.Errorf("medication differs: got %+v, want start %v and end %v", got, wantStart, end)
+	}
+	if _, err := ms.GetMedication(ctx, other.ID, added.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another pet's medication, got %v", err)
+	}
+
+	first := time.Date(2024, time.January, 1, 20, 0, 0, 0, zone)
+	second := time.Date(2024, time.January, 2, 8, 0, 0, 0, zone)
+	for _, d := range []types.DoseRecord{
+		{MedicationID: added.ID, ScheduledAt: second, Status: types.DoseSkipped, Note: "Vomited"},
+		{MedicationID: added.ID, ScheduledAt: first, Status: types.DoseGiven},
+	} {
+		if _, err := ms.RecordDose(ctx, d); err != nil {
+			t.Fatalf("RecordDose failed: %v", err)
+		}
+	}
+	if _, err := ms.RecordDose(ctx, types.DoseRecord{MedicationID: added.ID, ScheduledAt: first.UTC(), Status: types.DoseSkipped}); !errors.Is(err, store.ErrUniqueViolation) {
+		t.Errorf("expected ErrUniqueViolation for a dose recorded twice, got %v", err)
+	}
+
+	records, err := ms.GetDoseRecords(ctx, pet.ID, first, second.Add(time.Second))
+	if err != nil {
+		t.Fatalf("GetDoseRecords failed: %v", err)
+	}
+	if len(records) != 2 || !records[0].ScheduledAt.Equal(first) || records[0].Status != types.DoseGiven ||
+		records[1].Status != types.DoseSkipped || records[1].Note != "Vomited" || records[1].RecordedAt.IsZero() {
+		t.Fatalf("unexpected dose records %+v", records)
+	}
+	if records, _ := ms.GetDoseRecords(ctx, pet.ID, first.Add(time.Second), second); len(records) != 0 {
+		t.Errorf("expected the range to exclude both doses, got %+v", records)
+	}
+	if records, _ := ms.GetDoseRecords(ctx, other.ID, first, second.Add(time.Second)); len(records) != 0 {
+		t.Errorf("expected no doses for another pet, got %+v", records)
+	}
+
+	if _, err := s.DeletePet(ctx, pet.ID, store.AnyVersion); err != nil {
+		t.Fatalf("DeletePet failed: %v", err)
+	}
+	if _, err := ms.RecordDose(ctx, types.DoseRecord{MedicationID: added.ID, ScheduledAt: second.AddDate(0, 0, 1), Status: types.DoseGiven}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after deleting the pet, got %v", err)
+	}
+}
+
+func testJobs(t *testing.T, s Store) {
+	js, ok := s.(store.JobStore)
+	if !ok {
+		t.Skip("the store does not implement store.JobStore")
+	}
+	ctx := context.Background()
+
+	// Los trabajos del test vencen en el año 2000 y se reconocen por su clave, así que la
+	// base de datos puede tener otros trabajos.
+	prefix := uniqueName("job")
+	t0 := time.Date(2000, time.January, 1, 9, 0, 0, 0, time.UTC)
+	claim := func(now time.Time) []types.Job {
+		t.Helper()
+		jobs, err := js.ClaimJobs(ctx, now, time.Minute, 100)
+		if err != nil {
+			t.Fatalf("ClaimJobs failed: %v", err)
+		}
+		return slices.DeleteFunc(jobs, func(j types.Job) bool { return !strings.HasPrefix(j.Key, prefix) })
+	}
+	keys := func(jobs []types.Job) string {
+		var ks []string
+		for _, j := range jobs {
+			ks = append(ks, fmt.Sprintf("%s#%d", strings.TrimPrefix(j.Key, prefix), j.Attempts))
+		}
+		return strings.Join(ks, ",")
+	}
+
+	for _, j := range []types.Job{
+		{Kind: "test", Key: prefix + "a", RunAt: t0, MaxAttempts: 3, Payload: []byte(`{"n":1}`)},
+		{Kind: "test", Key: prefix + "b", RunAt: t0.Add(time.Minute), MaxAttempts: 3},
+	} {
+		if created, err := js.EnqueueJob(ctx, j); err != nil || !created {
+			t.Fatalf("EnqueueJob(%s): expected a new job, got %v (%v)", j.Key, created, err)
+		}
+	}
+	if created, err := js.EnqueueJob(ctx, types.Job{Kind: "test", Key: prefix + "a", RunAt: t0}); err != nil || created {
+		t.Errorf("EnqueueJob with a duplicate key: expected no new job, got %v (%v)", created, err)
+	}
+
+	jobs := claim(t0.Add(30 * time.Second))
+	if keys(jobs) != "a#1" {
+		t.Fatalf("expected to claim a, got %q", keys(jobs))
+	}
+	a := jobs[0]
+	var payload map[string]int
+	if err := json.Unmarshal(a.Payload, &payload); err != nil || payload["n"] != 1 {
+		t.Errorf("unexpected payload %s (%v)", a.Payload, err)
+	}
+	if a.Kind != "test" || a.Status != types.JobRunning || a.MaxAttempts != 3 || !a.RunAt.Equal(t0) ||
+		a.LockedUntil == nil || !a.LockedUntil.Equal(t0.Add(90*time.Second)) {
+		t.Errorf("unexpected claimed job %+v", a)
+	}
+	if jobs := claim(t0.Add(45 * time.Second)); len(jobs) != 0 {
+		t.Errorf("expected a locked job not to be claimed again, got %q", keys(jobs))
+	}
+
+	// Sin cerrar el intento, la reserva caduca y el trabajo se vuelve a entregar.
+	jobs = claim(t0.Add(2 * time.Minute))
+	if keys(jobs) != "a#2,b#1" {
+		t.Fatalf("expected to claim a again and b, got %q", keys(jobs))
+	}
+	b := jobs[1]
+
+	if err := js.RetryJob(ctx, a.ID, t0.Add(10*time.Minute), "boom"); err != nil {
+		t.Fatalf("RetryJob failed: %v", err)
+	}
+	if err := js.CompleteJob(ctx, b.ID); err != nil {
+		t.Fatalf("CompleteJob failed: %v", err)
+	}
+	if jobs := claim(t0.Add(5 * time.Minute)); len(jobs) != 0 {
+		t.Errorf("expected nothing due before the retry, got %q", keys(jobs))
+	}
+	jobs = claim(t0.Add(10 * time.Minute))
+	if keys(jobs) != "a#3" || jobs[0].LastError != "boom" {
+		t.Fatalf("expected to claim the retry of a, got %q %+v", keys(jobs), jobs)
+	}
+
+	if err := js.FailJob(ctx, a.ID, "gave up"); err != nil {
+		t.Fatalf("FailJob failed: %v", err)
+	}
+	if jobs := claim(t0.Add(time.Hour)); len(jobs) != 0 {
+		t.Errorf("expected done and failed jobs not to be claimed, got %q", keys(jobs))
+	}
+
+	if err := js.CompleteJob(ctx, -1); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("CompleteJob: expected ErrNotFound, got %v", err)
+	}
+	if err := js.RetryJob(ctx, -1, t0, "x"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RetryJob: expected ErrNotFound, got %v", err)
+	}
+	if err := js.FailJob(ctx, -1, "x"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("FailJob: expected ErrNotFound, got %v", err)
+	}
+}
+
+func testWebhooks(t *testing.T, s Store) {
+	ws, ok := s.(store.WebhookStore)
+	if !ok {
+		t.Skip("the store does not implement store.WebhookStore")
+	}
+	ctx := context.Background()
+
+	url := "https://example.com/" + uniqueName("hook")
+	w, err := ws.CreateWebhook(ctx, types.Webhook{URL: url, Events: []string{"pet.created", "pet.*"}, Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	t.Cleanup(func() { ws.DeleteWebhook(context.Background(), w.ID) })
+	if w.ID == "" || w.CreatedAt.IsZero() {
+		t.Errorf("expected ID and CreatedAt to be set, got %+v", w)
+	}
+
+	got, err := ws.GetWebhook(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("GetWebhook failed: %v", err)
+	}
+	if got.URL != url || got.Secret != "s3cret" || !slices.Equal(got.Events, []string{"pet.created", "pet.*"}) || !got.CreatedAt.Equal(w.CreatedAt) {
+		t.Errorf("expected %+v, got %+v", w, got)
+	}
+	all, err := ws.GetWebhooks(ctx)
+	if err != nil {
+		t.Fatalf("GetWebhooks failed: %v", err)
+	}
+	if !slices.ContainsFunc(all, func(h types.Webhook) bool { return h.ID == w.ID }) {
+		t.Errorf("expected GetWebhooks to include %s", w.ID)
+	}
+
+	var ids []int64
+	for _, typ := range []string{"pet.created", "pet.deleted"} {
+		d, err := ws.AddDelivery(ctx, types.WebhookDelivery{WebhookID: w.ID, EventID: uniqueName("event"), EventType: typ, Payload: []byte(`{"type":"` + typ + `"}`)})
+		if err != nil {
+			t.Fatalf("AddDelivery failed: %v", err)
+		}
+		if d.Status != types.DeliveryPending || d.Attempts != 0 {
+			t.Errorf("expected a pending delivery, got %+v", d)
+		}
+		ids = append(ids, d.ID)
+	}
+	if err := ws.RecordDeliveryAttempt(ctx, ids[0], types.DeliveryPending, 500, "server error"); err != nil {
+		t.Fatalf("RecordDeliveryAttempt failed: %v", err)
+	}
+	if err := ws.RecordDeliveryAttempt(ctx, ids[0], types.DeliverySucceeded, 204, ""); err != nil {
+		t.Fatalf("RecordDeliveryAttempt failed: %v", err)
+	}
+
+	deliveries, err := ws.GetDeliveries(ctx, w.ID, 10)
+	if err != nil {
+		t.Fatalf("GetDeliveries failed: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != ids[1] || deliveries[1].ID != ids[0] {
+		t.Fatalf("expected deliveries %v newest first, got %+v", ids, deliveries)
+	}
+	if d := deliveries[1]; d.Status != types.DeliverySucceeded || d.Attempts != 2 || d.ResponseStatus != 204 || d.LastError != "" || d.EventType != "pet.created" {
+		t.Errorf("unexpected delivery after two attempts %+v", d)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(deliveries[0].Payload, &payload); err != nil || payload["type"] != "pet.deleted" {
+		t.Errorf("unexpected payload %s (%v)", deliveries[0].Payload, err)
+	}
+	if deliveries, err := ws.GetDeliveries(ctx, w.ID, 1); err != nil || len(deliveries) != 1 || deliveries[0].ID != ids[1] {
+		t.Errorf("expected only the newest delivery with limit 1, got %+v (%v)", deliveries, err)
+	}
+
+	if err := ws.DeleteWebhook(ctx, w.ID); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	if _, err := ws.GetWebhook(ctx, w.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetWebhook after delete: expected ErrNotFound, got %v", err)
+	}
+	if _, err := ws.GetDeliveries(ctx, w.ID, 10); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetDeliveries after delete: expected ErrNotFound, got %v", err)
+	}
+	if _, err := ws.AddDelivery(ctx, types.WebhookDelivery{WebhookID: w.ID, EventID: "e", EventType: "pet.created", Payload: []byte("{}")}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("AddDelivery after delete: expected ErrNotFound, got %v", err)
+	}
+	if err := ws.DeleteWebhook(ctx, w.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeleteWebhook twice: expected ErrNotFound, got %v", err)
+	}
+	if err := ws.RecordDeliveryAttempt(ctx, ids[0], types.DeliveryDead, 0, "x"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RecordDeliveryAttempt after delete: expected ErrNotFound, got %v", err)
+	}
+}
+
+// testEventOutbox comprueba que cada cambio auditado guarda su evento como un trabajo
+// store.KindEvent en la misma transacción que el cambio, y solo si hay algún webhook.
+func testEventOutbox(t *testing.T, s Store) {
+	js, isJobs := s.(store.JobStore)
+	ws, isWebhooks := s.(store.WebhookStore)
+	tr, isTx := s.(store.Transactor)
+	if !isJobs || !isWebhooks || !isTx {
+		t.Skip("the store does not implement store.JobStore, store.WebhookStore and store.Transactor")
+	}
+	ctx := audit.WithMeta(context.Background(), audit.Meta{Actor: "conformance", RequestID: "req-1"})
+
+	// eventsOf reclama los trabajos del outbox y devuelve, en orden, los eventos de las
+	// entidades ids. La base de datos puede tener otros trabajos.
+	eventsOf := func(ids ...string) []events.Event {
+		t.Helper()
+		jobs, err := js.ClaimJobs(ctx, time.Now().Add(time.Hour), time.Minute, 1000)
+		if err != nil {
+			t.Fatalf("ClaimJobs failed: %v", err)
+		}
+		var out []events.Event
+		for _, j := range jobs {
+			if j.Kind != store.KindEvent {
+				continue
+			}
+			var e events.Event
+			if err := json.Unmarshal(j.Payload, &e); err != nil {
+				t.Fatalf("invalid event %s: %v", j.Payload, err)
+			}
+			if slices.Contains(ids, e.EntityID) {
+				out = append(out, e)
+				js.CompleteJob(ctx, j.ID)
+			}
+		}
+		return out
+	}
+
+	if hooks, err := ws.GetWebhooks(ctx); err != nil {
+		t.Fatalf("GetWebhooks failed: %v", err)
+	} else if len(hooks) == 0 {
+		pet, err := s.CreatePet(ctx, uniqueName("quiet"), day(2020, time.January, 1), "poodle")
+		if err != nil {
+			t.Fatalf("CreatePet failed: %v", err)
+		}
+		t.Cleanup(func() { s.DeletePet(context.Background(), pet.ID, store.AnyVersion) })
+		if got := eventsOf(pet.ID.String()); len(got) != 0 {
+			t.Errorf("expected no events without webhooks, got %+v", got)
+		}
+	}
+
+	w, err := ws.CreateWebhook(ctx, types.Webhook{URL: "https://example.com/" + uniqueName("outbox"), Events: []string{"*"}, Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	t.Cleanup(func() { ws.DeleteWebhook(context.Background(), w.ID) })
+
+	name := uniqueName("outbox")
+	pet, err := s.CreatePet(ctx, name, day(2020, time.January, 1), "poodle")
+	if err != nil {
+		t.Fatalf("CreatePet failed: %v", err)
+	}
+	if _, err := s.UpdatePet(ctx, pet.ID, 1, name, pet.Birth, "bulldog"); err != nil {
+		t.Fatalf("UpdatePet failed: %v", err)
+	}
+	if _, err := s.DeletePet(ctx, pet.ID, 2); err != nil {
+		t.Fatalf("DeletePet failed: %v", err)
+	}
+	var rolledBack types.PetID
+	boom := errors.New("boom")
+	err = tr.WithinTx(ctx, func(tx store.Tx) error {
+		p, err := tx.CreatePet(ctx, name, day(2020, time.January, 1), "poodle")
+		if err != nil {
+			return err
+		}
+		rolledBack = p.ID
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+
+	got := eventsOf(pet.ID.String(), rolledBack.String())
+	var typs []string
+	for _, e := range got {
+		typs = append(typs, e.Type)
+		if e.EntityID != pet.ID.String() || e.Actor != "conformance" || e.RequestID != "req-1" || e.ID == "" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	}
+	if want := []string{events.PetCreated, events.PetUpdated, events.PetDeleted}; !slices.Equal(typs, want) {
+		t.Fatalf("expected events %v, got %v", want, typs)
+	}
+	var data types.Pet
+	if err := json.Unmarshal(got[1].Data, &data); err != nil || data.Name != name || data.Breed.ID != "bulldog" {
+		t.Errorf("unexpected data of %s: %s (%v)", got[1].Type, got[1].Data, err)
+	}
+	if got[2].Data != nil {
+		t.Errorf("expected no data on %s, got %s", got[2].Type, got[2].Data)
+	}
+
+	bw, ok := s.(store.BreedWriter)
+	if !ok {
+		return
+	}
+	breed := types.Breed{ID: types.BreedID(uniqueName("outbox")), Name: "Outbox", Size: types.SizeSmall}
+	for _, size := range []types.BreedSize{types.SizeSmall, types.SizeSmall, types.SizeLarge} {
+		breed.Size = size
+		if _, err := bw.UpsertBreed(ctx, breed); err != nil {
+			t.Fatalf("UpsertBreed failed: %v", err)
+		}
+	}
+	typs = nil
+	got = eventsOf(breed.ID.String())
+	for _, e := range got {
+		typs = append(typs, e.Type)
+	}
+	// La segunda carga no cambia nada, así que no publica.
+	if want := []string{events.BreedCreated, events.BreedUpdated}; !slices.Equal(typs, want) {
+		t.Fatalf("expected events %v, got %v", want, typs)
+	}
+	var b types.Breed
+	if err := json.Unmarshal(got[1].Data, &b); err != nil || b != breed {
+		t.Errorf("unexpected data of %s: %s (%v)", got[1].Type, got[1].Data, err)
+	}
+}
+
+func testSync(t *testing.T, s Store) {
+	ss, ok := s.(store.SyncStore)
+	if !ok {
+		t.Skip("the store does not implement store.SyncStore")
+	}
+	ctx := context.Background()
+
+	a := createPet(t, s, uniqueName("sync-a"), day(2020, time.January, 1), "poodle")
+	pets, token, err := ss.PetSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("PetSnapshot failed: %v", err)
+	}
+	if !slices.ContainsFunc(pets, func(p types.Pet) bool { return p.ID == a.ID }) {
+		t.Errorf("expected the snapshot to include %s", a.ID)
+	}
+
+	if _, err := s.UpdatePet(ctx, a.ID, a.Version, a.Name+"-renamed", a.Birth, "bulldog"); err != nil {
+		t.Fatalf("UpdatePet failed: %v", err)
+	}
+	if _, err := s.DeletePet(ctx, a.ID, store.AnyVersion); err != nil {
+		t.Fatalf("DeletePet failed: %v", err)
+	}
+	b := createPet(t, s, uniqueName("sync-b"), day(2021, time.February, 2), "poodle")
+
+	// La base de datos puede ser compartida: solo se miran los cambios de a y b.
+	changes, err := ss.PetChanges(ctx, token, 1000)
+	if err != nil {
+		t.Fatalf("PetChanges failed: %v", err)
+	}
+	var got []string
+	last := token
+	for _, c := range changes {
+		if c.Seq <= last {
+			t.Errorf("expected changes in increasing order after %d, got %d", last, c.Seq)
+		}
+		last = c.Seq
+		switch {
+		case c.PetID == a.ID && c.Deleted && c.Pet == nil:
+			got = append(got, "delete a")
+		case c.PetID == a.ID && !c.Deleted && c.Pet != nil:
+			got = append(got, "upsert a "+string(c.Pet.Breed.ID)+" v"+fmt.Sprint(c.Pet.Version))
+		case c.PetID == b.ID && !c.Deleted && c.Pet != nil && c.Pet.Name == b.Name:
+			got = append(got, "upsert b")
+		case c.PetID == a.ID || c.PetID == b.ID:
+			t.Errorf("unexpected change %+v", c)
+		}
+	}
+	if want := "upsert a bulldog v2, delete a, upsert b"; strings.Join(got, ", ") != want {
+		t.Errorf("expected %q, got %q", want, strings.Join(got, ", "))
+	}
+
+	// Con limit se puede paginar desde el último Seq recibido.
+	page, err := ss.PetChanges(ctx, token, 1)
+	if err != nil || len(page) != 1 {
+		t.Fatalf("expected one change with limit 1, got %d (%v)", len(page), err)
+	}
+	rest, err := ss.PetChanges(ctx, page[0].Seq, 1000)
+	if err != nil {
+		t.Fatalf("PetChanges failed: %v", err)
+	}
+	if len(rest) != len(changes)-1 {
+		t.Errorf("expected %d changes after the first page, got %d", len(changes)-1, len(rest))
+	}
+}
+
+// testSyncConcurrent comprueba que un cliente que parte de cualquier instantánea reciba
+// después todas las mascotas creadas, aunque se creen a la vez que se escriben razas (que
+// también se auditan) y que se toman las instantáneas.
+func testSyncConcurrent(t *testing.T, s Store) {
+	ss, ok := s.(store.SyncStore)
+	if !ok {
+		t.Skip("the store does not implement store.SyncStore")
+	}
+	ctx := context.Background()
+	prefix := uniqueName("sync-concurrent")
+	bw, _ := s.(store.BreedWriter)
+	breed := types.Breed{ID: types.BreedID(prefix), Name: "Concurrent", Size: types.SizeSmall}
+	t.Cleanup(func() {
+		for _, p := range petsNamed(t, s, prefix) {
+			s.DeletePet(ctx, p.ID, store.AnyVersion)
+		}
+	})
+
+	const writers, petsPerWriter = 4, 10
+	var (
+		mu      sync.Mutex
+		created = map[types.PetID]bool{}
+		wg      sync.WaitGroup
+		errs    = make(chan error, writers+1)
+	)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range petsPerWriter {
+				pet, err := s.CreatePet(ctx, fmt.Sprintf("%s-%d-%d", prefix, w, i), day(2020, time.January, 1), "poodle")
+				if err != nil {
+					errs <- err
+					return
+				}
+				mu.Lock()
+				created[pet.ID] = true
+				mu.Unlock()
+				if bw != nil {
+					b := breed
+					b.Temperament = fmt.Sprintf("%d-%d", w, i)
+					if _, err := bw.UpsertBreed(ctx, b); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	type snapshot struct {
+		pets  map[types.PetID]bool
+		token int64
+	}
+	// Se guarda una instantánea por token: las demás con el mismo token no añaden nada.
+	var snapshots []snapshot
+	done, stop := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			// Después de que terminen los escritores se toma una instantánea más.
+			stopped := false
+			select {
+			case <-stop:
+				stopped = true
+			default:
+			}
+			pets, token, err := ss.PetSnapshot(ctx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			snap := snapshot{pets: map[types.PetID]bool{}, token: token}
+			for _, p := range pets {
+				if strings.HasPrefix(p.Name, prefix) {
+					snap.pets[p.ID] = true
+				}
+			}
+			if n := len(snapshots); n == 0 || snapshots[n-1].token != token {
+				snapshots = append(snapshots, snap)
+			}
+			if stopped {
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	<-done
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent write failed: %v", err)
+	}
+
+	for _, snap := range snapshots {
+		seen := snap.pets
+		for since := snap.token; ; {
+			changes, err := ss.PetChanges(ctx, since, 500)
+			if err != nil {
+				t.Fatalf("PetChanges failed: %v", err)
+			}
+			for _, c := range changes {
+				seen[c.PetID] = true
+				since = c.Seq
+			}
+			if len(changes) < 500 {
+				break
+			}
+		}
+		for id := range created {
+			if !seen[id] {
+				t.Fatalf("pet %s is neither in the snapshot at token %d nor in the changes after it", id, snap.token)
+			}
+		}
+	}
+}
+
+func testTransactions(t *testing.T, s Store) {
+	tr, ok := s.(store.Transactor)
+	if !ok {
+		t.Skip("the store does not implement store.Transactor")
+	}
+	name := uniqueName("tx")
+	boom := errors.New("boom")
+
+	err := tr.WithinTx(context.Background(), func(tx store.Tx) error {
+		if _, err := tx.CreatePet(context.Background(), name, day(2020, time.January, 1), "poodle"); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	if pets := petsNamed(t, s, name); len(pets) != 0 {
+		t.Errorf("expected rollback, got %d pets", len(pets))
+	}
+
+	var created *types.Pet
+	err = tr.WithinTx(context.Background(), func(tx store.Tx) error {
+		var err error
+		created, err = tx.CreatePet(context.Background(), name, day(2020, time.January, 1), "poodle")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithinTx failed: %v", err)
+	}
+	t.Cleanup(func() { s.DeletePet(context.Background(), created.ID, store.AnyVersion) })
+	if pets := petsNamed(t, s, name); len(pets) != 1 {
+		t.Errorf("expected the committed pet, got %d pets", len(pets))
+	}
+}
@@ -0,0 +1,118 @@
+// Package transfer exporta mascotas y razas a CSV y NDJSON, importa mascotas desde CSV
+// (p. ej. una hoja de cálculo) asociando sus columnas a los campos de la mascota, y lee
+// razas en el mismo formato en que se exportan.
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Formatos de exportación.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ErrUnknownFormat indica un formato distinto de FormatCSV y FormatNDJSON.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Columnas de los CSV exportados, en orden.
+var (
+	PetColumns   = []string{"id", "name", "birth", "breed_id", "breed_name", "breed_size", "version", "updated_at"}
+	BreedColumns = []string{"id", "name", "temperament", "origin", "size"}
+)
+
+// ContentType devuelve el tipo MIME del formato.
+func ContentType(format string) (string, error) {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8", nil
+	case FormatNDJSON:
+		return "application/x-ndjson", nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// WritePets escribe las mascotas en w, una por fila o por línea, a medida que las codifica.
+// En NDJSON cada línea es la mascota tal como la devuelve la API, con su raza.
+func WritePets(w io.Writer, format string, pets []types.Pet) error {
+	return write(w, format, PetColumns, pets, func(p types.Pet) []string {
+		return []string{
+			p.ID.String(),
+			p.Name,
+			p.Birth.Format(time.DateOnly),
+			p.Breed.ID.String(),
+			p.Breed.Name,
+			string(p.Breed.Size),
+			strconv.FormatInt(p.Version, 10),
+			p.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+	})
+}
+
+// WriteBreeds escribe las razas en w, como WritePets.
+func WriteBreeds(w io.Writer, format string, breeds []types.Breed) error {
+	return write(w, format, BreedColumns, breeds, func(b types.Breed) []string {
+		return []string{b.ID.String(), b.Name, b.Temperament, b.Origin, string(b.Size)}
+	})
+}
+
+func write[T any](w io.Writer, format string, columns []string, items []T, row func(T) []string) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+		for _, item := range items {
+			record := row(item)
+			for i, v := range record {
+				record[i] = escapeFormula(v)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatNDJSON:
+		// json.Encoder termina cada valor con un salto de línea.
+		enc := json.NewEncoder(w)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// escapeFormula antepone un apóstrofo a los valores que una hoja de cálculo ejecutaría
+// como fórmula (inyección CSV). Ningún valor generado por la API empieza así; solo los
+// textos que escriben los usuarios, como el nombre de una mascota.
+func escapeFormula(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// unescapeFormula deshace escapeFormula, para que un CSV exportado se pueda volver a importar.
+func unescapeFormula(v string) string {
+	if len(v) > 1 && v[0] == '\'' && escapeFormula(v[1:]) != v[1:] {
+		return v[1:]
+	}
+	return v
+}
@@ -0,0 +1,375 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// Campos de la mascota que se importan.
+const (
+	FieldName  = "name"
+	FieldBirth = "birth"
+	FieldBreed = "breed"
+)
+
+// ErrInvalidFile indica que el CSV no se puede importar entero: está mal formado, le falta
+// una columna obligatoria o tiene demasiadas filas.
+var ErrInvalidFile = errors.New("invalid import file")
+
+// fieldAliases son los encabezados que se asocian solos a cada campo, ya normalizados (ver
+// normalizeHeader). Si varias columnas encajan, gana la del alias que aparece antes: así un
+// CSV exportado usa breed_id y no breed_name.
+var fieldAliases = map[string][]string{
+	FieldName:  {"name", "petname", "dogname", "nombre"},
+	FieldBirth: {"birth", "birthdate", "dateofbirth", "dob", "born", "nacimiento", "fechadenacimiento"},
+	FieldBreed: {"breedid", "breed", "breedname", "raza"},
+}
+
+// Mapping asocia encabezados del CSV a campos (FieldName, FieldBirth, FieldBreed). Tiene
+// prioridad sobre los alias; las columnas que no se asocian a ningún campo se ignoran.
+type Mapping map[string]string
+
+// Options configura ReadPets.
+type Options struct {
+	Mapping Mapping
+	// Comma es el separador de columnas; por defecto ','. Las hojas de cálculo en
+	// configuraciones regionales que usan la coma decimal suelen exportar con ';'.
+	Comma rune
+	// MaxRows limita las filas de datos; 0 es sin límite.
+	MaxRows int
+}
+
+// PetRow es una fila válida, lista para crear la mascota. Line es su línea en el CSV.
+type PetRow struct {
+	Line    int
+	Name    string
+	Birth   time.Time
+	BreedID types.BreedID
+}
+
+// RowError es un error de una fila. Column es el encabezado de la columna con el valor
+// inválido, o vacío si el error es de la fila entera.
+type RowError struct {
+	Line   int    `json:"line"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Error  string `json:"error"`
+}
+
+// Result es el resultado de leer un CSV: las filas válidas, los errores de las demás y las
+// columnas que se ignoraron. Total es el número de filas de datos leídas.
+type Result struct {
+	Total          int
+	Rows           []PetRow
+	Errors         []RowError
+	IgnoredColumns []string
+}
+
+// Report es el informe de una importación: cuántas filas se leyeron, qué mascota se creó
+// con cada fila importada y los errores de las que no se importaron.
+type Report struct {
+	Rows           int          `json:"rows"`
+	Imported       int          `json:"imported"`
+	DryRun         bool         `json:"dryRun,omitempty"`
+	Created        []CreatedPet `json:"created"`
+	Errors         []RowError   `json:"errors"`
+	IgnoredColumns []string     `json:"ignoredColumns"`
+}
+
+// CreatedPet es la mascota creada con la fila Line.
+type CreatedPet struct {
+	Line int         `json:"line"`
+	ID   types.PetID `json:"id"`
+}
+
+// ReadPets lee mascotas de un CSV con una fila de encabezados. Las razas se buscan en
+// breeds por ID o por nombre, sin distinguir mayúsculas. Devuelve un error envuelto en
+// ErrInvalidFile si el archivo no es válido, o el error de r si no se pudo leer; los
+// errores de cada fila van en Result.Errors.
+func ReadPets(r io.Reader, breeds []types.Breed, opts Options) (*Result, error) {
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	// El número de columnas se comprueba en cada fila, para informar del error en ella.
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, readError(err)
+	}
+	// Excel antepone la marca de orden de bytes al guardar en UTF-8.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	columns, ignored, err := mapColumns(header, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]types.BreedID, len(breeds))
+	byName := make(map[string]types.BreedID, len(breeds))
+	for _, b := range breeds {
+		byID[b.ID.String()] = b.ID
+		byName[strings.ToLower(b.Name)] = b.ID
+	}
+
+	res := &Result{Rows: []PetRow{}, Errors: []RowError{}, IgnoredColumns: ignored}
+	for ; ; res.Total++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, readError(err)
+		}
+		if opts.MaxRows > 0 && res.Total == opts.MaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidFile, opts.MaxRows)
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(header) {
+			res.Errors = append(res.Errors, RowError{Line: line, Error: fmt.Sprintf("expected %d columns, got %d", len(header), len(record))})
+			continue
+		}
+
+		row, errs := parseRow(line, header, record, columns, byID, byName)
+		if len(errs) > 0 {
+			res.Errors = append(res.Errors, errs...)
+			continue
+		}
+		res.Rows = append(res.Rows, row)
+	}
+}
+
+// readError envuelve en ErrInvalidFile los errores de formato del CSV. Los de lectura se
+// devuelven tal cual.
+func readError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return err
+}
+
+// mapColumns devuelve la posición de la columna de cada campo y los encabezados ignorados.
+func mapColumns(header []string, mapping Mapping) (map[string]int, []string, error) {
+	columns := map[string]int{}
+	for h, field := range mapping {
+		if _, ok := fieldAliases[field]; !ok {
+			return nil, nil, fmt.Errorf("%w: unknown field %q in the mapping", ErrInvalidFile, field)
+		}
+		i := slices.IndexFunc(header, func(v string) bool { return strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(h)) })
+		if i < 0 {
+			return nil, nil, fmt.Errorf("%w: column %q is not in the header", ErrInvalidFile, h)
+		}
+		if _, dup := columns[field]; dup {
+			return nil, nil, fmt.Errorf("%w: several columns are mapped to %q", ErrInvalidFile, field)
+		}
+		columns[field] = i
+	}
+	for field, aliases := range fieldAliases {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+	aliases:
+		for _, alias := range aliases {
+			for i, h := range header {
+				// Una columna asociada a otro campo en mapping no se reutiliza.
+				if normalizeHeader(h) == alias && !isMapped(columns, i) {
+					columns[field] = i
+					break aliases
+				}
+			}
+		}
+		if _, ok := columns[field]; !ok {
+			return nil, nil, fmt.Errorf("%w: no column for %q", ErrInvalidFile, field)
+		}
+	}
+
+	ignored := []string{}
+	for i, h := range header {
+		if !isMapped(columns, i) {
+			ignored = append(ignored, h)
+		}
+	}
+	return columns, ignored, nil
+}
+
+func parseRow(line int, header, record []string, columns map[string]int, byID, byName map[string]types.BreedID) (PetRow, []RowError) {
+	row := PetRow{Line: line}
+	var errs []RowError
+	fail := func(field, msg string) {
+		i := columns[field]
+		errs = append(errs, RowError{Line: line, Column: header[i], Value: record[i], Error: msg})
+	}
+
+	row.Name = unescapeFormula(strings.TrimSpace(record[columns[FieldName]]))
+	if row.Name == "" {
+		fail(FieldName, "name is required")
+	}
+
+	birth, err := time.Parse(time.DateOnly, strings.TrimSpace(record[columns[FieldBirth]]))
+	if err != nil {
+		fail(FieldBirth, "bad date of birth format, use YYYY-MM-DD")
+	}
+	row.Birth = birth
+
+	breed := strings.TrimSpace(record[columns[FieldBreed]])
+	if id, ok := byID[breed]; ok {
+		row.BreedID = id
+	} else if id, ok := byName[strings.ToLower(breed)]; ok {
+		row.BreedID = id
+	} else {
+		fail(FieldBreed, "breed not found")
+	}
+	return row, errs
+}
+
+// normalizeHeader pasa el encabezado a minúsculas y quita espacios, guiones y guiones
+// bajos, para que "Date of birth", "date_of_birth" y "DateOfBirth" encajen con el mismo alias.
+func normalizeHeader(h string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '_', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(h)))
+}
+
+func isMapped(columns map[string]int, i int) bool {
+	for _, c := range columns {
+		if c == i {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadBreeds lee razas de un CSV con los encabezados de BreedColumns (temperament y origin
+// son opcionales) o de NDJSON, que también puede ser un único array JSON. Es el formato de
+// WriteBreeds, para poder cargar en una base de datos las razas exportadas de otra. Si
+// alguna raza no es válida o está repetida, devuelve un error envuelto en ErrInvalidFile.
+func ReadBreeds(r io.Reader, format string) ([]types.Breed, error) {
+	var breeds []types.Breed
+	var err error
+	switch format {
+	case FormatCSV:
+		breeds, err = readBreedsCSV(r)
+	case FormatNDJSON:
+		breeds, err = readBreedsJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[types.BreedID]bool, len(breeds))
+	for i, b := range breeds {
+		if err := b.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: breed %d: %v", ErrInvalidFile, i+1, err)
+		}
+		if seen[b.ID] {
+			return nil, fmt.Errorf("%w: breed %q is repeated", ErrInvalidFile, b.ID)
+		}
+		seen[b.ID] = true
+	}
+	return breeds, nil
+}
+
+func readBreedsCSV(r io.Reader) ([]types.Breed, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, readError(err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	columns := map[string]int{}
+	for i, h := range header {
+		columns[normalizeHeader(h)] = i
+	}
+	for _, required := range []string{"id", "name", "size"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: no column for %q", ErrInvalidFile, required)
+		}
+	}
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok {
+			return ""
+		}
+		return unescapeFormula(strings.TrimSpace(record[i]))
+	}
+
+	breeds := []types.Breed{}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return breeds, nil
+		}
+		if err != nil {
+			return nil, readError(err)
+		}
+		breeds = append(breeds, types.Breed{
+			ID:          types.BreedID(value(record, "id")),
+			Name:        value(record, "name"),
+			Temperament: value(record, "temperament"),
+			Origin:      value(record, "origin"),
+			Size:        types.BreedSize(value(record, "size")),
+		})
+	}
+}
+
+func readBreedsJSON(r io.Reader) ([]types.Breed, error) {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	dec.DisallowUnknownFields()
+	breeds := []types.Breed{}
+	if first, err := firstByte(br); err == nil && first == '[' {
+		if err := dec.Decode(&breeds); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		return breeds, nil
+	}
+	for {
+		var b types.Breed
+		err := dec.Decode(&b)
+		if errors.Is(err, io.EOF) {
+			return breeds, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: breed %d: %v", ErrInvalidFile, len(breeds)+1, err)
+		}
+		breeds = append(breeds, b)
+	}
+}
+
+// firstByte devuelve el primer byte que no es un espacio, sin consumirlo.
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(b)) {
+			return b, br.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+var (
+	testBreeds = []types.Breed{
+		{ID: "golden-retriever", Name: "Golden Retriever", Size: types.SizeLarge},
+		{ID: "poodle", Name: "Poodle", Size: types.SizeMedium},
+	}
+	testPets = []types.Pet{
+		{ID: "0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41", Name: "Fido", Birth: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Breed: testBreeds[0], Version: 3, UpdatedAt: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)},
+		{ID: "7d3f1e2a-8b4c-4d5e-9f6a-0b1c2d3e4f5a", Name: "=HYPERLINK(\"x\")", Birth: time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), Breed: testBreeds[1], Version: 1},
+	}
+)
+
+func TestWritePets(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WritePets(&buf, FormatCSV, testPets); err != nil {
+			t.Fatal(err)
+		}
+		want := "id,name,birth,breed_id,breed_name,breed_size,version,updated_at\n" +
+			"0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41,Fido,2020-01-02,golden-retriever,Golden Retriever,large,3,2024-05-06T07:08:09Z\n" +
+			"7d3f1e2a-8b4c-4d5e-9f6a-0b1c2d3e4f5a,\"'=HYPERLINK(\"\"x\"\")\",2021-03-04,poodle,Poodle,medium,1,0001-01-01T00:00:00Z\n"
+		if buf.String() != want {
+			t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WritePets(&buf, FormatNDJSON, testPets); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines, got %d", len(lines))
+		}
+		var got types.Pet
+		if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != testPets[1].Name || got.Breed.ID != "poodle" {
+			t.Errorf("unexpected pet: %+v", got)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if err := WriteBreeds(&bytes.Buffer{}, "xml", testBreeds); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("expected ErrUnknownFormat, got %v", err)
+		}
+	})
+}
+
+func TestReadPets(t *testing.T) {
+	t.Run("maps headers by alias and reports row errors", func(t *testing.T) {
+		csv := "\ufeffDog Name,Date of Birth,Breed,Notes\n" +
+			"Fido,2020-01-02,Golden Retriever,good boy\n" +
+			"Rex,02/03/2021,poodle,\n" +
+			",2021-01-01,unknown,\n" +
+			"Short,2020-01-01\n" +
+			"Luna,2022-05-06,POODLE,\n"
+		res, err := ReadPets(strings.NewReader(csv), testBreeds, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Rows) != 2 || res.Rows[0].Name != "Fido" || res.Rows[0].BreedID != "golden-retriever" || res.Rows[1].Line != 6 || res.Rows[1].BreedID != "poodle" {
+			t.Errorf("unexpected rows: %+v", res.Rows)
+		}
+		if !res.Rows[0].Birth.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected birth: %v", res.Rows[0].Birth)
+		}
+		want := []RowError{
+			{Line: 3, Column: "Date of Birth", Value: "02/03/2021", Error: "bad date of birth format, use YYYY-MM-DD"},
+			{Line: 4, Column: "Dog Name", Error: "name is required"},
+			{Line: 4, Column: "Breed", Value: "unknown", Error: "breed not found"},
+			{Line: 5, Error: "expected 4 columns, got 2"},
+		}
+		if len(res.Errors) != len(want) {
+			t.Fatalf("expected %d errors, got %+v", len(want), res.Errors)
+		}
+		for i := range want {
+			if res.Errors[i] != want[i] {
+				t.Errorf("error %d: expected %+v, got %+v", i, want[i], res.Errors[i])
+			}
+		}
+		if len(res.IgnoredColumns) != 1 || res.IgnoredColumns[0] != "Notes" {
+			t.Errorf("expected Notes to be ignored, got %v", res.IgnoredColumns)
+		}
+	})
+
+	t.Run("reads its own export", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WritePets(&buf, FormatCSV, testPets); err != nil {
+			t.Fatal(err)
+		}
+		res, err := ReadPets(&buf, testBreeds, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Errors) != 0 || len(res.Rows) != 2 {
+			t.Fatalf("expected 2 rows and no errors, got %+v", res)
+		}
+		if res.Rows[1].Name != testPets[1].Name || res.Rows[1].BreedID != "poodle" {
+			t.Errorf("unexpected row: %+v", res.Rows[1])
+		}
+	})
+
+	t.Run("explicit mapping and separator", func(t *testing.T) {
+		csv := "Perro;Nacido el;Tipo\nFido;2020-01-02;poodle\n"
+		res, err := ReadPets(strings.NewReader(csv), testBreeds, Options{
+			Mapping: Mapping{"perro": FieldName, "Nacido el": FieldBirth, "Tipo": FieldBreed},
+			Comma:   ';',
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Rows) != 1 || res.Rows[0].Name != "Fido" {
+			t.Errorf("unexpected result: %+v", res)
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			csv  string
+			opts Options
+		}{
+			"empty":           {csv: ""},
+			"missing column":  {csv: "name,birth\nFido,2020-01-01\n"},
+			"unknown field":   {csv: "name,birth,breed\n", opts: Options{Mapping: Mapping{"name": "age"}}},
+			"unmapped header": {csv: "name,birth,breed\n", opts: Options{Mapping: Mapping{"dob": FieldBirth}}},
+			"too many rows":   {csv: "name,birth,breed\na,2020-01-01,poodle\nb,2020-01-01,poodle\n", opts: Options{MaxRows: 1}},
+			"bad quotes":      {csv: "name,birth,breed\n\"a,2020-01-01,poodle\n"},
+		} {
+			if _, err := ReadPets(strings.NewReader(tc.csv), testBreeds, tc.opts); !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("%s: expected ErrInvalidFile, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestReadBreeds(t *testing.T) {
+	t.Run("reads its own export", func(t *testing.T) {
+		for _, format := range []string{FormatCSV, FormatNDJSON} {
+			var buf bytes.Buffer
+			if err := WriteBreeds(&buf, format, testBreeds); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadBreeds(&buf, format)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if len(got) != len(testBreeds) || got[0] != testBreeds[0] || got[1] != testBreeds[1] {
+				t.Errorf("%s: expected %+v, got %+v", format, testBreeds, got)
+			}
+		}
+	})
+
+	t.Run("json array and optional columns", func(t *testing.T) {
+		got, err := ReadBreeds(strings.NewReader(` [{"id":"poodle","name":"Poodle","size":"medium"}]`), FormatNDJSON)
+		if err != nil || len(got) != 1 || got[0].ID != "poodle" {
+			t.Errorf("unexpected result %+v (%v)", got, err)
+		}
+		got, err = ReadBreeds(strings.NewReader("ID,Name,Size\npoodle,Poodle,medium\n"), FormatCSV)
+		if err != nil || len(got) != 1 || got[0].Size != types.SizeMedium {
+			t.Errorf("unexpected result %+v (%v)", got, err)
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		for name, tc := range map[string]struct{ data, format string }{
+			"missing column": {"id,name\npoodle,Poodle\n", FormatCSV},
+			"bad id":         {"id,name,size\nPoodle Dog,Poodle,medium\n", FormatCSV},
+			"no name":        {`{"id":"poodle","size":"medium"}`, FormatNDJSON},
+			"unknown size":   {`{"id":"poodle","name":"Poodle","size":"huge"}`, FormatNDJSON},
+			"repeated":       {`{"id":"poodle","name":"Poodle","size":"medium"}` + "\n" + `{"id":"poodle","name":"Poodle","size":"medium"}`, FormatNDJSON},
+			"unknown field":  {`{"id":"poodle","name":"Poodle","size":"medium","color":"white"}`, FormatNDJSON},
+		} {
+			if _, err := ReadBreeds(strings.NewReader(tc.data), tc.format); !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("%s: expected ErrInvalidFile, got %v", name, err)
+			}
+		}
+	})
+}
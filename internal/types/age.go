@@ -0,0 +1,133 @@
+package types
+
+import "time"
+
+// BreedSize es el tamaño de una raza. Los perros grandes maduran más tarde y envejecen antes.
+type BreedSize string
+
+const (
+	SizeSmall  BreedSize = "small"
+	SizeMedium BreedSize = "medium"
+	SizeLarge  BreedSize = "large"
+	SizeGiant  BreedSize = "giant"
+)
+
+// LifeStage es la etapa de vida de una mascota según su edad y el tamaño de su raza.
+type LifeStage string
+
+const (
+	StagePuppy  LifeStage = "puppy"
+	StageAdult  LifeStage = "adult"
+	StageSenior LifeStage = "senior"
+)
+
+// sizeProfile reúne lo que cambia con el tamaño de la raza: a qué edad (en meses) deja de
+// ser cachorro y pasa a senior, y cuántos años humanos equivale cada año a partir del segundo.
+type sizeProfile struct {
+	adultMonths    int
+	seniorMonths   int
+	humanYearsStep float64
+}
+
+var sizeProfiles = map[BreedSize]sizeProfile{
+	SizeSmall:  {adultMonths: 10, seniorMonths: 11 * 12, humanYearsStep: 4},
+	SizeMedium: {adultMonths: 12, seniorMonths: 10 * 12, humanYearsStep: 5},
+	SizeLarge:  {adultMonths: 15, seniorMonths: 8 * 12, humanYearsStep: 6},
+	SizeGiant:  {adultMonths: 18, seniorMonths: 6 * 12, humanYearsStep: 7},
+}
+
+// Valid indica si s es uno de los tamaños conocidos.
+func (s BreedSize) Valid() bool {
+	_, ok := sizeProfiles[s]
+	return ok
+}
+
+// profile devuelve el perfil del tamaño; un tamaño desconocido se trata como mediano.
+func (s BreedSize) profile() sizeProfile {
+	if p, ok := sizeProfiles[s]; ok {
+		return p
+	}
+	return sizeProfiles[SizeMedium]
+}
+
+// Age es la edad en años y meses cumplidos.
+type Age struct {
+	Years  int `json:"years"`
+	Months int `json:"months"`
+}
+
+// AgeAt calcula la edad de alguien nacido en birth el día de now. Ambas fechas se comparan
+// como días de calendario: birth es una fecha sin hora (medianoche UTC, como la devuelven
+// los stores) y de now solo cuenta su fecha en UTC, para que el resultado no dependa de la
+// zona horaria del servidor. Un nacido el 29 de febrero cumple meses el último día del mes
+// cuando el mes no tiene ese día. Una fecha de nacimiento futura da edad cero.
+func AgeAt(birth, now time.Time) Age {
+	by, bm, bd := birth.UTC().Date()
+	ny, nm, nd := now.UTC().Date()
+
+	months := (ny-by)*12 + int(nm-bm)
+	if nd < bd && nd < daysIn(ny, nm) {
+		months--
+	}
+	if months < 0 {
+		return Age{}
+	}
+	return Age{Years: months / 12, Months: months % 12}
+}
+
+// daysIn devuelve el número de días del mes.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func (a Age) totalMonths() int {
+	return a.Years*12 + a.Months
+}
+
+// HumanYears estima la edad equivalente humana: 15 años el primer año, 9 más el segundo y
+// a partir de ahí entre 4 y 7 por año según el tamaño de la raza. Se redondea hacia abajo.
+func (a Age) HumanYears(size BreedSize) int {
+	years := float64(a.totalMonths()) / 12
+	switch {
+	case years < 1:
+		return int(years * 15)
+	case years < 2:
+		return int(15 + (years-1)*9)
+	default:
+		return int(24 + (years-2)*size.profile().humanYearsStep)
+	}
+}
+
+// LifeStage clasifica la edad según el tamaño de la raza.
+func (a Age) LifeStage(size BreedSize) LifeStage {
+	p := size.profile()
+	switch months := a.totalMonths(); {
+	case months < p.adultMonths:
+		return StagePuppy
+	case months < p.seniorMonths:
+		return StageAdult
+	default:
+		return StageSenior
+	}
+}
+
+// PetResponse es la representación de una mascota en la API: los datos guardados más los
+// calculados a partir de Birth y del tamaño de la raza, para que los clientes no tengan que
+// calcularlos cada uno a su manera.
+type PetResponse struct {
+	Pet
+	Age        Age       `json:"age"`
+	HumanYears int       `json:"humanYears"`
+	LifeStage  LifeStage `json:"lifeStage"`
+}
+
+// NewPetResponse calcula los datos derivados de p a fecha de now.
+func NewPetResponse(p Pet, now time.Time) PetResponse {
+	age := AgeAt(p.Birth, now)
+	return PetResponse{
+		Pet:        p,
+		Age:        age,
+		HumanYears: age.HumanYears(p.Breed.Size),
+		LifeStage:  age.LifeStage(p.Breed.Size),
+	}
+}
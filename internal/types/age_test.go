@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAgeAt(t *testing.T) {
+	tests := []struct {
+		name       string
+		birth, now time.Time
+		want       Age
+	}{
+		{"same day", date(2020, 5, 10), date(2020, 5, 10), Age{}},
+		{"day before the monthly birthday", date(2020, 5, 10), date(2020, 6, 9), Age{}},
+		{"monthly birthday", date(2020, 5, 10), date(2020, 6, 10), Age{Months: 1}},
+		{"years and months", date(2020, 5, 10), date(2023, 8, 11), Age{Years: 3, Months: 3}},
+		{"day before the birthday", date(2020, 5, 10), date(2023, 5, 9), Age{Years: 2, Months: 11}},
+		{"end of a shorter month", date(2020, 1, 31), date(2020, 2, 29), Age{Months: 1}},
+		{"leap day in a common year", date(2020, 2, 29), date(2021, 2, 28), Age{Years: 1}},
+		{"future birth", date(2030, 1, 1), date(2020, 1, 1), Age{}},
+		// La hora y la zona de now no cuentan: solo su fecha en UTC.
+		{"now late in a western zone", date(2020, 5, 10), time.Date(2021, 5, 9, 22, 0, 0, 0, time.FixedZone("UTC-3", -3*3600)), Age{Years: 1}},
+		{"now early in an eastern zone", date(2020, 5, 10), time.Date(2021, 5, 10, 1, 0, 0, 0, time.FixedZone("UTC+5", 5*3600)), Age{Months: 11}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AgeAt(tt.birth, tt.now); got != tt.want {
+				t.Errorf("AgeAt(%s, %s) = %+v, want %+v", tt.birth, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanYearsAndLifeStage(t *testing.T) {
+	tests := []struct {
+		age   Age
+		size  BreedSize
+		human int
+		stage LifeStage
+	}{
+		{Age{Months: 6}, SizeMedium, 7, StagePuppy},
+		{Age{Months: 11}, SizeSmall, 13, StageAdult},
+		{Age{Years: 1}, SizeLarge, 15, StagePuppy},
+		{Age{Years: 1, Months: 6}, SizeGiant, 19, StageAdult},
+		{Age{Years: 2}, SizeMedium, 24, StageAdult},
+		{Age{Years: 8}, SizeSmall, 48, StageAdult},
+		{Age{Years: 8}, SizeLarge, 60, StageSenior},
+		{Age{Years: 10}, SizeMedium, 64, StageSenior},
+		{Age{Years: 10}, SizeSmall, 56, StageAdult},
+		{Age{Years: 6}, SizeGiant, 52, StageSenior},
+		// Un tamaño desconocido se trata como mediano.
+		{Age{Years: 10}, "", 64, StageSenior},
+	}
+	for _, tt := range tests {
+		if got := tt.age.HumanYears(tt.size); got != tt.human {
+			t.Errorf("%+v %q: HumanYears = %d, want %d", tt.age, tt.size, got, tt.human)
+		}
+		if got := tt.age.LifeStage(tt.size); got != tt.stage {
+			t.Errorf("%+v %q: LifeStage = %s, want %s", tt.age, tt.size, got, tt.stage)
+		}
+	}
+}
+
+func TestPetResponseJSON(t *testing.T) {
+	pet := Pet{ID: "0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41", Name: "Fido", Birth: date(2015, 3, 1), Breed: Breed{ID: "poodle", Size: SizeLarge}}
+	body, err := json.Marshal(NewPetResponse(pet, date(2024, 4, 15)))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var got map[string]any
+	json.Unmarshal(body, &got)
+	if got["name"] != "Fido" || got["lifeStage"] != "senior" || got["humanYears"] != float64(66) {
+		t.Errorf("unexpected response: %s", body)
+	}
+	if age := got["age"].(map[string]any); age["years"] != float64(9) || age["months"] != float64(1) {
+		t.Errorf("unexpected age: %v", got["age"])
+	}
+}
@@ -0,0 +1,50 @@
+package types
+
+// Modos de las operaciones en lote.
+const (
+	// BulkAtomic aplica todos los elementos en una transacción, o ninguno.
+	BulkAtomic = "atomic"
+	// BulkPartial aplica cada elemento por separado: los válidos se aplican aunque otros fallen.
+	BulkPartial = "partial"
+)
+
+// BulkCreatePetsRequest es el cuerpo de POST /api/v1/pets/bulk. Mode es BulkAtomic (por
+// defecto) o BulkPartial.
+type BulkCreatePetsRequest struct {
+	Mode  string             `json:"mode,omitempty"`
+	Items []CreatePetRequest `json:"items"`
+}
+
+// BulkDeletePetsRequest es el cuerpo de POST /api/v1/pets/bulk/delete.
+type BulkDeletePetsRequest struct {
+	Mode string   `json:"mode,omitempty"`
+	IDs  []string `json:"ids"`
+}
+
+// Estados de BulkResult.
+const (
+	BulkCreated  = "created"
+	BulkDeleted  = "deleted"
+	BulkNotFound = "notFound"
+	// BulkInvalid: el elemento no es válido; Error explica por qué.
+	BulkInvalid = "invalid"
+	// BulkSkipped: en modo atómico, el elemento era válido pero no se aplicó porque otro falló.
+	BulkSkipped = "skipped"
+	// BulkFailed: el servidor no pudo aplicar el elemento; puede reintentarse.
+	BulkFailed = "failed"
+)
+
+// BulkResult es el resultado de un elemento del lote. Index es su posición en la solicitud.
+type BulkResult struct {
+	Index  int          `json:"index"`
+	Status string       `json:"status"`
+	ID     PetID        `json:"id,omitempty"`
+	Pet    *PetResponse `json:"pet,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// BulkResponse es la respuesta de las operaciones en lote, con un resultado por elemento
+// en el orden de la solicitud.
+type BulkResponse struct {
+	Results []BulkResult `json:"results"`
+}
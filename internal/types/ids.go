@@ -0,0 +1,80 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidID indica que un identificador no tiene el formato esperado.
+var ErrInvalidID = errors.New("invalid id")
+
+// PetID identifica una mascota. Es un UUID en forma canónica: 36 caracteres, con guiones
+// y en minúsculas, igual que lo devuelve Postgres.
+type PetID string
+
+// BreedID identifica una raza por su slug, p. ej. "golden-retriever".
+type BreedID string
+
+var breedSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// maxBreedIDLength limita el tamaño de un slug para no aceptar entradas arbitrariamente largas.
+const maxBreedIDLength = 64
+
+// ParsePetID valida un UUID (en mayúsculas o minúsculas) y lo devuelve normalizado.
+func ParsePetID(s string) (PetID, error) {
+	if !isUUID(s) {
+		return "", fmt.Errorf("%w: pet id %q is not a UUID", ErrInvalidID, s)
+	}
+	return PetID(strings.ToLower(s)), nil
+}
+
+// ParseUUID valida los IDs que no tienen tipo propio, como los de las pautas de medicación,
+// y los devuelve normalizados igual que ParsePetID.
+func ParseUUID(s string) (string, error) {
+	if !isUUID(s) {
+		return "", fmt.Errorf("%w: id %q is not a UUID", ErrInvalidID, s)
+	}
+	return strings.ToLower(s), nil
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !isHex(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ParseBreedID valida que s sea un slug: letras minúsculas, dígitos y guiones simples.
+func ParseBreedID(s string) (BreedID, error) {
+	if len(s) > maxBreedIDLength || !breedSlug.MatchString(s) {
+		return "", fmt.Errorf("%w: breed id %q is not a slug", ErrInvalidID, s)
+	}
+	return BreedID(s), nil
+}
+
+func (id PetID) String() string {
+	return string(id)
+}
+
+func (id BreedID) String() string {
+	return string(id)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePetID(t *testing.T) {
+	valid := map[string]PetID{
+		"0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41": "0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41",
+		"0B9E6C1A-3F0E-4C5B-9A57-2F1D2B9E8C41": "0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41",
+	}
+	for in, want := range valid {
+		got, err := ParsePetID(in)
+		if err != nil || got != want {
+			t.Errorf("ParsePetID(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"not-a-uuid",
+		"0b9e6c1a3f0e4c5b9a572f1d2b9e8c41",
+		"{0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c41}",
+		"0b9e6c1a-3f0e-4c5b-9a57-2f1d2b9e8c4g",
+		"0b9e6c1a_3f0e-4c5b-9a57-2f1d2b9e8c41",
+	}
+	for _, in := range invalid {
+		if _, err := ParsePetID(in); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("ParsePetID(%q): expected ErrInvalidID, got %v", in, err)
+		}
+	}
+}
+
+func TestParseBreedID(t *testing.T) {
+	for _, in := range []string{"poodle", "golden-retriever", "k9-2"} {
+		if got, err := ParseBreedID(in); err != nil || string(got) != in {
+			t.Errorf("ParseBreedID(%q) = %q, %v", in, got, err)
+		}
+	}
+
+	for _, in := range []string{"", "Poodle", "golden retriever", "-poodle", "poodle-", "golden--retriever", "../etc"} {
+		if _, err := ParseBreedID(in); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("ParseBreedID(%q): expected ErrInvalidID, got %v", in, err)
+		}
+	}
+}
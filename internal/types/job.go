@@ -0,0 +1,38 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobStatus es el estado de un trabajo del planificador.
+type JobStatus string
+
+const (
+	// JobPending espera a que llegue RunAt (o a su siguiente reintento).
+	JobPending JobStatus = "pending"
+	// JobRunning está reservado por un proceso hasta LockedUntil. Si el proceso muere, la
+	// reserva caduca y el trabajo se vuelve a entregar.
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	// JobFailed agotó sus intentos y no se vuelve a ejecutar.
+	JobFailed JobStatus = "failed"
+)
+
+// Job es un trabajo persistente del planificador. Key identifica el trabajo de forma única
+// (p. ej. "birthday:<pet>:2025") para que planificarlo dos veces no lo duplique. Payload es
+// el JSON que interpreta el manejador de Kind.
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Key         string          `json:"key"`
+	Payload     json.RawMessage `json:"payload"`
+	RunAt       time.Time       `json:"runAt"`
+	Status      JobStatus       `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	LastError   string          `json:"lastError,omitempty"`
+	LockedUntil *time.Time      `json:"lockedUntil,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
@@ -0,0 +1,116 @@
+package types
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// VetVisit es una visita al veterinario. Date es una fecha sin hora, como Pet.Birth.
+// NextDue, si se indica, es la fecha (sin hora) en que vence la próxima dosis de la vacuna
+// puesta en la visita; el planificador avisa al dueño ese día.
+type VetVisit struct {
+	ID          string       `json:"id"`
+	PetID       PetID        `json:"petId"`
+	Date        time.Time    `json:"date"`
+	Clinic      string       `json:"clinic"`
+	Reason      string       `json:"reason"`
+	Diagnosis   string       `json:"diagnosis"`
+	Treatments  []string     `json:"treatments"`
+	Attachments []Attachment `json:"attachments"`
+	NextDue     *time.Time   `json:"nextDue,omitempty"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// Attachment describe un archivo adjunto a una visita (informe, radiografía...). Solo se
+// guardan sus metadatos; el archivo vive en URL.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+}
+
+// ConditionKind distingue las enfermedades crónicas de las alergias.
+type ConditionKind string
+
+const (
+	KindCondition ConditionKind = "condition"
+	KindAllergy   ConditionKind = "allergy"
+)
+
+// Condition es una enfermedad o alergia de una mascota. Since, si se conoce, es la fecha
+// (sin hora) desde la que la tiene.
+type Condition struct {
+	ID        string        `json:"id"`
+	PetID     PetID         `json:"petId"`
+	Kind      ConditionKind `json:"kind"`
+	Name      string        `json:"name"`
+	Notes     string        `json:"notes"`
+	Since     *time.Time    `json:"since,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// CreateVetVisitRequest es el cuerpo de POST /api/v1/pets/{id}/medical/visits.
+type CreateVetVisitRequest struct {
+	Date        string       `json:"date"`
+	Clinic      string       `json:"clinic"`
+	Reason      string       `json:"reason"`
+	Diagnosis   string       `json:"diagnosis"`
+	Treatments  []string     `json:"treatments"`
+	Attachments []Attachment `json:"attachments"`
+	NextDue     string       `json:"nextDue"`
+}
+
+// CreateConditionRequest es el cuerpo de POST /api/v1/pets/{id}/medical/conditions.
+type CreateConditionRequest struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Notes string `json:"notes"`
+	Since string `json:"since"`
+}
+
+// TimelineEntry es un evento del historial médico: una visita o el diagnóstico de una
+// enfermedad o alergia. Type es "visit" o el Kind de la condición.
+type TimelineEntry struct {
+	Date      time.Time  `json:"date"`
+	Type      string     `json:"type"`
+	Visit     *VetVisit  `json:"visit,omitempty"`
+	Condition *Condition `json:"condition,omitempty"`
+}
+
+// MedicalHistory es la respuesta de GET /api/v1/pets/{id}/medical.
+type MedicalHistory struct {
+	PetID    PetID           `json:"petId"`
+	Timeline []TimelineEntry `json:"timeline"`
+}
+
+// NewMedicalHistory mezcla visitas y condiciones en una única línea de tiempo, de la más
+// antigua a la más reciente. Una condición sin Since se ubica en el día en que se registró.
+// Los empates se resuelven por fecha de registro, para que el orden sea estable.
+func NewMedicalHistory(petID PetID, visits []VetVisit, conditions []Condition) MedicalHistory {
+	timeline := make([]TimelineEntry, 0, len(visits)+len(conditions))
+	for _, v := range visits {
+		timeline = append(timeline, TimelineEntry{Date: v.Date, Type: "visit", Visit: &v})
+	}
+	for _, c := range conditions {
+		date := c.CreatedAt
+		if c.Since != nil {
+			date = *c.Since
+		}
+		y, m, d := date.UTC().Date()
+		timeline = append(timeline, TimelineEntry{Date: time.Date(y, m, d, 0, 0, 0, 0, time.UTC), Type: string(c.Kind), Condition: &c})
+	}
+
+	slices.SortStableFunc(timeline, func(a, b TimelineEntry) int {
+		return cmp.Or(a.Date.Compare(b.Date), a.createdAt().Compare(b.createdAt()))
+	})
+	return MedicalHistory{PetID: petID, Timeline: timeline}
+}
+
+func (e TimelineEntry) createdAt() time.Time {
+	if e.Visit != nil {
+		return e.Visit.CreatedAt
+	}
+	return e.Condition.CreatedAt
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewMedicalHistory(t *testing.T) {
+	registered := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	since := date(2021, 7, 1)
+	visits := []VetVisit{
+		{ID: "v1", Date: date(2023, 1, 10), CreatedAt: registered.Add(time.Hour)},
+		{ID: "v2", Date: date(2024, 3, 1), CreatedAt: registered.Add(2 * time.Hour)},
+	}
+	conditions := []Condition{
+		{ID: "c1", Kind: KindAllergy, Since: &since, CreatedAt: registered},
+		// Sin Since, la condición cuenta desde el día en que se registró.
+		{ID: "c2", Kind: KindCondition, CreatedAt: registered},
+	}
+
+	history := NewMedicalHistory("p1", visits, conditions)
+
+	var got []string
+	for _, e := range history.Timeline {
+		id := ""
+		if e.Visit != nil {
+			id = e.Visit.ID
+		} else {
+			id = e.Condition.ID
+		}
+		got = append(got, id+" "+e.Type+" "+e.Date.Format(time.DateOnly))
+	}
+	// El mismo día, lo registrado antes va primero.
+	want := []string{"c1 allergy 2021-07-01", "v1 visit 2023-01-10", "c2 condition 2024-03-01", "v2 visit 2024-03-01"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package types
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/recurrence"
+)
+
+// Medication es una pauta de medicación de una mascota. Frequency es una regla RRULE
+// (ver el paquete recurrence) que se aplica desde Start en la zona horaria TimeZone, para
+// que "BYHOUR=8" sean las 8 de la mañana del dueño. End, si se indica, es la última toma posible.
+type Medication struct {
+	ID        string     `json:"id"`
+	PetID     PetID      `json:"petId"`
+	Drug      string     `json:"drug"`
+	Dosage    string     `json:"dosage"`
+	Frequency string     `json:"frequency"`
+	TimeZone  string     `json:"timeZone"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// DoseStatus es el estado de una toma.
+type DoseStatus string
+
+const (
+	DosePending DoseStatus = "pending"
+	DoseGiven   DoseStatus = "given"
+	DoseSkipped DoseStatus = "skipped"
+)
+
+// DoseRecord anota que la toma prevista para ScheduledAt se dio o se saltó.
+type DoseRecord struct {
+	MedicationID string     `json:"medicationId"`
+	ScheduledAt  time.Time  `json:"scheduledAt"`
+	Status       DoseStatus `json:"status"`
+	Note         string     `json:"note"`
+	RecordedAt   time.Time  `json:"recordedAt"`
+}
+
+// Dose es una toma del calendario: prevista por la pauta y, si ya se anotó, con su estado.
+type Dose struct {
+	MedicationID string     `json:"medicationId"`
+	Drug         string     `json:"drug"`
+	Dosage       string     `json:"dosage"`
+	ScheduledAt  time.Time  `json:"scheduledAt"`
+	Status       DoseStatus `json:"status"`
+	Note         string     `json:"note,omitempty"`
+	RecordedAt   *time.Time `json:"recordedAt,omitempty"`
+}
+
+// CreateMedicationRequest es el cuerpo de POST /api/v1/pets/{id}/medications. Start y End
+// son fechas y horas RFC 3339; TimeZone es un nombre IANA (por defecto "UTC").
+type CreateMedicationRequest struct {
+	Drug      string `json:"drug"`
+	Dosage    string `json:"dosage"`
+	Frequency string `json:"frequency"`
+	TimeZone  string `json:"timeZone"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+}
+
+// RecordDoseRequest es el cuerpo de POST /api/v1/pets/{id}/medications/{medicationId}/doses.
+type RecordDoseRequest struct {
+	ScheduledAt string `json:"scheduledAt"`
+	Status      string `json:"status"`
+	Note        string `json:"note"`
+}
+
+// Schedule interpreta Frequency y TimeZone. Devuelve recurrence.ErrInvalidRule si la regla
+// no es válida.
+func (m Medication) Schedule() (recurrence.Rule, *time.Location, error) {
+	rule, err := recurrence.Parse(m.Frequency)
+	if err != nil {
+		return rule, nil, err
+	}
+	loc, err := time.LoadLocation(cmp.Or(m.TimeZone, "UTC"))
+	if err != nil || strings.EqualFold(m.TimeZone, "local") {
+		return rule, nil, fmt.Errorf("%w: unknown time zone %q", recurrence.ErrInvalidRule, m.TimeZone)
+	}
+	return rule, loc, nil
+}
+
+// DosesBetween devuelve las tomas previstas con from <= t < to, como mucho limit (0 es sin
+// límite), en la zona horaria de la pauta.
+func (m Medication) DosesBetween(from, to time.Time, limit int) ([]time.Time, error) {
+	rule, loc, err := m.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	if m.End != nil && !to.Before(*m.End) {
+		to = m.End.Add(time.Nanosecond)
+	}
+	return rule.Between(m.Start.In(loc), from, to, limit), nil
+}
+
+// FirstDose devuelve la primera toma prevista, u ok=false si la pauta no tiene ninguna
+// (p. ej. "FREQ=HOURLY;INTERVAL=24;BYHOUR=3" empezada a las 8, o un End anterior a la
+// primera toma).
+func (m Medication) FirstDose() (t time.Time, ok bool) {
+	doses, err := m.DosesBetween(m.Start, time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC), 1)
+	if err != nil || len(doses) == 0 {
+		return time.Time{}, false
+	}
+	return doses[0], true
+}
+
+// IsDose indica si t es una de las tomas previstas.
+func (m Medication) IsDose(t time.Time) bool {
+	doses, err := m.DosesBetween(t, t.Add(time.Nanosecond), 1)
+	return err == nil && len(doses) == 1 && doses[0].Equal(t)
+}
+
+// ScheduleDoses mezcla las tomas previstas de todas las pautas con las ya anotadas, en
+// orden cronológico, con from <= t < to y como mucho limit. Las tomas sin anotar quedan
+// como DosePending.
+func ScheduleDoses(meds []Medication, records []DoseRecord, from, to time.Time, limit int) ([]Dose, error) {
+	type key struct {
+		medicationID string
+		at           int64
+	}
+	recorded := make(map[key]DoseRecord, len(records))
+	for _, r := range records {
+		recorded[key{r.MedicationID, r.ScheduledAt.UnixNano()}] = r
+	}
+
+	doses := []Dose{}
+	for _, m := range meds {
+		times, err := m.DosesBetween(from, to, limit)
+		if err != nil {
+			return nil, fmt.Errorf("medication %s: %w", m.ID, err)
+		}
+		for _, t := range times {
+			d := Dose{MedicationID: m.ID, Drug: m.Drug, Dosage: m.Dosage, ScheduledAt: t, Status: DosePending}
+			if r, ok := recorded[key{m.ID, t.UnixNano()}]; ok {
+				d.Status, d.Note, d.RecordedAt = r.Status, r.Note, &r.RecordedAt
+			}
+			doses = append(doses, d)
+		}
+	}
+
+	slices.SortStableFunc(doses, func(a, b Dose) int {
+		return cmp.Or(a.ScheduledAt.Compare(b.ScheduledAt), strings.Compare(a.Drug, b.Drug))
+	})
+	if limit > 0 && len(doses) > limit {
+		doses = doses[:limit]
+	}
+	return doses, nil
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleDoses(t *testing.T) {
+	at := func(d, h int) time.Time { return time.Date(2024, 1, d, h, 0, 0, 0, time.UTC) }
+	end := at(2, 20)
+	meds := []Medication{
+		{ID: "m1", Drug: "Carprofen", Frequency: "FREQ=DAILY;BYHOUR=8,20", Start: at(1, 8), End: &end},
+		{ID: "m2", Drug: "Amoxicillin", Frequency: "FREQ=HOURLY;INTERVAL=12", Start: at(1, 8)},
+	}
+	records := []DoseRecord{
+		{MedicationID: "m1", ScheduledAt: at(2, 8), Status: DoseSkipped, Note: "Vomited", RecordedAt: at(2, 9)},
+	}
+
+	doses, err := ScheduleDoses(meds, records, at(2, 0), at(3, 12), 0)
+	if err != nil {
+		t.Fatalf("ScheduleDoses failed: %v", err)
+	}
+	want := []struct {
+		drug   string
+		at     time.Time
+		status DoseStatus
+	}{
+		// A la misma hora, por orden alfabético del medicamento.
+		{"Amoxicillin", at(2, 8), DosePending},
+		{"Carprofen", at(2, 8), DoseSkipped},
+		{"Amoxicillin", at(2, 20), DosePending},
+		{"Carprofen", at(2, 20), DosePending},
+		{"Amoxicillin", at(3, 8), DosePending},
+	}
+	if len(doses) != len(want) {
+		t.Fatalf("expected %d doses, got %+v", len(want), doses)
+	}
+	for i, w := range want {
+		d := doses[i]
+		if d.Drug != w.drug || !d.ScheduledAt.Equal(w.at) || d.Status != w.status {
+			t.Errorf("dose %d: expected %s at %v (%s), got %+v", i, w.drug, w.at, w.status, d)
+		}
+	}
+	if doses[1].Note != "Vomited" || doses[1].RecordedAt == nil {
+		t.Errorf("expected the recorded note and time, got %+v", doses[1])
+	}
+
+	if limited, _ := ScheduleDoses(meds, nil, at(2, 0), at(3, 12), 2); len(limited) != 2 {
+		t.Errorf("expected 2 doses with a limit, got %d", len(limited))
+	}
+	if _, err := ScheduleDoses([]Medication{{ID: "bad", Frequency: "FREQ=YEARLY"}}, nil, at(2, 0), at(3, 0), 0); err == nil {
+		t.Error("expected an error for an invalid frequency")
+	}
+}
@@ -0,0 +1,80 @@
+package types
+
+// PetChange es un cambio de una mascota tal como lo guarda el store, en orden de Seq. Pet es
+// la mascota después del cambio (nil si se borró).
+type PetChange struct {
+	Seq     int64
+	PetID   PetID
+	Deleted bool
+	Pet     *Pet
+}
+
+// Operaciones de SyncChange y SyncMutation.
+const (
+	SyncUpsert = "upsert"
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// SyncResponse es la respuesta de GET /api/v1/sync. SyncToken es opaco: el cliente lo
+// guarda y lo reenvía en la siguiente sincronización. Si HasMore es true, hay más cambios
+// y el cliente debe volver a pedirlos ya con el nuevo token.
+type SyncResponse struct {
+	Changes   []SyncChange `json:"changes"`
+	SyncToken string       `json:"syncToken"`
+	HasMore   bool         `json:"hasMore"`
+}
+
+// SyncChange es el estado final de una mascota: "upsert" con la mascota, o "delete" (la
+// lápida de una mascota borrada) solo con su ID.
+type SyncChange struct {
+	Op  string       `json:"op"`
+	ID  PetID        `json:"id"`
+	Pet *PetResponse `json:"pet,omitempty"`
+}
+
+// SyncRequest es el cuerpo de POST /api/v1/sync: los cambios hechos sin conexión, que se
+// aplican en orden y de forma independiente.
+type SyncRequest struct {
+	Mutations []SyncMutation `json:"mutations"`
+}
+
+// SyncMutation es un cambio hecho en el cliente. ClientID lo elige el cliente para
+// reconocer su resultado. BaseVersion es la versión de la mascota sobre la que se hizo un
+// "update" o "delete", y es obligatoria en ambos.
+type SyncMutation struct {
+	ClientID    string `json:"clientId"`
+	Op          string `json:"op"`
+	ID          string `json:"id,omitempty"`
+	BaseVersion int64  `json:"baseVersion,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Birth       string `json:"birth,omitempty"`
+	BreedID     string `json:"breedId,omitempty"`
+}
+
+// Estados de SyncResult.
+const (
+	// SyncApplied: el cambio se aplicó (o, en un borrado, la mascota ya no existía).
+	SyncApplied = "applied"
+	// SyncConflict: la mascota cambió o se borró en el servidor desde BaseVersion. Pet es
+	// la versión actual (nil si se borró).
+	SyncConflict = "conflict"
+	// SyncInvalid: el cambio no es válido; Error explica por qué.
+	SyncInvalid = "invalid"
+	// SyncFailed: el servidor no pudo aplicar el cambio; puede reintentarse.
+	SyncFailed = "failed"
+)
+
+// SyncResult es el resultado de una SyncMutation, en el mismo orden que la solicitud.
+type SyncResult struct {
+	ClientID string       `json:"clientId"`
+	Status   string       `json:"status"`
+	Pet      *PetResponse `json:"pet,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// SyncPushResponse es la respuesta de POST /api/v1/sync.
+type SyncPushResponse struct {
+	Results []SyncResult `json:"results"`
+}
@@ -1,19 +1,48 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Breed struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Temperament string `json:"temperament"`
-	Origin      string `json:"origin"`
+	ID          BreedID `json:"id"`
+	Name        string  `json:"name"`
+	Temperament string  `json:"temperament"`
+	Origin      string  `json:"origin"`
+	// Size ajusta la edad equivalente humana y las etapas de vida (ver NewPetResponse).
+	Size BreedSize `json:"size"`
+}
+
+// Validate comprueba que la raza se pueda guardar: un ID con formato de slug, un nombre y un
+// tamaño conocido.
+func (b Breed) Validate() error {
+	if _, err := ParseBreedID(b.ID.String()); err != nil {
+		return err
+	}
+	if strings.TrimSpace(b.Name) == "" {
+		return errors.New("name is required")
+	}
+	if !b.Size.Valid() {
+		return fmt.Errorf("unknown size %q", b.Size)
+	}
+	return nil
 }
 
 type Pet struct {
-	ID    string    `json:"id"`
+	ID    PetID     `json:"id"`
 	Name  string    `json:"name"`
 	Birth time.Time `json:"birth"`
 	Breed Breed     `json:"breed"`
+	// Owner es el sujeto autenticado que creó la mascota, o "" si se creó sin sujeto (p. ej.
+	// con los fixtures). No cambia después del alta.
+	Owner string `json:"owner,omitempty"`
+	// Version se incrementa en cada modificación y se usa como ETag para el control de concurrencia.
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 type CreatePetRequest struct {
@@ -21,3 +50,23 @@ type CreatePetRequest struct {
 	Birth   string `json:"birth"` // La fecha se envía como un string, luego la convertiremos a time.Time
 	BreedID string `json:"breedId"`
 }
+
+// UpdatePetRequest reemplaza todos los campos editables de una mascota.
+type UpdatePetRequest struct {
+	Name    string `json:"name"`
+	Birth   string `json:"birth"`
+	BreedID string `json:"breedId"`
+}
+
+// AuditEntry registra un cambio sobre una entidad, con su estado antes y después.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	EntityType string          `json:"entityType"`
+	EntityID   string          `json:"entityId"`
+	Action     string          `json:"action"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"requestId"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
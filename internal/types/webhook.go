@@ -0,0 +1,64 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Webhook es la suscripción de un socio (clínica, aseguradora...) a los eventos del BFF.
+// Events son filtros: un tipo exacto ("pet.created"), todos los de una entidad ("pet.*")
+// o todos ("*"). Secret firma las entregas y solo se devuelve al crear la suscripción.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Matches indica si el webhook está suscrito a eventType.
+func (w Webhook) Matches(eventType string) bool {
+	entity, _, _ := strings.Cut(eventType, ".")
+	for _, f := range w.Events {
+		if f == "*" || f == eventType || f == entity+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus es el estado de una entrega de webhook.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending está en la cola, o a la espera de un reintento.
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryDead agotó sus reintentos (dead letter) y no se vuelve a enviar.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookDelivery es el envío de un evento a un webhook. Payload es el cuerpo que se envía
+// y ResponseStatus, el código HTTP de la última respuesta (0 si no hubo respuesta).
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      string          `json:"webhookId"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+}
+
+// CreateWebhookRequest es el cuerpo de POST /api/v1/admin/webhooks. Si Secret está vacío
+// se genera uno.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
@@ -0,0 +1,243 @@
+// Package webhooks entrega los eventos de dominio (internal/events) a las suscripciones
+// guardadas en un store.WebhookStore. El store guarda cada evento como un trabajo
+// store.KindEvent en la misma transacción que el cambio; cuando el planificador
+// (internal/scheduler) lo ejecuta, se registra y se encola una entrega por webhook
+// suscrito. Las entregas se reintentan con espera exponencial y, cuando se agotan los
+// intentos, quedan como types.DeliveryDead.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/scheduler"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// KindDelivery es el tipo de trabajo de las entregas.
+const KindDelivery = "webhook-delivery"
+
+// Cabeceras de cada entrega. Idempotency-Key lleva el ID del evento para que el receptor
+// descarte las entregas repetidas.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Config ajusta las entregas.
+type Config struct {
+	// Timeout es el plazo de cada petición al receptor.
+	Timeout time.Duration
+}
+
+// DefaultConfig devuelve la configuración por defecto de las entregas.
+func DefaultConfig() Config {
+	return Config{Timeout: 10 * time.Second}
+}
+
+// Dispatcher convierte cada evento del outbox en una entrega por webhook suscrito y envía
+// las entregas, ambas cosas cuando el planificador ejecuta sus trabajos.
+type Dispatcher struct {
+	store  store.WebhookStore
+	client *http.Client
+	sched  *scheduler.Scheduler
+	now    func() time.Time
+}
+
+func NewDispatcher(ws store.WebhookStore, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		store:  ws,
+		client: &http.Client{Timeout: cfg.Timeout},
+		now:    time.Now,
+	}
+}
+
+// deliveryPayload es el Payload de KindDelivery. Body es el evento tal como se firma y
+// se envía.
+type deliveryPayload struct {
+	DeliveryID int64           `json:"deliveryId"`
+	WebhookID  string          `json:"webhookId"`
+	EventID    string          `json:"eventId"`
+	EventType  string          `json:"eventType"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// Register añade a s los Handler de los eventos del outbox y de las entregas, que se
+// encolan en s.
+func (d *Dispatcher) Register(s *scheduler.Scheduler) {
+	d.sched = s
+	s.Handle(store.KindEvent, d.dispatchJob)
+	s.Handle(KindDelivery, d.deliver)
+}
+
+// dispatchJob reparte el evento de un trabajo store.KindEvent. Si falla, el trabajo se
+// reintenta entero, así que un webhook puede recibir la misma entrega dos veces; el
+// receptor las reconoce por el Idempotency-Key.
+func (d *Dispatcher) dispatchJob(ctx context.Context, job types.Job) error {
+	var e events.Event
+	if err := json.Unmarshal(job.Payload, &e); err != nil {
+		return scheduler.Permanent(fmt.Errorf("invalid event: %w", err))
+	}
+	return d.dispatch(ctx, e)
+}
+
+// dispatch registra y encola una entrega por cada webhook suscrito al evento.
+func (d *Dispatcher) dispatch(ctx context.Context, e events.Event) error {
+	hooks, err := d.store.GetWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	var body []byte
+	var errs []error
+	for _, w := range hooks {
+		if !w.Matches(e.Type) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(e); err != nil {
+				return fmt.Errorf("failed to marshal event: %w", err)
+			}
+		}
+		delivery, err := d.store.AddDelivery(ctx, types.WebhookDelivery{WebhookID: w.ID, EventID: e.ID, EventType: e.Type, Payload: body})
+		if errors.Is(err, store.ErrNotFound) {
+			// El webhook se borró mientras se repartía el evento.
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", w.ID, err))
+			continue
+		}
+		payload := deliveryPayload{DeliveryID: delivery.ID, WebhookID: w.ID, EventID: e.ID, EventType: e.Type, Body: body}
+		key := fmt.Sprintf("%s:%d", KindDelivery, delivery.ID)
+		if _, err := d.sched.Enqueue(ctx, KindDelivery, key, d.now(), payload); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", w.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver envía un intento de la entrega y anota el resultado en el store. Las entregas de
+// webhooks borrados se descartan sin error.
+func (d *Dispatcher) deliver(ctx context.Context, job types.Job) error {
+	var p deliveryPayload
+	if err := json.Unmarshal(job.Payload, &p); err != nil {
+		return scheduler.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+	w, err := d.store.GetWebhook(ctx, p.WebhookID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	code, err := d.send(ctx, w, p)
+	status, lastError := types.DeliverySucceeded, ""
+	if err != nil {
+		lastError = err.Error()
+		status = types.DeliveryPending
+		if errors.Is(err, scheduler.ErrPermanent) || job.Attempts >= job.MaxAttempts {
+			status = types.DeliveryDead
+		}
+	}
+	if recErr := d.store.RecordDeliveryAttempt(ctx, p.DeliveryID, status, code, lastError); recErr != nil && !errors.Is(recErr, store.ErrNotFound) {
+		return errors.Join(err, fmt.Errorf("failed to record the attempt of delivery %d: %w", p.DeliveryID, recErr))
+	}
+	return err
+}
+
+// send hace el POST firmado y devuelve el código de la respuesta (0 si no la hubo). Las
+// respuestas 2xx son un éxito; 410 Gone indica que el receptor no quiere más entregas.
+func (d *Dispatcher) send(ctx context.Context, w *types.Webhook, p deliveryPayload) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(p.Body))
+	if err != nil {
+		return 0, scheduler.Permanent(fmt.Errorf("failed to create webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dog-app-bff-webhooks")
+	req.Header.Set(SignatureHeader, Sign(w.Secret, d.now(), p.Body))
+	req.Header.Set(EventHeader, p.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(p.DeliveryID, 10))
+	req.Header.Set("Idempotency-Key", p.EventID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// Se vacía el cuerpo para que la conexión pueda reutilizarse.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return resp.StatusCode, nil
+	case resp.StatusCode == http.StatusGone:
+		return resp.StatusCode, scheduler.Permanent(fmt.Errorf("webhook responded %s", resp.Status))
+	default:
+		return resp.StatusCode, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+}
+
+// Sign devuelve el valor de SignatureHeader: "t=<unix>,v1=<firma>", donde la firma es el
+// HMAC-SHA256 en hexadecimal de "<unix>.<body>" con secret como clave. Incluir el momento
+// en la firma permite al receptor rechazar las entregas reenviadas por un tercero.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, body)
+}
+
+func signature(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrInvalidSignature indica que la firma no corresponde al cuerpo o está caducada.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Verify comprueba header (el valor de SignatureHeader) contra body y secret, y que la
+// firma no tenga más de tolerance de antigüedad respecto de now. Es lo que debe hacer el
+// receptor de cada entrega.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+	want := signature(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+}
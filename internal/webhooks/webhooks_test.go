@@ -0,0 +1,182 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agugliotta/dog-app-bff/internal/audit"
+	"github.com/agugliotta/dog-app-bff/internal/events"
+	"github.com/agugliotta/dog-app-bff/internal/scheduler"
+	"github.com/agugliotta/dog-app-bff/internal/store"
+	"github.com/agugliotta/dog-app-bff/internal/types"
+)
+
+// receiver es un receptor de webhooks que guarda lo que recibe y responde status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.status)
+}
+
+func newTestDispatcher(maxAttempts int) (*scheduler.Scheduler, *store.MemoryStore) {
+	ms := store.NewMemoryStore([]types.Breed{{ID: "b1", Name: "Breed1"}})
+	cfg := scheduler.DefaultConfig()
+	cfg.MaxAttempts = maxAttempts
+	s := scheduler.New(ms, cfg)
+	NewDispatcher(ms, DefaultConfig()).Register(s)
+	return s, ms
+}
+
+// runJobs ejecuta los trabajos vencidos, incluidos los que encolan otros trabajos, hasta
+// que no quede ninguno.
+func runJobs(t *testing.T, s *scheduler.Scheduler) {
+	t.Helper()
+	for {
+		n, err := s.RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("RunOnce failed: %v", err)
+		}
+		if n == 0 {
+			return
+		}
+	}
+}
+
+func TestDeliversSignedEvents(t *testing.T) {
+	ctx := audit.WithMeta(context.Background(), audit.Meta{Actor: "alice"})
+	rcv := &receiver{status: http.StatusNoContent}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	s, ms := newTestDispatcher(3)
+	all, _ := ms.CreateWebhook(ctx, types.Webhook{URL: srv.URL + "/all", Events: []string{"pet.*"}, Secret: "s3cret"})
+	deletes, _ := ms.CreateWebhook(ctx, types.Webhook{URL: srv.URL + "/deletes", Events: []string{events.PetDeleted}, Secret: "other"})
+
+	pet, err := ms.CreatePet(ctx, "Fido", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "b1")
+	if err != nil {
+		t.Fatalf("CreatePet failed: %v", err)
+	}
+	runJobs(t, s)
+
+	if len(rcv.requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(rcv.requests))
+	}
+	req, body := rcv.requests[0], rcv.bodies[0]
+	var got events.Event
+	if err := json.Unmarshal(body, &got); err != nil || got.Type != events.PetCreated || got.EntityID != pet.ID.String() || got.Actor != "alice" {
+		t.Fatalf("unexpected body %s (%v)", body, err)
+	}
+	if req.URL.Path != "/all" || req.Header.Get(EventHeader) != events.PetCreated || req.Header.Get("Idempotency-Key") != got.ID {
+		t.Errorf("unexpected request %s %v", req.URL.Path, req.Header)
+	}
+	if err := Verify("s3cret", req.Header.Get(SignatureHeader), body, time.Now(), time.Minute); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	var data types.Pet
+	if err := json.Unmarshal(got.Data, &data); err != nil || data.Name != "Fido" {
+		t.Errorf("unexpected event data %s (%v)", got.Data, err)
+	}
+
+	deliveries, err := ms.GetDeliveries(ctx, all.ID, 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("expected one delivery, got %+v (%v)", deliveries, err)
+	}
+	if dl := deliveries[0]; dl.Status != types.DeliverySucceeded || dl.Attempts != 1 || dl.ResponseStatus != http.StatusNoContent || dl.EventID != got.ID {
+		t.Errorf("unexpected delivery %+v", dl)
+	}
+	if deliveries, _ := ms.GetDeliveries(ctx, deletes.ID, 10); len(deliveries) != 0 {
+		t.Errorf("expected no delivery to a webhook not subscribed to %s, got %+v", got.Type, deliveries)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	rcv := &receiver{status: http.StatusInternalServerError}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	s, ms := newTestDispatcher(1)
+	w, _ := ms.CreateWebhook(ctx, types.Webhook{URL: srv.URL, Events: []string{"*"}, Secret: "s3cret"})
+	if _, err := ms.CreatePet(ctx, "Fido", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "b1"); err != nil {
+		t.Fatalf("CreatePet failed: %v", err)
+	}
+	runJobs(t, s)
+
+	deliveries, err := ms.GetDeliveries(ctx, w.ID, 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("expected one delivery, got %+v (%v)", deliveries, err)
+	}
+	if dl := deliveries[0]; dl.Status != types.DeliveryDead || dl.Attempts != 1 || dl.ResponseStatus != http.StatusInternalServerError || dl.LastError == "" {
+		t.Errorf("expected a dead delivery, got %+v", dl)
+	}
+}
+
+func TestDeletedWebhookIsSkipped(t *testing.T) {
+	ctx := context.Background()
+	rcv := &receiver{status: http.StatusOK}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	s, ms := newTestDispatcher(3)
+	w, _ := ms.CreateWebhook(ctx, types.Webhook{URL: srv.URL, Events: []string{"*"}, Secret: "s3cret"})
+	if _, err := ms.CreatePet(ctx, "Fido", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "b1"); err != nil {
+		t.Fatalf("CreatePet failed: %v", err)
+	}
+	if err := ms.DeleteWebhook(ctx, w.ID); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	runJobs(t, s)
+	if len(rcv.requests) != 0 {
+		t.Errorf("expected no request to a deleted webhook, got %d", len(rcv.requests))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"pet.created"}`)
+	header := Sign("s3cret", now, body)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		valid  bool
+	}{
+		{"valid", "s3cret", header, body, now.Add(time.Minute), true},
+		{"rotated secret", "s3cret", header + ",v1=deadbeef", body, now, true},
+		{"wrong secret", "other", header, body, now, false},
+		{"tampered body", "s3cret", header, []byte(`{"type":"pet.deleted"}`), now, false},
+		{"too old", "s3cret", header, body, now.Add(10 * time.Minute), false},
+		{"malformed", "s3cret", "v1=abc", body, now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tt.now, 5*time.Minute)
+			if tt.valid && err != nil {
+				t.Errorf("expected a valid signature, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+}